@@ -1,6 +1,9 @@
 package config
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"log"
 	"os"
 	"strconv"
@@ -34,6 +37,50 @@ type Config struct {
 	CloudinaryAPIKey       string
 	CloudinaryAPISecret    string
 	CloudinaryUploadFolder string
+
+	// Paystack
+	PaystackSecretKey   string
+	PaystackBaseURL     string
+	PaystackCallbackURL string
+	PaystackClient      string
+
+	// Payment reconciliation
+	ReconcileAfterMinutes    int
+	ReconcileIntervalMinutes int
+
+	// Business details printed on invoices
+	BusinessName    string
+	BusinessAddress string
+	BusinessEmail   string
+	BusinessPhone   string
+	BusinessTaxID   string
+	InvoicePrefix   string
+
+	// Customer accounts
+	CustomerLoginURL    string
+	MagicLinkTTLMinutes int
+
+	// Email
+	Mailer       string // "smtp", "file" or "memory"
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPTLSMode  string // "starttls", "tls" (implicit, usually port 465) or "none"
+	MailFrom     string
+	MailDir      string // Where the file mailer writes .eml files
+	CEOEmail     string // Gets new lead notifications when no notification rule matches
+
+	// Email outbox
+	EmailMaxAttempts           int // Attempts before a message is dead-lettered
+	EmailWorkerIntervalSeconds int
+
+	// Newsletter
+	APIBaseURL                string // Public URL of this API, for one-click unsubscribe links
+	NewsletterConfirmURL      string
+	NewsletterUnsubscribeURL  string
+	NewsletterSecret          string // Signs unsubscribe links; derived from the JWT secret when unset
+	NewsletterConfirmTTLHours int
 }
 
 var AppConfig *Config
@@ -46,6 +93,13 @@ func LoadConfig() *Config {
 	}
 
 	jwtExpiry, _ := strconv.Atoi(getEnv("JWT_EXPIRY_HOURS", "24"))
+	reconcileAfter, _ := strconv.Atoi(getEnv("RECONCILE_AFTER_MINUTES", "30"))
+	reconcileInterval, _ := strconv.Atoi(getEnv("RECONCILE_INTERVAL_MINUTES", "15"))
+	magicLinkTTL, _ := strconv.Atoi(getEnv("MAGIC_LINK_TTL_MINUTES", "15"))
+	smtpPort, _ := strconv.Atoi(getEnv("SMTP_PORT", "587"))
+	emailMaxAttempts, _ := strconv.Atoi(getEnv("EMAIL_MAX_ATTEMPTS", "8"))
+	emailWorkerInterval, _ := strconv.Atoi(getEnv("EMAIL_WORKER_INTERVAL_SECONDS", "15"))
+	newsletterConfirmTTL, _ := strconv.Atoi(getEnv("NEWSLETTER_CONFIRM_TTL_HOURS", "48"))
 
 	AppConfig = &Config{
 		// Database
@@ -73,6 +127,57 @@ func LoadConfig() *Config {
 		CloudinaryAPIKey:       getEnv("CLOUDINARY_API_KEY", ""),
 		CloudinaryAPISecret:    getEnv("CLOUDINARY_API_SECRET", ""),
 		CloudinaryUploadFolder: getEnv("CLOUDINARY_UPLOAD_FOLDER", "plantbased"),
+
+		// Paystack
+		PaystackSecretKey:   getEnv("PAYSTACK_SECRET_KEY", ""),
+		PaystackBaseURL:     getEnv("PAYSTACK_BASE_URL", "https://api.paystack.co"),
+		PaystackCallbackURL: getEnv("PAYSTACK_CALLBACK_URL", ""),
+		PaystackClient:      getEnv("PAYSTACK_CLIENT", "http"), // "http" or "fake"
+
+		// Payment reconciliation
+		ReconcileAfterMinutes:    reconcileAfter,
+		ReconcileIntervalMinutes: reconcileInterval,
+
+		// Business details printed on invoices
+		BusinessName:    getEnv("BUSINESS_NAME", "PlantBased Meals"),
+		BusinessAddress: getEnv("BUSINESS_ADDRESS", ""), // Use "|" to separate lines
+		BusinessEmail:   getEnv("BUSINESS_EMAIL", ""),
+		BusinessPhone:   getEnv("BUSINESS_PHONE", ""),
+		BusinessTaxID:   getEnv("BUSINESS_TAX_ID", ""),
+		InvoicePrefix:   getEnv("INVOICE_PREFIX", "INV"),
+
+		// Customer accounts
+		CustomerLoginURL:    getEnv("CUSTOMER_LOGIN_URL", "https://plantbasedmeals.netlify.app/account/login"), // The token is appended as ?token=
+		MagicLinkTTLMinutes: magicLinkTTL,
+
+		// Email
+		Mailer:       getEnv("MAILER", "smtp"),
+		SMTPHost:     getEnv("SMTP_HOST", "smtp.gmail.com"),
+		SMTPPort:     smtpPort,
+		SMTPUsername: getEnv("SMTP_USERNAME", getEnv("SMTP_EMAIL", "")),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		SMTPTLSMode:  getEnv("SMTP_TLS_MODE", "starttls"),
+		MailFrom:     getEnv("MAIL_FROM", getEnv("SMTP_EMAIL", "")),
+		MailDir:      getEnv("MAIL_DIR", "mail"),
+		CEOEmail:     getEnv("CEO_EMAIL", ""),
+
+		// Email outbox
+		EmailMaxAttempts:           emailMaxAttempts,
+		EmailWorkerIntervalSeconds: emailWorkerInterval,
+
+		// Newsletter
+		APIBaseURL:                getEnv("API_BASE_URL", "http://localhost:8080"),
+		NewsletterConfirmURL:      getEnv("NEWSLETTER_CONFIRM_URL", "https://plantbasedmeals.netlify.app/newsletter/confirm"),         // The token is appended as ?token=
+		NewsletterUnsubscribeURL:  getEnv("NEWSLETTER_UNSUBSCRIBE_URL", "https://plantbasedmeals.netlify.app/newsletter/unsubscribe"), // The email and signature are appended
+		NewsletterSecret:          getEnv("NEWSLETTER_SECRET", ""),
+		NewsletterConfirmTTLHours: newsletterConfirmTTL,
+	}
+
+	// Unsubscribe links are never signed with the JWT secret itself, so a
+	// signature from one can never be passed off as the other
+	if AppConfig.NewsletterSecret == "" {
+		log.Println("NEWSLETTER_SECRET is not set, deriving it from JWT_SECRET")
+		AppConfig.NewsletterSecret = deriveSecret(AppConfig.JWTSecret, "newsletter-unsubscribe-links")
 	}
 
 	return AppConfig
@@ -85,4 +190,11 @@ func getEnv(key, defaultValue string) string {
 		return defaultValue
 	}
 	return value
+}
+
+// deriveSecret derives a separate key for one purpose from a secret
+func deriveSecret(secret, label string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(label))
+	return hex.EncodeToString(mac.Sum(nil))
 }
\ No newline at end of file
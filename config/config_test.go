@@ -0,0 +1,26 @@
+package config
+
+import "testing"
+
+func TestNewsletterSecret(t *testing.T) {
+	t.Setenv("JWT_SECRET", "jwt-secret")
+	t.Setenv("NEWSLETTER_SECRET", "")
+
+	derived := LoadConfig().NewsletterSecret
+	if derived == "" || derived == "jwt-secret" {
+		t.Errorf("NewsletterSecret = %q, want a key derived from the JWT secret", derived)
+	}
+	if again := LoadConfig().NewsletterSecret; again != derived {
+		t.Errorf("derived secret is not stable: %q then %q", derived, again)
+	}
+
+	t.Setenv("JWT_SECRET", "another-jwt-secret")
+	if rotated := LoadConfig().NewsletterSecret; rotated == derived {
+		t.Error("derived secret does not depend on the JWT secret")
+	}
+
+	t.Setenv("NEWSLETTER_SECRET", "newsletter-secret")
+	if got := LoadConfig().NewsletterSecret; got != "newsletter-secret" {
+		t.Errorf("NewsletterSecret = %q, want NEWSLETTER_SECRET", got)
+	}
+}
@@ -72,7 +72,10 @@ func RunMigrations(db *sql.DB) error {
 		program_id INTEGER NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
 		name VARCHAR(255) NOT NULL,
 		subtitle TEXT NOT NULL,
-		price VARCHAR(50) NOT NULL,
+		price_amount BIGINT NOT NULL,
+		currency VARCHAR(3) NOT NULL DEFAULT 'NGN',
+		billing_interval VARCHAR(20) NOT NULL DEFAULT 'one_off',
+		paystack_plan_code VARCHAR(100) UNIQUE,
 		features JSONB NOT NULL,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
@@ -92,6 +95,30 @@ func RunMigrations(db *sql.DB) error {
 		return fmt.Errorf("failed to create program_id index: %w", err)
 	}
 
+	// Move pricing plans from the free-text price column to minor units + currency
+	addPricingPlanMoneyColumns := `
+	ALTER TABLE program_pricing_plans ADD COLUMN IF NOT EXISTS price_amount BIGINT;
+	ALTER TABLE program_pricing_plans ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'NGN';
+	`
+
+	if _, err := db.Exec(addPricingPlanMoneyColumns); err != nil {
+		return fmt.Errorf("failed to add pricing plan money columns: %w", err)
+	}
+
+	if err := migratePricingPlanPrices(db); err != nil {
+		return fmt.Errorf("failed to migrate pricing plan prices: %w", err)
+	}
+
+	// Billing interval and Paystack plan for recurring pricing plans
+	addPricingPlanIntervalColumns := `
+	ALTER TABLE program_pricing_plans ADD COLUMN IF NOT EXISTS billing_interval VARCHAR(20) NOT NULL DEFAULT 'one_off';
+	ALTER TABLE program_pricing_plans ADD COLUMN IF NOT EXISTS paystack_plan_code VARCHAR(100) UNIQUE;
+	`
+
+	if _, err := db.Exec(addPricingPlanIntervalColumns); err != nil {
+		return fmt.Errorf("failed to add pricing plan interval columns: %w", err)
+	}
+
 	// Create testimonials table
 	createTestimonialsTable := `
 	CREATE TABLE IF NOT EXISTS testimonials (
@@ -100,6 +127,7 @@ func RunMigrations(db *sql.DB) error {
 		location VARCHAR(255) NOT NULL,
 		review TEXT NOT NULL,
 		avatar TEXT,
+		email VARCHAR(255) NOT NULL DEFAULT '',
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 	);
@@ -109,5 +137,499 @@ func RunMigrations(db *sql.DB) error {
 		return fmt.Errorf("failed to create testimonials table: %w", err)
 	}
 
+	// Create orders table
+	createOrdersTable := `
+	CREATE TABLE IF NOT EXISTS orders (
+		id SERIAL PRIMARY KEY,
+		reference VARCHAR(255) UNIQUE NOT NULL,
+		amount BIGINT NOT NULL,
+		expected_amount BIGINT,
+		refunded_amount BIGINT NOT NULL DEFAULT 0,
+		currency VARCHAR(3) NOT NULL DEFAULT 'NGN',
+		customer_email VARCHAR(255) NOT NULL,
+		program_id INTEGER REFERENCES programs(id) ON DELETE SET NULL,
+		plan_id INTEGER REFERENCES program_pricing_plans(id) ON DELETE SET NULL,
+		program_name VARCHAR(255) NOT NULL DEFAULT '',
+		plan_name VARCHAR(255) NOT NULL DEFAULT '',
+		status VARCHAR(50) NOT NULL DEFAULT 'pending',
+		paid_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+
+	if _, err := db.Exec(createOrdersTable); err != nil {
+		return fmt.Errorf("failed to create orders table: %w", err)
+	}
+
+	// Add columns introduced after the orders table and create its indexes
+	createOrdersIndexes := `
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS expected_amount BIGINT;
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS refunded_amount BIGINT NOT NULL DEFAULT 0;
+	CREATE INDEX IF NOT EXISTS idx_orders_customer_email ON orders(customer_email);
+	CREATE INDEX IF NOT EXISTS idx_orders_status ON orders(status);
+	CREATE INDEX IF NOT EXISTS idx_orders_program_id ON orders(program_id);
+	`
+
+	if _, err := db.Exec(createOrdersIndexes); err != nil {
+		return fmt.Errorf("failed to update orders table: %w", err)
+	}
+
+	// Create webhook_events table (ledger of processed Paystack webhooks)
+	createWebhookEventsTable := `
+	CREATE TABLE IF NOT EXISTS webhook_events (
+		id SERIAL PRIMARY KEY,
+		event VARCHAR(100) NOT NULL,
+		reference VARCHAR(255) NOT NULL,
+		payload JSONB NOT NULL,
+		status VARCHAR(50) NOT NULL DEFAULT 'processing',
+		attempts INTEGER NOT NULL DEFAULT 1,
+		last_error TEXT NOT NULL DEFAULT '',
+		processed_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (event, reference)
+	);
+	`
+
+	if _, err := db.Exec(createWebhookEventsTable); err != nil {
+		return fmt.Errorf("failed to create webhook_events table: %w", err)
+	}
+
+	createWebhookEventsStatusIndex := `
+	CREATE INDEX IF NOT EXISTS idx_webhook_events_status ON webhook_events(status);
+	`
+
+	if _, err := db.Exec(createWebhookEventsStatusIndex); err != nil {
+		return fmt.Errorf("failed to create webhook_events status index: %w", err)
+	}
+
+	// Create reconciliation_runs table (history of payment reconciliation passes)
+	createReconciliationRunsTable := `
+	CREATE TABLE IF NOT EXISTS reconciliation_runs (
+		id SERIAL PRIMARY KEY,
+		trigger VARCHAR(50) NOT NULL,
+		checked INTEGER NOT NULL DEFAULT 0,
+		paid INTEGER NOT NULL DEFAULT 0,
+		failed INTEGER NOT NULL DEFAULT 0,
+		abandoned INTEGER NOT NULL DEFAULT 0,
+		unchanged INTEGER NOT NULL DEFAULT 0,
+		errors JSONB NOT NULL DEFAULT '[]',
+		started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		finished_at TIMESTAMP
+	);
+	`
+
+	if _, err := db.Exec(createReconciliationRunsTable); err != nil {
+		return fmt.Errorf("failed to create reconciliation_runs table: %w", err)
+	}
+
+	// Create refunds table (refund history per order)
+	createRefundsTable := `
+	CREATE TABLE IF NOT EXISTS refunds (
+		id SERIAL PRIMARY KEY,
+		order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+		reference VARCHAR(255) NOT NULL,
+		paystack_refund_id VARCHAR(255) UNIQUE,
+		amount BIGINT NOT NULL,
+		currency VARCHAR(3) NOT NULL DEFAULT 'NGN',
+		status VARCHAR(50) NOT NULL DEFAULT 'pending',
+		reason TEXT NOT NULL DEFAULT '',
+		initiated_by INTEGER REFERENCES admins(id) ON DELETE SET NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_refunds_order_id ON refunds(order_id);
+	`
+
+	if _, err := db.Exec(createRefundsTable); err != nil {
+		return fmt.Errorf("failed to create refunds table: %w", err)
+	}
+
+	// Create disputes table (chargebacks raised against orders)
+	createDisputesTable := `
+	CREATE TABLE IF NOT EXISTS disputes (
+		id SERIAL PRIMARY KEY,
+		order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+		reference VARCHAR(255) NOT NULL,
+		paystack_dispute_id VARCHAR(255) UNIQUE NOT NULL,
+		status VARCHAR(100) NOT NULL,
+		resolution VARCHAR(100) NOT NULL DEFAULT '',
+		category VARCHAR(100) NOT NULL DEFAULT '',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_disputes_order_id ON disputes(order_id);
+	`
+
+	if _, err := db.Exec(createDisputesTable); err != nil {
+		return fmt.Errorf("failed to create disputes table: %w", err)
+	}
+
+	// Create subscriptions table (recurring Paystack subscriptions)
+	createSubscriptionsTable := `
+	CREATE TABLE IF NOT EXISTS subscriptions (
+		id SERIAL PRIMARY KEY,
+		subscription_code VARCHAR(100) UNIQUE NOT NULL,
+		email_token VARCHAR(100) NOT NULL DEFAULT '',
+		customer_email VARCHAR(255) NOT NULL,
+		customer_code VARCHAR(100) NOT NULL DEFAULT '',
+		plan_code VARCHAR(100) NOT NULL,
+		program_id INTEGER REFERENCES programs(id) ON DELETE SET NULL,
+		plan_id INTEGER REFERENCES program_pricing_plans(id) ON DELETE SET NULL,
+		program_name VARCHAR(255) NOT NULL DEFAULT '',
+		plan_name VARCHAR(255) NOT NULL DEFAULT '',
+		status VARCHAR(50) NOT NULL DEFAULT 'active',
+		next_payment_date TIMESTAMP,
+		cancelled_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_subscriptions_status ON subscriptions(status);
+	CREATE INDEX IF NOT EXISTS idx_subscriptions_customer_email ON subscriptions(customer_email);
+	`
+
+	if _, err := db.Exec(createSubscriptionsTable); err != nil {
+		return fmt.Errorf("failed to create subscriptions table: %w", err)
+	}
+
+	// Create coupons table
+	createCouponsTable := `
+	CREATE TABLE IF NOT EXISTS coupons (
+		id SERIAL PRIMARY KEY,
+		code VARCHAR(50) UNIQUE NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		discount_type VARCHAR(20) NOT NULL,
+		percent_off INTEGER NOT NULL DEFAULT 0,
+		amount_off BIGINT NOT NULL DEFAULT 0,
+		currency VARCHAR(3) NOT NULL DEFAULT 'NGN',
+		program_ids JSONB NOT NULL DEFAULT '[]',
+		max_redemptions INTEGER,
+		max_per_customer INTEGER,
+		expires_at TIMESTAMP,
+		active BOOLEAN NOT NULL DEFAULT TRUE,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+
+	if _, err := db.Exec(createCouponsTable); err != nil {
+		return fmt.Errorf("failed to create coupons table: %w", err)
+	}
+
+	// Create coupon_redemptions table (one row per checkout a coupon was applied to)
+	createCouponRedemptionsTable := `
+	CREATE TABLE IF NOT EXISTS coupon_redemptions (
+		id SERIAL PRIMARY KEY,
+		coupon_id INTEGER REFERENCES coupons(id) ON DELETE SET NULL,
+		coupon_code VARCHAR(50) NOT NULL,
+		order_id INTEGER REFERENCES orders(id) ON DELETE SET NULL,
+		reference VARCHAR(255) UNIQUE NOT NULL,
+		customer_email VARCHAR(255) NOT NULL,
+		original_amount BIGINT NOT NULL,
+		discount_amount BIGINT NOT NULL,
+		currency VARCHAR(3) NOT NULL DEFAULT 'NGN',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_coupon_id ON coupon_redemptions(coupon_id);
+	`
+
+	if _, err := db.Exec(createCouponRedemptionsTable); err != nil {
+		return fmt.Errorf("failed to create coupon_redemptions table: %w", err)
+	}
+
+	// Create invoices table (PDF receipts for paid orders)
+	createInvoicesTable := `
+	CREATE TABLE IF NOT EXISTS invoices (
+		id SERIAL PRIMARY KEY,
+		invoice_number VARCHAR(50) UNIQUE NOT NULL,
+		sequence INTEGER UNIQUE NOT NULL,
+		order_id INTEGER UNIQUE NOT NULL REFERENCES orders(id) ON DELETE RESTRICT,
+		reference VARCHAR(255) NOT NULL,
+		customer_email VARCHAR(255) NOT NULL,
+		program_name VARCHAR(255) NOT NULL DEFAULT '',
+		plan_name VARCHAR(255) NOT NULL DEFAULT '',
+		subtotal BIGINT NOT NULL,
+		discount BIGINT NOT NULL DEFAULT 0,
+		amount BIGINT NOT NULL,
+		currency VARCHAR(3) NOT NULL DEFAULT 'NGN',
+		pdf BYTEA NOT NULL,
+		issued_at TIMESTAMP NOT NULL,
+		emailed_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+
+	if _, err := db.Exec(createInvoicesTable); err != nil {
+		return fmt.Errorf("failed to create invoices table: %w", err)
+	}
+
+	// Create leads table (registrations from /send-customer-details)
+	createLeadsTable := `
+	CREATE TABLE IF NOT EXISTS leads (
+		id SERIAL PRIMARY KEY,
+		full_name VARCHAR(255) NOT NULL DEFAULT '',
+		email VARCHAR(255) NOT NULL DEFAULT '',
+		nationality VARCHAR(100) NOT NULL DEFAULT '',
+		country_code VARCHAR(2) NOT NULL DEFAULT '',
+		phone_number VARCHAR(50) NOT NULL DEFAULT '',
+		program VARCHAR(255) NOT NULL DEFAULT '',
+		program_id INTEGER REFERENCES programs(id) ON DELETE SET NULL,
+		package VARCHAR(255) NOT NULL DEFAULT '',
+		plan_id INTEGER REFERENCES program_pricing_plans(id) ON DELETE SET NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'new',
+		assigned_to INTEGER REFERENCES admins(id) ON DELETE SET NULL,
+		notified_at TIMESTAMP,
+		anonymized_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_leads_email ON leads(email);
+	`
+
+	if _, err := db.Exec(createLeadsTable); err != nil {
+		return fmt.Errorf("failed to create leads table: %w", err)
+	}
+
+	// Lead pipeline: status, assignment, notes and status history
+	createLeadPipeline := `
+	ALTER TABLE leads ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'new';
+	ALTER TABLE leads ADD COLUMN IF NOT EXISTS assigned_to INTEGER REFERENCES admins(id) ON DELETE SET NULL;
+	CREATE INDEX IF NOT EXISTS idx_leads_status ON leads(status);
+
+	CREATE TABLE IF NOT EXISTS lead_notes (
+		id SERIAL PRIMARY KEY,
+		lead_id INTEGER NOT NULL REFERENCES leads(id) ON DELETE CASCADE,
+		admin_id INTEGER REFERENCES admins(id) ON DELETE SET NULL,
+		body TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_lead_notes_lead_id ON lead_notes(lead_id);
+
+	CREATE TABLE IF NOT EXISTS lead_status_history (
+		id SERIAL PRIMARY KEY,
+		lead_id INTEGER NOT NULL REFERENCES leads(id) ON DELETE CASCADE,
+		from_status VARCHAR(20) NOT NULL,
+		to_status VARCHAR(20) NOT NULL,
+		admin_id INTEGER REFERENCES admins(id) ON DELETE SET NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_lead_status_history_lead_id ON lead_status_history(lead_id);
+	`
+
+	if _, err := db.Exec(createLeadPipeline); err != nil {
+		return fmt.Errorf("failed to create lead pipeline tables: %w", err)
+	}
+
+	// Link paid orders to the registration they came from
+	addOrderLeadColumn := `
+	ALTER TABLE orders ADD COLUMN IF NOT EXISTS lead_id INTEGER REFERENCES leads(id) ON DELETE SET NULL;
+	CREATE INDEX IF NOT EXISTS idx_orders_lead_id ON orders(lead_id);
+	`
+
+	if _, err := db.Exec(addOrderLeadColumn); err != nil {
+		return fmt.Errorf("failed to add order lead column: %w", err)
+	}
+
+	// Normalized registration details
+	addLeadNormalizedColumns := `
+	ALTER TABLE leads ADD COLUMN IF NOT EXISTS country_code VARCHAR(2) NOT NULL DEFAULT '';
+	ALTER TABLE leads ADD COLUMN IF NOT EXISTS program_id INTEGER REFERENCES programs(id) ON DELETE SET NULL;
+	ALTER TABLE leads ADD COLUMN IF NOT EXISTS plan_id INTEGER REFERENCES program_pricing_plans(id) ON DELETE SET NULL;
+	`
+
+	if _, err := db.Exec(addLeadNormalizedColumns); err != nil {
+		return fmt.Errorf("failed to add lead normalized columns: %w", err)
+	}
+
+	// Data subject requests: link testimonials to an email, mark anonymized
+	// leads, and keep an audit trail keyed by email hash
+	createDataSubjectTables := `
+	ALTER TABLE testimonials ADD COLUMN IF NOT EXISTS email VARCHAR(255) NOT NULL DEFAULT '';
+	CREATE INDEX IF NOT EXISTS idx_testimonials_email ON testimonials(LOWER(email));
+	ALTER TABLE leads ADD COLUMN IF NOT EXISTS anonymized_at TIMESTAMP;
+
+	CREATE TABLE IF NOT EXISTS data_subject_audit_log (
+		id SERIAL PRIMARY KEY,
+		action VARCHAR(20) NOT NULL,
+		email_hash VARCHAR(64) NOT NULL,
+		reason TEXT NOT NULL DEFAULT '',
+		admin_id INTEGER REFERENCES admins(id) ON DELETE SET NULL,
+		details JSONB NOT NULL DEFAULT '{}',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_data_subject_audit_log_email_hash ON data_subject_audit_log(email_hash);
+	`
+
+	if _, err := db.Exec(createDataSubjectTables); err != nil {
+		return fmt.Errorf("failed to create data subject tables: %w", err)
+	}
+
+	// Customer accounts and their single-use magic login links
+	createCustomerAccountTables := `
+	CREATE TABLE IF NOT EXISTS customer_accounts (
+		id SERIAL PRIMARY KEY,
+		email VARCHAR(255) UNIQUE NOT NULL,
+		full_name VARCHAR(255) NOT NULL DEFAULT '',
+		is_active BOOLEAN DEFAULT true,
+		last_login_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS customer_login_tokens (
+		id SERIAL PRIMARY KEY,
+		customer_id INTEGER NOT NULL REFERENCES customer_accounts(id) ON DELETE CASCADE,
+		token_hash VARCHAR(64) UNIQUE NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		used_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_customer_login_tokens_customer_id ON customer_login_tokens(customer_id);
+	`
+
+	if _, err := db.Exec(createCustomerAccountTables); err != nil {
+		return fmt.Errorf("failed to create customer account tables: %w", err)
+	}
+
+	// Customer progress through program sections and daily check-ins
+	createProgressTables := `
+	CREATE TABLE IF NOT EXISTS program_section_progress (
+		id SERIAL PRIMARY KEY,
+		customer_id INTEGER NOT NULL REFERENCES customer_accounts(id) ON DELETE CASCADE,
+		program_id INTEGER NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+		section VARCHAR(50) NOT NULL,
+		completed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (customer_id, program_id, section)
+	);
+	CREATE INDEX IF NOT EXISTS idx_program_section_progress_program_id ON program_section_progress(program_id);
+
+	CREATE TABLE IF NOT EXISTS program_check_ins (
+		id SERIAL PRIMARY KEY,
+		customer_id INTEGER NOT NULL REFERENCES customer_accounts(id) ON DELETE CASCADE,
+		program_id INTEGER NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+		check_in_date DATE NOT NULL,
+		weight_kg NUMERIC(5, 1),
+		energy_level SMALLINT,
+		symptoms JSONB NOT NULL DEFAULT '[]',
+		notes TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (customer_id, program_id, check_in_date)
+	);
+	CREATE INDEX IF NOT EXISTS idx_program_check_ins_program_id ON program_check_ins(program_id);
+	`
+
+	if _, err := db.Exec(createProgressTables); err != nil {
+		return fmt.Errorf("failed to create progress tables: %w", err)
+	}
+
+	// Outgoing email, queued with the change that caused it and sent by a
+	// background worker with retries
+	createEmailOutboxTable := `
+	CREATE TABLE IF NOT EXISTS email_outbox (
+		id SERIAL PRIMARY KEY,
+		kind VARCHAR(50) NOT NULL,
+		reference_id INTEGER,
+		customer_email VARCHAR(255) NOT NULL DEFAULT '',
+		recipients JSONB NOT NULL,
+		subject TEXT NOT NULL DEFAULT '',
+		message BYTEA,
+		status VARCHAR(20) NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		expires_at TIMESTAMP,
+		sent_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_email_outbox_status_next_attempt_at ON email_outbox(status, next_attempt_at);
+	CREATE INDEX IF NOT EXISTS idx_email_outbox_customer_email ON email_outbox(LOWER(customer_email));
+	`
+
+	if _, err := db.Exec(createEmailOutboxTable); err != nil {
+		return fmt.Errorf("failed to create email outbox table: %w", err)
+	}
+
+	// Admin edits to email templates; kinds without a row use the built-in template
+	createEmailTemplatesTable := `
+	CREATE TABLE IF NOT EXISTS email_templates (
+		id SERIAL PRIMARY KEY,
+		key VARCHAR(50) UNIQUE NOT NULL,
+		subject TEXT NOT NULL,
+		html_body TEXT NOT NULL,
+		text_body TEXT NOT NULL,
+		updated_by INTEGER REFERENCES admins(id) ON DELETE SET NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+
+	if _, err := db.Exec(createEmailTemplatesTable); err != nil {
+		return fmt.Errorf("failed to create email templates table: %w", err)
+	}
+
+	// Kinds of email admins have switched on or off; kinds without a row are on
+	createEmailSettingsTable := `
+	CREATE TABLE IF NOT EXISTS email_settings (
+		id SERIAL PRIMARY KEY,
+		key VARCHAR(50) UNIQUE NOT NULL,
+		enabled BOOLEAN NOT NULL DEFAULT true,
+		updated_by INTEGER REFERENCES admins(id) ON DELETE SET NULL,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+
+	if _, err := db.Exec(createEmailSettingsTable); err != nil {
+		return fmt.Errorf("failed to create email settings table: %w", err)
+	}
+
+	// Who gets the internal notification emails for each event. Rules for a
+	// program replace the general rules for that event.
+	createNotificationRulesTable := `
+	CREATE TABLE IF NOT EXISTS notification_rules (
+		id SERIAL PRIMARY KEY,
+		event VARCHAR(50) NOT NULL,
+		program_id INTEGER REFERENCES programs(id) ON DELETE CASCADE,
+		recipients JSONB NOT NULL DEFAULT '[]',
+		cc JSONB NOT NULL DEFAULT '[]',
+		bcc JSONB NOT NULL DEFAULT '[]',
+		is_active BOOLEAN NOT NULL DEFAULT true,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_notification_rules_event ON notification_rules(event, program_id);
+	`
+
+	if _, err := db.Exec(createNotificationRulesTable); err != nil {
+		return fmt.Errorf("failed to create notification rules table: %w", err)
+	}
+
+	// Newsletter subscribers. Sign-ups stay pending until the emailed link is
+	// confirmed; program_ids holds the programs they are interested in.
+	createNewsletterSubscribersTable := `
+	CREATE TABLE IF NOT EXISTS newsletter_subscribers (
+		id SERIAL PRIMARY KEY,
+		email VARCHAR(255) UNIQUE NOT NULL,
+		status VARCHAR(20) NOT NULL DEFAULT 'pending',
+		program_ids JSONB NOT NULL DEFAULT '[]',
+		confirm_token_hash VARCHAR(64) UNIQUE,
+		confirm_expires_at TIMESTAMP,
+		confirmation_sent_at TIMESTAMP,
+		confirmed_at TIMESTAMP,
+		unsubscribed_at TIMESTAMP,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_newsletter_subscribers_status ON newsletter_subscribers(status);
+	-- Interests asked for by a subscribed address, applied once the change is confirmed by email
+	ALTER TABLE newsletter_subscribers ADD COLUMN IF NOT EXISTS pending_program_ids JSONB;
+	`
+
+	if _, err := db.Exec(createNewsletterSubscribersTable); err != nil {
+		return fmt.Errorf("failed to create newsletter subscribers table: %w", err)
+	}
+
 	return nil
 }
\ No newline at end of file
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+	"plantbased-backend/models"
+)
+
+// migratePricingPlanPrices converts the legacy VARCHAR price column into
+// price_amount (minor units) and currency. Rows that cannot be parsed are
+// reported and left untouched; the legacy column is only dropped once every
+// row has been converted, so those prices can still be fixed by hand.
+func migratePricingPlanPrices(db *sql.DB) error {
+	var hasLegacyColumn bool
+	err := db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_name = 'program_pricing_plans' AND column_name = 'price'
+		)
+	`).Scan(&hasLegacyColumn)
+	if err != nil {
+		return err
+	}
+
+	if !hasLegacyColumn {
+		return nil
+	}
+
+	if _, err := db.Exec(`ALTER TABLE program_pricing_plans ALTER COLUMN price DROP NOT NULL`); err != nil {
+		return err
+	}
+
+	rows, err := db.Query(`
+		SELECT id, price FROM program_pricing_plans
+		WHERE price_amount IS NULL AND price IS NOT NULL
+	`)
+	if err != nil {
+		return err
+	}
+
+	type legacyPrice struct {
+		id    int
+		price string
+	}
+	var pending []legacyPrice
+	for rows.Next() {
+		var p legacyPrice
+		if err := rows.Scan(&p.id, &p.price); err != nil {
+			rows.Close()
+			return err
+		}
+		pending = append(pending, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, p := range pending {
+		money, err := models.ParseMoney(p.price)
+		if err == nil {
+			err = money.Validate()
+		}
+		if err != nil {
+			log.Printf("⚠ Pricing plan %d: could not parse price %q: %v", p.id, p.price, err)
+			continue
+		}
+
+		_, err = db.Exec(`
+			UPDATE program_pricing_plans SET price_amount = $1, currency = $2 WHERE id = $3
+		`, money.Amount, money.Currency, p.id)
+		if err != nil {
+			return err
+		}
+	}
+
+	var unparsed int
+	err = db.QueryRow(`SELECT COUNT(*) FROM program_pricing_plans WHERE price_amount IS NULL`).Scan(&unparsed)
+	if err != nil {
+		return err
+	}
+
+	if unparsed > 0 {
+		log.Printf("⚠ %d pricing plan(s) still have an unparseable price; keeping the legacy price column until they are updated", unparsed)
+		return nil
+	}
+
+	_, err = db.Exec(`
+		ALTER TABLE program_pricing_plans ALTER COLUMN price_amount SET NOT NULL;
+		ALTER TABLE program_pricing_plans DROP COLUMN price;
+	`)
+	if err != nil {
+		return err
+	}
+
+	log.Println("✓ Pricing plan prices migrated to minor units")
+	return nil
+}
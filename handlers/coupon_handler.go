@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"plantbased-backend/models"
+	"plantbased-backend/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CouponHandler struct {
+	couponService *services.CouponService
+}
+
+func NewCouponHandler(couponService *services.CouponService) *CouponHandler {
+	return &CouponHandler{couponService: couponService}
+}
+
+// CreateCoupon creates a new coupon (admin only)
+func (h *CouponHandler) CreateCoupon(c *gin.Context) {
+	var req models.CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	coupon, err := h.couponService.CreateCoupon(req)
+	if errors.Is(err, services.ErrInvalidCoupon) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid coupon",
+			Message: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to create coupon",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, coupon)
+}
+
+// GetCoupons lists all coupons (admin only)
+func (h *CouponHandler) GetCoupons(c *gin.Context) {
+	coupons, err := h.couponService.GetCoupons()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to fetch coupons",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, coupons)
+}
+
+// GetCouponByID retrieves a single coupon (admin only)
+func (h *CouponHandler) GetCouponByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid coupon ID",
+		})
+		return
+	}
+
+	coupon, err := h.couponService.GetCouponByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, coupon)
+}
+
+// UpdateCoupon updates an existing coupon (admin only)
+func (h *CouponHandler) UpdateCoupon(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid coupon ID",
+		})
+		return
+	}
+
+	var req models.CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	coupon, err := h.couponService.UpdateCoupon(id, req)
+	if errors.Is(err, services.ErrInvalidCoupon) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid coupon",
+			Message: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to update coupon",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Coupon updated successfully",
+		Data:    coupon,
+	})
+}
+
+// DeleteCoupon deletes a coupon (admin only)
+func (h *CouponHandler) DeleteCoupon(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid coupon ID",
+		})
+		return
+	}
+
+	err = h.couponService.DeleteCoupon(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to delete coupon",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Coupon deleted successfully",
+	})
+}
+
+// GetCouponRedemptions lists the checkouts a coupon was applied to (admin only)
+func (h *CouponHandler) GetCouponRedemptions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid coupon ID",
+		})
+		return
+	}
+
+	redemptions, err := h.couponService.GetRedemptions(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to fetch redemptions",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, redemptions)
+}
+
+// ValidateCoupon returns the price of a pricing plan with a coupon applied
+func (h *CouponHandler) ValidateCoupon(c *gin.Context) {
+	var req models.ValidateCouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	quote, err := h.couponService.Quote(req.Code, req.ProgramID, req.PlanID, req.Email)
+	if errors.Is(err, services.ErrInvalidCoupon) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid coupon",
+			Message: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to validate coupon",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
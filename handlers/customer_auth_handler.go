@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"plantbased-backend/models"
+	"plantbased-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CustomerAuthHandler struct {
+	customerAuthService *services.CustomerAuthService
+}
+
+func NewCustomerAuthHandler(customerAuthService *services.CustomerAuthService) *CustomerAuthHandler {
+	return &CustomerAuthHandler{customerAuthService: customerAuthService}
+}
+
+// RequestMagicLink emails a paying customer a login link
+func (h *CustomerAuthHandler) RequestMagicLink(c *gin.Context) {
+	var req models.MagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Fields:  bindingFieldErrors(err, &req),
+		})
+		return
+	}
+
+	if err := h.customerAuthService.RequestMagicLink(req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to send login link",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// The same response whether or not the email has an account
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "If that email belongs to a customer, a login link is on its way",
+	})
+}
+
+// VerifyMagicLink logs a customer in with the token from a login link
+func (h *CustomerAuthHandler) VerifyMagicLink(c *gin.Context) {
+	var req models.MagicLinkVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response, err := h.customerAuthService.VerifyMagicLink(req.Token)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidMagicLink) {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   err.Error(),
+				Message: "Please request a new login link",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to log in",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RefreshToken issues a new customer access token
+func (h *CustomerAuthHandler) RefreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	token, err := h.customerAuthService.RefreshToken(req.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   err.Error(),
+			Message: "Please log in again",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token})
+}
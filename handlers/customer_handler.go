@@ -1,36 +1,58 @@
 package handlers
 
 import (
+	"encoding/csv"
+	"errors"
+	"log"
+	"net/http"
 	"plantbased-backend/models"
 	"plantbased-backend/services"
+	"plantbased-backend/utils"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type CustomerHandler struct {
-	emailService *services.EmailService
+	customerService *services.CustomerService
 }
 
-func NewCustomerHandler(emailService *services.EmailService) *CustomerHandler {
-	return &CustomerHandler{emailService: emailService}
+func NewCustomerHandler(customerService *services.CustomerService) *CustomerHandler {
+	return &CustomerHandler{
+		customerService: customerService,
+	}
 }
 
 func (h *CustomerHandler) SendCustomerDetails(c *gin.Context) {
 	var details models.CustomerDetails
-	
+
 	if err := c.ShouldBindJSON(&details); err != nil {
 		c.JSON(400, models.ErrorResponse{
 			Error:   "invalid_request",
 			Message: "Invalid request body",
+			Fields:  bindingFieldErrors(err, &details),
 		})
 		return
 	}
 
-	err := h.emailService.SendCustomerDetailsToCEO(details)
-	if err != nil {
+	// The lead notification is queued with the lead, so mail server outages
+	// do not fail the registration
+	if _, err := h.customerService.CreateLead(details); err != nil {
+		var validationErr *services.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(400, models.ErrorResponse{
+				Error:   "validation_failed",
+				Message: "Some customer details are invalid",
+				Fields:  validationErr.Fields,
+			})
+			return
+		}
+
 		c.JSON(500, models.ErrorResponse{
-			Error:   "email_failed",
-			Message: "Failed to send email",
+			Error:   "save_failed",
+			Message: "Failed to save customer details",
 		})
 		return
 	}
@@ -39,4 +61,326 @@ func (h *CustomerHandler) SendCustomerDetails(c *gin.Context) {
 		Success: true,
 		Message: "Customer details sent successfully",
 	})
-}
\ No newline at end of file
+}
+
+// GetLeads lists leads, optionally filtered by a search term (admin only)
+func (h *CustomerHandler) GetLeads(c *gin.Context) {
+	filter := models.LeadFilter{
+		Search: c.Query("search"),
+		Status: c.Query("status"),
+	}
+
+	intParams := map[string]*int{
+		"assigned_to": &filter.AssignedTo,
+		"limit":       &filter.Limit,
+		"offset":      &filter.Offset,
+	}
+	for name, target := range intParams {
+		if value := c.Query(name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{
+					Error:   "Invalid filter",
+					Message: (&queryParamError{name: name}).Error(),
+				})
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	leads, err := h.customerService.GetLeads(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to fetch leads",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, leads)
+}
+
+// GetLeadByID retrieves a single lead with its notes and status history (admin only)
+func (h *CustomerHandler) GetLeadByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid lead ID",
+		})
+		return
+	}
+
+	lead, err := h.customerService.GetLeadDetail(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, lead)
+}
+
+// UpdateLeadStatus moves a lead to a new pipeline status (admin only)
+func (h *CustomerHandler) UpdateLeadStatus(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid lead ID",
+		})
+		return
+	}
+
+	var req models.LeadStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	lead, err := h.customerService.ChangeStatus(id, req, c.GetInt("adminID"))
+	if err != nil {
+		h.leadUpdateError(c, "Failed to update lead status", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Lead status updated successfully",
+		Data:    lead,
+	})
+}
+
+// AddLeadNote adds a note to a lead (admin only)
+func (h *CustomerHandler) AddLeadNote(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid lead ID",
+		})
+		return
+	}
+
+	var req models.LeadNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	note, err := h.customerService.AddNote(id, req, c.GetInt("adminID"))
+	if err != nil {
+		h.leadUpdateError(c, "Failed to add note", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, note)
+}
+
+// AssignLead assigns a lead to an admin, or unassigns it (admin only)
+func (h *CustomerHandler) AssignLead(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid lead ID",
+		})
+		return
+	}
+
+	var req models.LeadAssignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	lead, err := h.customerService.AssignLead(id, req.AdminID)
+	if err != nil {
+		h.leadUpdateError(c, "Failed to assign lead", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Lead assigned successfully",
+		Data:    lead,
+	})
+}
+
+// ExportCustomers streams registrations and payments as CSV or XLSX (admin only)
+func (h *CustomerHandler) ExportCustomers(c *gin.Context) {
+	filter := models.CustomerExportFilter{
+		Status:        c.Query("status"),
+		PaymentStatus: c.Query("payment_status"),
+	}
+
+	if filter.Status != "" && !models.IsValidLeadStatus(filter.Status) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid filter",
+			Message: (&queryParamError{name: "status"}).Error(),
+		})
+		return
+	}
+
+	if filter.PaymentStatus != "" && !models.IsValidOrderStatus(filter.PaymentStatus) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid filter",
+			Message: (&queryParamError{name: "payment_status"}).Error(),
+		})
+		return
+	}
+
+	if value := c.Query("program_id"); value != "" {
+		programID, err := strconv.Atoi(value)
+		if err != nil || programID < 0 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid filter",
+				Message: (&queryParamError{name: "program_id"}).Error(),
+			})
+			return
+		}
+		filter.ProgramID = programID
+	}
+
+	dateParams := map[string]**time.Time{
+		"from": &filter.From,
+		"to":   &filter.To,
+	}
+	for name, target := range dateParams {
+		if value := c.Query(name); value != "" {
+			parsed, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{
+					Error:   "Invalid filter",
+					Message: (&queryParamError{name: name}).Error(),
+				})
+				return
+			}
+			if name == "to" {
+				// Include the whole "to" day
+				parsed = parsed.AddDate(0, 0, 1)
+			}
+			*target = &parsed
+		}
+	}
+
+	var columns []string
+	if value := c.Query("columns"); value != "" {
+		columns = strings.Split(value, ",")
+	}
+
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "xlsx" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid filter",
+			Message: "format must be csv or xlsx",
+		})
+		return
+	}
+
+	filename := "customers-" + time.Now().Format("20060102") + "." + format
+
+	// Headers are sent with the first row, so errors before any row is
+	// written can still be reported as JSON
+	started := false
+	var write func(row []string) error
+	var finish func() error
+
+	if format == "xlsx" {
+		var xlsx *utils.XLSXWriter
+		write = func(row []string) error {
+			if !started {
+				started = true
+				c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+				c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+				c.Status(http.StatusOK)
+
+				var err error
+				if xlsx, err = utils.NewXLSXWriter(c.Writer, "Customers"); err != nil {
+					return err
+				}
+			}
+			return xlsx.WriteRow(row)
+		}
+		finish = func() error { return xlsx.Close() }
+	} else {
+		writer := csv.NewWriter(c.Writer)
+		rowCount := 0
+		write = func(row []string) error {
+			if !started {
+				started = true
+				c.Header("Content-Type", "text/csv; charset=utf-8")
+				c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+				c.Status(http.StatusOK)
+			}
+			if err := writer.Write(csvSafeRow(row)); err != nil {
+				return err
+			}
+			// Flush regularly so rows reach the client as they are read
+			if rowCount++; rowCount%500 == 0 {
+				writer.Flush()
+				c.Writer.Flush()
+			}
+			return writer.Error()
+		}
+		finish = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	}
+
+	err := h.customerService.ExportCustomers(filter, columns, write)
+	if err == nil {
+		err = finish()
+	}
+	if err == nil {
+		return
+	}
+
+	if started {
+		// The response is already under way; all we can do is cut it short
+		log.Printf("Customer export failed mid-stream: %v", err)
+		c.Abort()
+		return
+	}
+
+	if errors.Is(err, services.ErrInvalidExport) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid export",
+			Message: err.Error() + " (available: " + strings.Join(services.CustomerExportColumnKeys(), ", ") + ")",
+		})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Error:   "Failed to export customers",
+		Message: err.Error(),
+	})
+}
+
+// leadUpdateError maps lead service errors to responses
+func (h *CustomerHandler) leadUpdateError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidLead):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid lead update",
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrLeadNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   message,
+			Message: err.Error(),
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLeadRequestValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &CustomerHandler{}
+	router := gin.New()
+	router.GET("/leads", h.GetLeads)
+	router.GET("/leads/:id", h.GetLeadByID)
+
+	// Rejected before the database is touched
+	for _, path := range []string{"/leads?limit=ten", "/leads?offset=-1", "/leads/abc"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("GET %s = %d, want %d", path, w.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+	"plantbased-backend/models"
+	"plantbased-backend/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DataSubjectHandler serves data subject (GDPR/NDPR) requests. Emails are sent
+// in request bodies rather than URLs so they stay out of access logs.
+type DataSubjectHandler struct {
+	dataSubjectService *services.DataSubjectService
+}
+
+func NewDataSubjectHandler(dataSubjectService *services.DataSubjectService) *DataSubjectHandler {
+	return &DataSubjectHandler{dataSubjectService: dataSubjectService}
+}
+
+// FindRecords lists everything stored about an email (admin only)
+func (h *DataSubjectHandler) FindRecords(c *gin.Context) {
+	var req models.DataSubjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Fields:  bindingFieldErrors(err, &req),
+		})
+		return
+	}
+
+	records, err := h.dataSubjectService.Find(req, models.DataSubjectActionFind, c.GetInt("adminID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to find records",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, records)
+}
+
+// ExportRecords serves everything stored about an email as a JSON download (admin only)
+func (h *DataSubjectHandler) ExportRecords(c *gin.Context) {
+	var req models.DataSubjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Fields:  bindingFieldErrors(err, &req),
+		})
+		return
+	}
+
+	records, err := h.dataSubjectService.Find(req, models.DataSubjectActionExport, c.GetInt("adminID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to export records",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	filename := "data-export-" + time.Now().Format("20060102-150405") + ".json"
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.IndentedJSON(http.StatusOK, records)
+}
+
+// AnonymizeRecords erases the personal details stored for an email, keeping
+// financial records (admin only)
+func (h *DataSubjectHandler) AnonymizeRecords(c *gin.Context) {
+	var req models.DataSubjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Fields:  bindingFieldErrors(err, &req),
+		})
+		return
+	}
+
+	result, err := h.dataSubjectService.Anonymize(req, c.GetInt("adminID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to anonymize records",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Personal data anonymized successfully",
+		Data:    result,
+	})
+}
+
+// GetAuditLog lists data subject actions, optionally for one email (admin only)
+func (h *DataSubjectHandler) GetAuditLog(c *gin.Context) {
+	entries, err := h.dataSubjectService.GetAuditLog(c.Query("email"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to fetch audit log",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
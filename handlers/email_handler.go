@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"plantbased-backend/models"
+	"plantbased-backend/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EmailHandler struct {
+	emailService *services.EmailService
+}
+
+func NewEmailHandler(emailService *services.EmailService) *EmailHandler {
+	return &EmailHandler{emailService: emailService}
+}
+
+// GetOutbox lists queued and sent email, optionally filtered by status (admin only)
+func (h *EmailHandler) GetOutbox(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", models.EmailStatusPending, models.EmailStatusSent, models.EmailStatusDead:
+	default:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid filter",
+			Message: (&queryParamError{name: "status"}).Error(),
+		})
+		return
+	}
+
+	emails, err := h.emailService.GetOutbox(status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to fetch emails",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, emails)
+}
+
+// GetOutboxEmail retrieves a single outbox entry (admin only)
+func (h *EmailHandler) GetOutboxEmail(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid email ID",
+		})
+		return
+	}
+
+	email, err := h.emailService.GetOutboxEmailByID(id)
+	if err != nil {
+		h.outboxError(c, "Failed to fetch email", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, email)
+}
+
+// ResendEmail queues an unsent email for immediate delivery (admin only)
+func (h *EmailHandler) ResendEmail(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid email ID",
+		})
+		return
+	}
+
+	email, err := h.emailService.ResendEmail(id)
+	if err != nil {
+		h.outboxError(c, "Failed to resend email", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Email queued for delivery",
+		Data:    email,
+	})
+}
+
+// outboxError maps email outbox errors to responses
+func (h *EmailHandler) outboxError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrEmailNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: err.Error(),
+		})
+	case errors.Is(err, services.ErrEmailAlreadySent):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   message,
+			Message: err.Error(),
+		})
+	}
+}
+
+// GetTemplates lists the email templates (admin only)
+func (h *EmailHandler) GetTemplates(c *gin.Context) {
+	templates, err := h.emailService.GetTemplates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to fetch email templates",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// GetTemplate retrieves one email template (admin only)
+func (h *EmailHandler) GetTemplate(c *gin.Context) {
+	tmpl, err := h.emailService.GetTemplate(c.Param("key"))
+	if err != nil {
+		h.templateError(c, "Failed to fetch email template", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tmpl)
+}
+
+// UpdateTemplate saves an edited email template (admin only)
+func (h *EmailHandler) UpdateTemplate(c *gin.Context) {
+	var req models.EmailTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Fields:  bindingFieldErrors(err, &req),
+		})
+		return
+	}
+
+	tmpl, err := h.emailService.UpdateTemplate(c.Param("key"), req, c.GetInt("adminID"))
+	if err != nil {
+		h.templateError(c, "Failed to update email template", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Email template updated successfully",
+		Data:    tmpl,
+	})
+}
+
+// ResetTemplate restores the built-in version of an email template (admin only)
+func (h *EmailHandler) ResetTemplate(c *gin.Context) {
+	tmpl, err := h.emailService.ResetTemplate(c.Param("key"))
+	if err != nil {
+		h.templateError(c, "Failed to reset email template", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Email template reset to the default",
+		Data:    tmpl,
+	})
+}
+
+// SetTemplateEnabled switches a kind of email on or off (admin only)
+func (h *EmailHandler) SetTemplateEnabled(c *gin.Context) {
+	var req models.EmailTemplateEnabledRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Fields:  bindingFieldErrors(err, &req),
+		})
+		return
+	}
+
+	tmpl, err := h.emailService.SetTemplateEnabled(c.Param("key"), *req.Enabled, c.GetInt("adminID"))
+	if err != nil {
+		h.templateError(c, "Failed to update email template", err)
+		return
+	}
+
+	message := "Email switched off"
+	if tmpl.Enabled {
+		message = "Email switched on"
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: message,
+		Data:    tmpl,
+	})
+}
+
+// PreviewTemplate renders an email template, with any unsaved changes, using
+// sample data (admin only)
+func (h *EmailHandler) PreviewTemplate(c *gin.Context) {
+	var req models.EmailTemplatePreviewRequest
+	// The body is optional; without one the saved template is previewed
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid request",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	preview, err := h.emailService.PreviewTemplate(c.Param("key"), req)
+	if err != nil {
+		h.templateError(c, "Failed to preview email template", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// templateError maps email template errors to responses
+func (h *EmailHandler) templateError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrTemplateNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidTemplate):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid email template",
+			Message: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   message,
+			Message: err.Error(),
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package handlers
+
+import "strings"
+
+// csvSafeRow returns row with every cell that a spreadsheet would run as a
+// formula prefixed with a quote. Exports carry text typed into public forms,
+// so a name such as "=HYPERLINK(...)" must not execute when staff open the file.
+func csvSafeRow(row []string) []string {
+	safe := make([]string, len(row))
+	for i, value := range row {
+		if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+			value = "'" + value
+		}
+		safe[i] = value
+	}
+	return safe
+}
@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCSVSafeRow(t *testing.T) {
+	row := []string{"", "Adaeze", "=HYPERLINK(\"x\")", "+2348012345678", "-1", "@SUM(A1)", "\tcmd", "\rcmd", "a=b"}
+	want := []string{"", "Adaeze", "'=HYPERLINK(\"x\")", "'+2348012345678", "'-1", "'@SUM(A1)", "'\tcmd", "'\rcmd", "a=b"}
+
+	if got := csvSafeRow(row); !reflect.DeepEqual(got, want) {
+		t.Errorf("csvSafeRow() = %q, want %q", got, want)
+	}
+	if row[2] != "=HYPERLINK(\"x\")" {
+		t.Error("csvSafeRow modified its input")
+	}
+}
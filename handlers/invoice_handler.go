@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"plantbased-backend/models"
+	"plantbased-backend/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InvoiceHandler struct {
+	invoiceService *services.InvoiceService
+}
+
+func NewInvoiceHandler(invoiceService *services.InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{invoiceService: invoiceService}
+}
+
+// GetInvoices lists issued invoices (admin only)
+func (h *InvoiceHandler) GetInvoices(c *gin.Context) {
+	invoices, err := h.invoiceService.GetInvoices()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to fetch invoices",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, invoices)
+}
+
+// DownloadInvoice serves the PDF of an invoice (admin only)
+func (h *InvoiceHandler) DownloadInvoice(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid invoice ID",
+		})
+		return
+	}
+
+	number, pdf, err := h.invoiceService.GetInvoicePDF(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+number+`.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"log"
+	"net/http"
+	"plantbased-backend/models"
+	"plantbased-backend/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NewsletterHandler struct {
+	newsletterService *services.NewsletterService
+}
+
+func NewNewsletterHandler(newsletterService *services.NewsletterService) *NewsletterHandler {
+	return &NewsletterHandler{newsletterService: newsletterService}
+}
+
+// Subscribe signs an email up for the newsletter and sends a confirmation link
+func (h *NewsletterHandler) Subscribe(c *gin.Context) {
+	var req models.NewsletterSubscribeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Fields:  bindingFieldErrors(err, &req),
+		})
+		return
+	}
+
+	if err := h.newsletterService.Subscribe(req); err != nil {
+		if errors.Is(err, services.ErrInvalidSubscription) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid subscription",
+				Message: err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to subscribe",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// The same response whether or not the email is already subscribed
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Please check your email to confirm your subscription",
+	})
+}
+
+// Confirm completes a newsletter sign-up with the token from the confirmation email
+func (h *NewsletterHandler) Confirm(c *gin.Context) {
+	var req models.NewsletterConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if _, err := h.newsletterService.Confirm(req.Token); err != nil {
+		h.linkError(c, "Failed to confirm subscription", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Your subscription is confirmed",
+	})
+}
+
+// Unsubscribe removes an email from the newsletter using a signed link. Mail
+// clients doing a one-click unsubscribe (RFC 8058) post a form body with the
+// email and token in the query string; the site posts them as JSON.
+func (h *NewsletterHandler) Unsubscribe(c *gin.Context) {
+	req := models.NewsletterUnsubscribeRequest{
+		Email: c.Query("email"),
+		Token: c.Query("token"),
+	}
+	if req.Email == "" || req.Token == "" {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid request",
+				Message: err.Error(),
+				Fields:  bindingFieldErrors(err, &req),
+			})
+			return
+		}
+	}
+
+	if err := h.newsletterService.Unsubscribe(req.Email, req.Token); err != nil {
+		h.linkError(c, "Failed to unsubscribe", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "You have been unsubscribed",
+	})
+}
+
+// linkError maps confirmation and unsubscribe link errors to responses
+func (h *NewsletterHandler) linkError(c *gin.Context, message string, err error) {
+	if errors.Is(err, services.ErrInvalidNewsletterLink) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Error:   message,
+		Message: err.Error(),
+	})
+}
+
+// parseNewsletterFilter reads the segment query parameters
+func parseNewsletterFilter(c *gin.Context) (models.NewsletterFilter, error) {
+	var filter models.NewsletterFilter
+
+	filter.Status = c.Query("status")
+	switch filter.Status {
+	case "", models.NewsletterStatusPending, models.NewsletterStatusSubscribed, models.NewsletterStatusUnsubscribed:
+	default:
+		return filter, &queryParamError{name: "status"}
+	}
+
+	if value := c.Query("program_id"); value != "" {
+		programID, err := strconv.Atoi(value)
+		if err != nil || programID < 0 {
+			return filter, &queryParamError{name: "program_id"}
+		}
+		filter.ProgramID = programID
+	}
+
+	return filter, nil
+}
+
+// GetSubscribers lists newsletter subscribers, optionally filtered by status
+// and program interest (admin only)
+func (h *NewsletterHandler) GetSubscribers(c *gin.Context) {
+	filter, err := parseNewsletterFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid filter",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	subscribers, err := h.newsletterService.GetSubscribers(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to fetch subscribers",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, subscribers)
+}
+
+// ExportSubscribers downloads a segment of newsletter subscribers as CSV (admin only)
+func (h *NewsletterHandler) ExportSubscribers(c *gin.Context) {
+	filter, err := parseNewsletterFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid filter",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	filename := "newsletter-subscribers-" + time.Now().Format("20060102") + ".csv"
+
+	// Headers are sent with the first row, so errors before any row is
+	// written can still be reported as JSON
+	started := false
+	writer := csv.NewWriter(c.Writer)
+	write := func(row []string) error {
+		if !started {
+			started = true
+			c.Header("Content-Type", "text/csv; charset=utf-8")
+			c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+			c.Status(http.StatusOK)
+		}
+		if err := writer.Write(csvSafeRow(row)); err != nil {
+			return err
+		}
+		return writer.Error()
+	}
+
+	err = h.newsletterService.ExportSubscribers(filter, write)
+	if err == nil {
+		writer.Flush()
+		err = writer.Error()
+	}
+	if err == nil {
+		return
+	}
+
+	if started {
+		// The response is already under way; all we can do is cut it short
+		log.Printf("Newsletter export failed mid-stream: %v", err)
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Error:   "Failed to export subscribers",
+		Message: err.Error(),
+	})
+}
+
+// SendNewsletter queues a newsletter issue for the confirmed subscribers of a
+// segment (admin only)
+func (h *NewsletterHandler) SendNewsletter(c *gin.Context) {
+	var req models.NewsletterSendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Fields:  bindingFieldErrors(err, &req),
+		})
+		return
+	}
+
+	result, err := h.newsletterService.SendNewsletter(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidTemplate), errors.Is(err, services.ErrInvalidNewsletter):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid newsletter",
+				Message: err.Error(),
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Failed to send newsletter",
+				Message: err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Newsletter queued for delivery",
+		Data:    result,
+	})
+}
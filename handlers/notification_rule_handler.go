@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"plantbased-backend/models"
+	"plantbased-backend/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationRuleHandler struct {
+	notificationRuleService *services.NotificationRuleService
+}
+
+func NewNotificationRuleHandler(notificationRuleService *services.NotificationRuleService) *NotificationRuleHandler {
+	return &NotificationRuleHandler{notificationRuleService: notificationRuleService}
+}
+
+// GetRules lists the notification rules, optionally for one event (admin only)
+func (h *NotificationRuleHandler) GetRules(c *gin.Context) {
+	event := c.Query("event")
+	if event != "" && !models.IsNotificationEvent(event) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid filter",
+			Message: (&queryParamError{name: "event"}).Error(),
+		})
+		return
+	}
+
+	rules, err := h.notificationRuleService.GetRules(event)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to fetch notification rules",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// GetRule retrieves a single notification rule (admin only)
+func (h *NotificationRuleHandler) GetRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid notification rule ID",
+		})
+		return
+	}
+
+	rule, err := h.notificationRuleService.GetRuleByID(id)
+	if err != nil {
+		h.ruleError(c, "Failed to fetch notification rule", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// CreateRule creates a notification rule (admin only)
+func (h *NotificationRuleHandler) CreateRule(c *gin.Context) {
+	var req models.NotificationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Fields:  bindingFieldErrors(err, &req),
+		})
+		return
+	}
+
+	rule, err := h.notificationRuleService.CreateRule(req)
+	if err != nil {
+		h.ruleError(c, "Failed to create notification rule", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateRule replaces a notification rule (admin only)
+func (h *NotificationRuleHandler) UpdateRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid notification rule ID",
+		})
+		return
+	}
+
+	var req models.NotificationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Fields:  bindingFieldErrors(err, &req),
+		})
+		return
+	}
+
+	rule, err := h.notificationRuleService.UpdateRule(id, req)
+	if err != nil {
+		h.ruleError(c, "Failed to update notification rule", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Notification rule updated successfully",
+		Data:    rule,
+	})
+}
+
+// DeleteRule deletes a notification rule (admin only)
+func (h *NotificationRuleHandler) DeleteRule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid notification rule ID",
+		})
+		return
+	}
+
+	if err := h.notificationRuleService.DeleteRule(id); err != nil {
+		h.ruleError(c, "Failed to delete notification rule", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Notification rule deleted successfully",
+	})
+}
+
+// ruleError maps notification rule errors to responses
+func (h *NotificationRuleHandler) ruleError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrNotificationRuleNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidNotificationRule):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid notification rule",
+			Message: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   message,
+			Message: err.Error(),
+		})
+	}
+}
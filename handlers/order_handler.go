@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"plantbased-backend/models"
+	"plantbased-backend/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrderHandler struct {
+	orderService    *services.OrderService
+	refundService   *services.RefundService
+	customerService *services.CustomerService
+}
+
+func NewOrderHandler(
+	orderService *services.OrderService,
+	refundService *services.RefundService,
+	customerService *services.CustomerService,
+) *OrderHandler {
+	return &OrderHandler{
+		orderService:    orderService,
+		refundService:   refundService,
+		customerService: customerService,
+	}
+}
+
+// parseOrderFilter reads order filters from the query string
+func parseOrderFilter(c *gin.Context) (models.OrderFilter, error) {
+	filter := models.OrderFilter{
+		Status:    c.Query("status"),
+		Email:     c.Query("email"),
+		Reference: c.Query("reference"),
+	}
+
+	intParams := map[string]*int{
+		"program_id": &filter.ProgramID,
+		"plan_id":    &filter.PlanID,
+		"limit":      &filter.Limit,
+		"offset":     &filter.Offset,
+	}
+	for name, target := range intParams {
+		if value := c.Query(name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				return filter, &queryParamError{name: name}
+			}
+			*target = parsed
+		}
+	}
+
+	if value := c.Query("from"); value != "" {
+		from, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return filter, &queryParamError{name: "from"}
+		}
+		filter.From = &from
+	}
+
+	if value := c.Query("to"); value != "" {
+		to, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return filter, &queryParamError{name: "to"}
+		}
+		// Include the whole "to" day
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+
+	return filter, nil
+}
+
+// queryParamError reports an invalid query string parameter
+type queryParamError struct {
+	name string
+}
+
+func (e *queryParamError) Error() string {
+	return "invalid value for query parameter: " + e.name
+}
+
+// GetOrders lists orders with optional filters (admin only)
+func (h *OrderHandler) GetOrders(c *gin.Context) {
+	filter, err := parseOrderFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid filter",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	orders, err := h.orderService.GetOrders(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to fetch orders",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+// GetUnmatchedOrders lists paid orders that could not be matched to a lead (admin only)
+func (h *OrderHandler) GetUnmatchedOrders(c *gin.Context) {
+	filter, err := parseOrderFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid filter",
+			Message: err.Error(),
+		})
+		return
+	}
+	filter.Unmatched = true
+
+	orders, err := h.orderService.GetOrders(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to fetch unmatched orders",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+// LinkLead links an unmatched order to a lead and marks the lead enrolled (admin only)
+func (h *OrderHandler) LinkLead(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid order ID",
+		})
+		return
+	}
+
+	var req models.LinkLeadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	lead, err := h.customerService.LinkOrder(id, req.LeadID, c.GetInt("adminID"))
+	if errors.Is(err, services.ErrInvalidLead) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid link",
+			Message: err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, services.ErrLeadNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to link order",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Order linked to lead successfully",
+		Data:    lead,
+	})
+}
+
+// GetOrderByID retrieves a single order (admin only)
+func (h *OrderHandler) GetOrderByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid order ID",
+		})
+		return
+	}
+
+	order, err := h.orderService.GetOrderByID(id)
+	if errors.Is(err, services.ErrOrderNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to fetch order",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// RefundOrder starts a full or partial refund of an order (admin only)
+func (h *OrderHandler) RefundOrder(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid order ID",
+		})
+		return
+	}
+
+	// An empty body refunds the remaining balance
+	var req models.RefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	refund, err := h.refundService.InitiateRefund(id, req, c.GetInt("adminID"))
+	if errors.Is(err, services.ErrOrderNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, services.ErrInvalidRefund) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid refund",
+			Message: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to refund order",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, refund)
+}
+
+// GetOrderRefunds retrieves the refund history of an order (admin only)
+func (h *OrderHandler) GetOrderRefunds(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid order ID",
+		})
+		return
+	}
+
+	refunds, err := h.refundService.GetRefundsByOrderID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to fetch refunds",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, refunds)
+}
+
+// GetOrderDisputes retrieves the disputes raised against an order (admin only)
+func (h *OrderHandler) GetOrderDisputes(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid order ID",
+		})
+		return
+	}
+
+	disputes, err := h.refundService.GetDisputesByOrderID(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to fetch disputes",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, disputes)
+}
@@ -1,19 +1,27 @@
 package handlers
 
 import (
+	"errors"
 	"io"
+	"log"
+	"net/http"
 	"plantbased-backend/models"
 	"plantbased-backend/services"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
 type PaymentHandler struct {
 	paymentService *services.PaymentService
+	webhookService *services.WebhookService
 }
 
-func NewPaymentHandler(paymentService *services.PaymentService) *PaymentHandler {
-	return &PaymentHandler{paymentService: paymentService}
+func NewPaymentHandler(paymentService *services.PaymentService, webhookService *services.WebhookService) *PaymentHandler {
+	return &PaymentHandler{
+		paymentService: paymentService,
+		webhookService: webhookService,
+	}
 }
 
 func (h *PaymentHandler) HandleWebhook(c *gin.Context) {
@@ -43,19 +51,106 @@ func (h *PaymentHandler) HandleWebhook(c *gin.Context) {
 		return
 	}
 
-	var webhook models.PaystackWebhook
-	if err := c.ShouldBindJSON(&webhook); err != nil {
-		c.JSON(400, models.ErrorResponse{
-			Error:   "invalid_request",
-			Message: "Invalid webhook data",
+	duplicate, err := h.webhookService.ProcessPayload(body)
+	if errors.Is(err, services.ErrInvalidWebhookPayload) {
+		// Redelivering the same payload cannot fix it, so acknowledge it to
+		// stop the retries; decodable events stay in the ledger as failed
+		log.Printf("Ignoring webhook that could not be decoded: %v", err)
+		c.JSON(200, gin.H{"status": "ignored"})
+		return
+	}
+	if err != nil {
+		// A non-2xx response makes Paystack retry the webhook later
+		log.Printf("Failed to process webhook: %v", err)
+		c.JSON(500, models.ErrorResponse{
+			Error:   "processing_failed",
+			Message: "Failed to process webhook",
 		})
 		return
 	}
 
-	if webhook.Event == "charge.success" && webhook.Data.Status == "success" {
-		// Payment successful - handle it here
-		// TODO: Store order in database, send confirmation email, etc.
+	if duplicate {
+		c.JSON(200, gin.H{"status": "duplicate"})
+		return
 	}
 
 	c.JSON(200, gin.H{"status": "success"})
-}
\ No newline at end of file
+}
+
+// Checkout starts a Paystack transaction for a program pricing plan.
+// The amount is always computed on the server from the selected plan.
+func (h *PaymentHandler) Checkout(c *gin.Context) {
+	var req models.CheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response, err := h.paymentService.InitializeCheckout(req)
+	if errors.Is(err, services.ErrInvalidCoupon) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid coupon",
+			Message: err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, services.ErrInvalidCheckout) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid checkout",
+			Message: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, models.ErrorResponse{
+			Error:   "Failed to start checkout",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetWebhookEvents lists recorded webhook deliveries, optionally filtered by status (admin only)
+func (h *PaymentHandler) GetWebhookEvents(c *gin.Context) {
+	events, err := h.webhookService.GetEvents(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to fetch webhook events",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// RetryWebhookEvent reprocesses a failed webhook event (admin only)
+func (h *PaymentHandler) RetryWebhookEvent(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid webhook event ID",
+		})
+		return
+	}
+
+	event, err := h.webhookService.RetryEvent(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to retry webhook event",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Webhook event processed successfully",
+		Data:    event,
+	})
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"plantbased-backend/models"
+	"plantbased-backend/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PortalHandler serves the customer portal (/me). Every route requires a
+// customer token.
+type PortalHandler struct {
+	portalService *services.PortalService
+}
+
+func NewPortalHandler(portalService *services.PortalService) *PortalHandler {
+	return &PortalHandler{portalService: portalService}
+}
+
+// portalError maps portal service errors to responses
+func (h *PortalHandler) portalError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrAccountInactive):
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: err.Error(),
+		})
+	case errors.Is(err, services.ErrInvoiceNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: err.Error(),
+		})
+	case errors.Is(err, services.ErrNotEntitled):
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   err.Error(),
+			Message: "Purchase the program to see its content",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   message,
+			Message: err.Error(),
+		})
+	}
+}
+
+// GetAccount returns the logged-in customer's account
+func (h *PortalHandler) GetAccount(c *gin.Context) {
+	account, err := h.portalService.GetAccount(c.GetInt("customerID"))
+	if err != nil {
+		h.portalError(c, "Failed to fetch account", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// GetPrograms lists the programs the customer has paid for, with full content
+func (h *PortalHandler) GetPrograms(c *gin.Context) {
+	programs, err := h.portalService.GetPrograms(c.GetInt("customerID"))
+	if err != nil {
+		h.portalError(c, "Failed to fetch programs", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, programs)
+}
+
+// GetProgramByID returns the full content of a program the customer has paid for
+func (h *PortalHandler) GetProgramByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid program ID",
+		})
+		return
+	}
+
+	program, err := h.portalService.GetProgram(c.GetInt("customerID"), id)
+	if err != nil {
+		h.portalError(c, "Failed to fetch program", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, program)
+}
+
+// GetOrders lists the customer's orders
+func (h *PortalHandler) GetOrders(c *gin.Context) {
+	orders, err := h.portalService.GetOrders(c.GetInt("customerID"))
+	if err != nil {
+		h.portalError(c, "Failed to fetch orders", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+// GetReceipts lists the customer's invoices
+func (h *PortalHandler) GetReceipts(c *gin.Context) {
+	invoices, err := h.portalService.GetInvoices(c.GetInt("customerID"))
+	if err != nil {
+		h.portalError(c, "Failed to fetch receipts", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, invoices)
+}
+
+// DownloadReceipt serves the PDF of one of the customer's invoices
+func (h *PortalHandler) DownloadReceipt(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid receipt ID",
+		})
+		return
+	}
+
+	number, pdf, err := h.portalService.GetInvoicePDF(c.GetInt("customerID"), id)
+	if err != nil {
+		h.portalError(c, "Failed to fetch receipt", err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+number+`.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// GetSubscriptions lists the customer's subscriptions and their status
+func (h *PortalHandler) GetSubscriptions(c *gin.Context) {
+	subscriptions, err := h.portalService.GetSubscriptions(c.GetInt("customerID"))
+	if err != nil {
+		h.portalError(c, "Failed to fetch subscriptions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"mime/multipart"
 	"net/http"
 	"plantbased-backend/models"
@@ -93,6 +94,13 @@ func (h *ProgramHandler) CreateProgram(c *gin.Context) {
 
 	// Create program
 	response, err := h.programService.CreateProgram(req, images)
+	if errors.Is(err, services.ErrInvalidPricingPlan) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid pricing plan",
+			Message: err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to create program",
@@ -183,6 +191,13 @@ func (h *ProgramHandler) UpdateProgram(c *gin.Context) {
 
 	// Update program
 	response, err := h.programService.UpdateProgram(id, req, images)
+	if errors.Is(err, services.ErrInvalidPricingPlan) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid pricing plan",
+			Message: err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to update program",
@@ -194,7 +209,8 @@ func (h *ProgramHandler) UpdateProgram(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
-// GetAllPrograms retrieves all programs
+// GetAllPrograms lists programs with their marketing fields and pricing plans.
+// The full content is only served to admins and enrolled customers.
 func (h *ProgramHandler) GetAllPrograms(c *gin.Context) {
 	programs, err := h.programService.GetAllPrograms()
 	if err != nil {
@@ -205,17 +221,63 @@ func (h *ProgramHandler) GetAllPrograms(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, programs)
+	summaries := make([]models.PublicProgramResponse, len(programs))
+	for i, program := range programs {
+		summaries[i] = models.PublicProgramResponse{
+			Program:      program.Program.Summary(),
+			PricingPlans: program.PricingPlans,
+		}
+	}
+
+	c.JSON(http.StatusOK, summaries)
 }
 
-// GetProgramByID retrieves a single program
+// GetProgramByID retrieves a single program's marketing fields and pricing plans
 func (h *ProgramHandler) GetProgramByID(c *gin.Context) {
+	program, ok := h.loadProgram(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PublicProgramResponse{
+		Program:      program.Program.Summary(),
+		PricingPlans: program.PricingPlans,
+	})
+}
+
+// GetProgramsWithContent lists programs with their full content (admin only)
+func (h *ProgramHandler) GetProgramsWithContent(c *gin.Context) {
+	programs, err := h.programService.GetAllPrograms()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to fetch programs",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, programs)
+}
+
+// GetProgramWithContent retrieves a single program with its full content (admin only)
+func (h *ProgramHandler) GetProgramWithContent(c *gin.Context) {
+	program, ok := h.loadProgram(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, program)
+}
+
+// loadProgram fetches the program named by the id parameter with its pricing
+// plans, writing an error response when it cannot
+func (h *ProgramHandler) loadProgram(c *gin.Context) (*models.ProgramResponse, bool) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error: "Invalid program ID",
 		})
-		return
+		return nil, false
 	}
 
 	program, err := h.programService.GetProgramByID(id)
@@ -223,7 +285,7 @@ func (h *ProgramHandler) GetProgramByID(c *gin.Context) {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
 			Error: err.Error(),
 		})
-		return
+		return nil, false
 	}
 
 	// Get pricing plans
@@ -232,13 +294,13 @@ func (h *ProgramHandler) GetProgramByID(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error: "Failed to fetch pricing plans",
 		})
-		return
+		return nil, false
 	}
 
-	c.JSON(http.StatusOK, models.ProgramResponse{
+	return &models.ProgramResponse{
 		Program:      *program,
 		PricingPlans: pricingPlans,
-	})
+	}, true
 }
 
 // DeleteProgram deletes a program
@@ -286,6 +348,13 @@ func (h *ProgramHandler) AddPricingPlan(c *gin.Context) {
 	}
 
 	plan, err := h.programService.AddPricingPlan(programID, req)
+	if errors.Is(err, services.ErrInvalidPricingPlan) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid pricing plan",
+			Message: err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to add pricing plan",
@@ -325,6 +394,19 @@ func (h *ProgramHandler) UpdatePricingPlan(c *gin.Context) {
 	}
 
 	plan, err := h.programService.UpdatePricingPlan(programID, planID, req)
+	if errors.Is(err, services.ErrPricingPlanNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, services.ErrInvalidPricingPlan) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid pricing plan",
+			Message: err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to update pricing plan",
@@ -355,6 +437,12 @@ func (h *ProgramHandler) DeletePricingPlan(c *gin.Context) {
 	}
 
 	err = h.programService.DeletePricingPlan(programID, planID)
+	if errors.Is(err, services.ErrPricingPlanNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to delete pricing plan",
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"plantbased-backend/models"
+	"plantbased-backend/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProgressHandler struct {
+	progressService *services.ProgressService
+}
+
+func NewProgressHandler(progressService *services.ProgressService) *ProgressHandler {
+	return &ProgressHandler{progressService: progressService}
+}
+
+// progressError maps progress service errors to responses
+func (h *ProgressHandler) progressError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidProgress):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrNotEntitled), errors.Is(err, services.ErrAccountInactive):
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   message,
+			Message: err.Error(),
+		})
+	}
+}
+
+// programIDParam parses the program id parameter, writing an error response when invalid
+func programIDParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid program ID",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// GetProgress returns the customer's completed sections of a program
+func (h *ProgressHandler) GetProgress(c *gin.Context) {
+	id, ok := programIDParam(c)
+	if !ok {
+		return
+	}
+
+	progress, err := h.progressService.GetProgress(c.GetInt("customerID"), id)
+	if err != nil {
+		h.progressError(c, "Failed to fetch progress", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// CompleteSection marks a program section complete for the customer
+func (h *ProgressHandler) CompleteSection(c *gin.Context) {
+	h.setSectionComplete(c, true)
+}
+
+// UncompleteSection marks a program section not complete for the customer
+func (h *ProgressHandler) UncompleteSection(c *gin.Context) {
+	h.setSectionComplete(c, false)
+}
+
+func (h *ProgressHandler) setSectionComplete(c *gin.Context, complete bool) {
+	id, ok := programIDParam(c)
+	if !ok {
+		return
+	}
+
+	progress, err := h.progressService.SetSectionComplete(c.GetInt("customerID"), id, c.Param("section"), complete)
+	if err != nil {
+		h.progressError(c, "Failed to update progress", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Success: true,
+		Message: "Progress updated successfully",
+		Data:    progress,
+	})
+}
+
+// RecordCheckIn records the customer's daily check-in for a program
+func (h *ProgressHandler) RecordCheckIn(c *gin.Context) {
+	id, ok := programIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.CheckInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Message: err.Error(),
+			Fields:  bindingFieldErrors(err, &req),
+		})
+		return
+	}
+
+	checkIn, err := h.progressService.RecordCheckIn(c.GetInt("customerID"), id, req)
+	if err != nil {
+		h.progressError(c, "Failed to record check-in", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, checkIn)
+}
+
+// GetCheckIns lists the customer's check-ins for a program
+func (h *ProgressHandler) GetCheckIns(c *gin.Context) {
+	id, ok := programIDParam(c)
+	if !ok {
+		return
+	}
+
+	checkIns, err := h.progressService.GetCheckIns(c.GetInt("customerID"), id)
+	if err != nil {
+		h.progressError(c, "Failed to fetch check-ins", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, checkIns)
+}
+
+// GetCompletionStats summarizes customer progress for every program (admin only)
+func (h *ProgressHandler) GetCompletionStats(c *gin.Context) {
+	stats, err := h.progressService.GetCompletionStats(0)
+	if err != nil {
+		h.progressError(c, "Failed to fetch completion statistics", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetProgramCompletionStats summarizes customer progress for one program (admin only)
+func (h *ProgressHandler) GetProgramCompletionStats(c *gin.Context) {
+	id, ok := programIDParam(c)
+	if !ok {
+		return
+	}
+
+	stats, err := h.progressService.GetCompletionStats(id)
+	if err != nil {
+		h.progressError(c, "Failed to fetch completion statistics", err)
+		return
+	}
+	if len(stats) == 0 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "program not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats[0])
+}
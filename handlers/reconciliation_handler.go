@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"plantbased-backend/models"
+	"plantbased-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReconciliationHandler struct {
+	reconciliationService *services.ReconciliationService
+}
+
+func NewReconciliationHandler(reconciliationService *services.ReconciliationService) *ReconciliationHandler {
+	return &ReconciliationHandler{reconciliationService: reconciliationService}
+}
+
+// RunReconciliation triggers a payment reconciliation run on demand (admin only)
+func (h *ReconciliationHandler) RunReconciliation(c *gin.Context) {
+	run, err := h.reconciliationService.Run("manual")
+	if errors.Is(err, services.ErrReconciliationRunning) {
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to run reconciliation",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// GetLastRun shows the result of the most recent reconciliation run (admin only)
+func (h *ReconciliationHandler) GetLastRun(c *gin.Context) {
+	run, err := h.reconciliationService.GetLastRun()
+	if errors.Is(err, services.ErrNoReconciliationRuns) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to fetch reconciliation run",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
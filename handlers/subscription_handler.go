@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"plantbased-backend/models"
+	"plantbased-backend/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SubscriptionHandler struct {
+	subscriptionService *services.SubscriptionService
+}
+
+func NewSubscriptionHandler(subscriptionService *services.SubscriptionService) *SubscriptionHandler {
+	return &SubscriptionHandler{subscriptionService: subscriptionService}
+}
+
+// GetSubscriptions lists subscribers, optionally filtered by status (admin only)
+func (h *SubscriptionHandler) GetSubscriptions(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", models.SubscriptionStatusActive, models.SubscriptionStatusPastDue, models.SubscriptionStatusCancelled:
+	default:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid filter",
+			Message: "status must be one of active, past_due or cancelled",
+		})
+		return
+	}
+
+	subscriptions, err := h.subscriptionService.GetSubscriptions(status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to fetch subscriptions",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+// GetSubscriptionByID retrieves a single subscription (admin only)
+func (h *SubscriptionHandler) GetSubscriptionByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid subscription ID",
+		})
+		return
+	}
+
+	subscription, err := h.subscriptionService.GetSubscriptionByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
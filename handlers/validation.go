@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// bindingFieldErrors converts binding validation errors on obj into messages
+// keyed by JSON field name. It returns nil for other errors, such as
+// malformed JSON.
+func bindingFieldErrors(err error, obj interface{}) map[string]string {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil
+	}
+
+	objType := reflect.TypeOf(obj)
+	for objType.Kind() == reflect.Ptr {
+		objType = objType.Elem()
+	}
+
+	fields := map[string]string{}
+	for _, fieldErr := range validationErrors {
+		name := fieldErr.Field()
+		if field, ok := objType.FieldByName(fieldErr.StructField()); ok {
+			if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+				name = tag
+			}
+		}
+		if _, exists := fields[name]; !exists {
+			fields[name] = validationMessage(fieldErr)
+		}
+	}
+	return fields
+}
+
+func validationMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "max":
+		return "must be at most " + fieldErr.Param() + " characters"
+	case "min":
+		return "must be at least " + fieldErr.Param() + " characters"
+	case "oneof":
+		return "must be one of " + fieldErr.Param()
+	}
+	return "is invalid"
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"errors"
+	"plantbased-backend/models"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+func TestBindingFieldErrors(t *testing.T) {
+	details := models.CustomerDetails{
+		FullName:    "Adaeze Okafor",
+		Email:       "not-an-email",
+		PhoneNumber: string(make([]byte, 51)),
+		Program:     "30-Day Reset",
+		Package:     "Standard",
+	}
+
+	err := binding.Validator.ValidateStruct(&details)
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+
+	// Fields are keyed by their JSON names
+	want := map[string]string{
+		"email":       "must be a valid email address",
+		"nationality": "is required",
+		"phoneNumber": "must be at most 50 characters",
+	}
+	if got := bindingFieldErrors(err, &details); !reflect.DeepEqual(got, want) {
+		t.Errorf("bindingFieldErrors() = %v, want %v", got, want)
+	}
+
+	if got := bindingFieldErrors(errors.New("unexpected EOF"), &details); got != nil {
+		t.Errorf("bindingFieldErrors(non-validation error) = %v, want nil", got)
+	}
+}
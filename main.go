@@ -54,7 +54,9 @@ func main() {
 	log.Println("✓ CORS middleware added")
 
 	// Setup routes
-	routes.SetupRoutes(router, db)
+	if err := routes.SetupRoutes(router, db); err != nil {
+		log.Fatal("Failed to configure routes:", err)
+	}
 	log.Println("✓ Routes configured")
 
 	// Start server
@@ -45,4 +45,65 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Set("adminID", claims.AdminID)
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// CustomerAccountChecker reports whether a customer account may still sign in
+type CustomerAccountChecker interface {
+	IsAccountActive(id int) (bool, error)
+}
+
+// CustomerAuthMiddleware authenticates customer accounts with an access
+// token. Admin tokens and refresh tokens are rejected, as are tokens of
+// accounts that have since been deactivated.
+func CustomerAuthMiddleware(accounts CustomerAccountChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error: "Authorization header required",
+			})
+			c.Abort()
+			return
+		}
+
+		parts := strings.Split(authHeader, " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error: "Invalid authorization header format",
+			})
+			c.Abort()
+			return
+		}
+
+		claims, err := utils.ValidateCustomerToken(parts[1], utils.CustomerAccessToken)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "Invalid or expired token",
+				Message: err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		active, err := accounts.IsAccountActive(claims.CustomerID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Failed to check account",
+				Message: err.Error(),
+			})
+			c.Abort()
+			return
+		}
+		if !active {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error: "Account is inactive",
+			})
+			c.Abort()
+			return
+		}
+
+		// Set customer ID in context for use in handlers
+		c.Set("customerID", claims.CustomerID)
+		c.Next()
+	}
+}
@@ -59,12 +59,14 @@ type CreateTestimonialRequest struct {
 	Location string `json:"location" binding:"required"`
 	Review   string `json:"review" binding:"required"`
 	Avatar   string `json:"avatar"`
+	Email    string `json:"email" binding:"omitempty,email"` // Not shown publicly; used for data subject requests
 }
 
 // ErrorResponse represents an error response
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
+	Error   string            `json:"error"`
+	Message string            `json:"message,omitempty"`
+	Fields  map[string]string `json:"fields,omitempty"` // Field-level validation errors keyed by JSON field name
 }
 
 // SuccessResponse represents a success response
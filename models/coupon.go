@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// Coupon discount types
+const (
+	DiscountTypePercentage = "percentage"
+	DiscountTypeFixed      = "fixed"
+)
+
+// Coupon represents a discount code that can be applied at checkout
+type Coupon struct {
+	ID             int        `json:"id"`
+	Code           string     `json:"code"`
+	Description    string     `json:"description"`
+	DiscountType   string     `json:"discount_type"`
+	PercentOff     int        `json:"percent_off,omitempty"` // Percentage coupons, 1-99
+	AmountOff      *Money     `json:"amount_off,omitempty"`  // Fixed coupons
+	ProgramIDs     []int      `json:"program_ids"`           // Empty means every program
+	MaxRedemptions *int       `json:"max_redemptions"`       // Nil means unlimited
+	MaxPerCustomer *int       `json:"max_per_customer"`      // Nil means unlimited
+	ExpiresAt      *time.Time `json:"expires_at"`
+	Active         bool       `json:"active"`
+	TimesRedeemed  int        `json:"times_redeemed"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// CouponRequest represents the payload to create or update a coupon
+type CouponRequest struct {
+	Code           string     `json:"code" binding:"required"`
+	Description    string     `json:"description"`
+	DiscountType   string     `json:"discount_type" binding:"required"`
+	PercentOff     int        `json:"percent_off"`
+	AmountOff      *Money     `json:"amount_off"`
+	ProgramIDs     []int      `json:"program_ids"`
+	MaxRedemptions *int       `json:"max_redemptions"`
+	MaxPerCustomer *int       `json:"max_per_customer"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	Active         *bool      `json:"active"` // Defaults to true
+}
+
+// CouponRedemption records a coupon applied to a checkout
+type CouponRedemption struct {
+	ID             int       `json:"id"`
+	CouponID       *int      `json:"coupon_id"`
+	CouponCode     string    `json:"coupon_code"`
+	OrderID        *int      `json:"order_id"`
+	Reference      string    `json:"reference"` // Paystack transaction reference
+	CustomerEmail  string    `json:"customer_email"`
+	OriginalAmount int64     `json:"original_amount"`
+	DiscountAmount int64     `json:"discount_amount"`
+	Currency       string    `json:"currency"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ValidateCouponRequest represents the payload to price a plan with a coupon
+type ValidateCouponRequest struct {
+	Code      string `json:"code" binding:"required"`
+	ProgramID int    `json:"program_id" binding:"required"`
+	PlanID    int    `json:"plan_id" binding:"required"`
+	Email     string `json:"email" binding:"omitempty,email"` // Enables the per-customer limit check
+}
+
+// CouponQuote is the price of a pricing plan after a coupon is applied
+type CouponQuote struct {
+	Code            string `json:"code"`
+	ProgramID       int    `json:"program_id"`
+	PlanID          int    `json:"plan_id"`
+	OriginalPrice   Money  `json:"original_price"`
+	Discount        Money  `json:"discount"`
+	DiscountedPrice Money  `json:"discounted_price"`
+}
@@ -1,11 +1,114 @@
 package models
 
+import "time"
+
 // CustomerDetails represents customer registration data
 type CustomerDetails struct {
-	FullName    string `json:"fullName"`
-	Email       string `json:"email"`
-	Nationality string `json:"nationality"`
-	PhoneNumber string `json:"phoneNumber"`
-	Program     string `json:"program"`
-	Package     string `json:"package"`
-}
\ No newline at end of file
+	FullName    string `json:"fullName" binding:"required,max=255"`
+	Email       string `json:"email" binding:"required,email,max=255"`
+	Nationality string `json:"nationality" binding:"required,max=100"`
+	Residence   string `json:"countryOfResidence" binding:"max=100"` // Country the phone number is local to; defaults to the nationality
+	PhoneNumber string `json:"phoneNumber" binding:"required,max=50"`
+	Program     string `json:"program" binding:"required,max=255"`
+	Package     string `json:"package" binding:"required,max=255"`
+}
+
+// Lead pipeline statuses
+const (
+	LeadStatusNew       = "new"
+	LeadStatusContacted = "contacted"
+	LeadStatusEnrolled  = "enrolled"
+	LeadStatusLost      = "lost"
+)
+
+// IsValidLeadStatus reports whether status is a lead pipeline status
+func IsValidLeadStatus(status string) bool {
+	switch status {
+	case LeadStatusNew, LeadStatusContacted, LeadStatusEnrolled, LeadStatusLost:
+		return true
+	}
+	return false
+}
+
+// Lead is a stored customer registration
+type Lead struct {
+	ID          int        `json:"id"`
+	FullName    string     `json:"full_name"`
+	Email       string     `json:"email"`
+	Nationality string     `json:"nationality"`
+	CountryCode string     `json:"country_code"` // ISO 3166-1 alpha-2
+	PhoneNumber string     `json:"phone_number"` // E.164
+	Program     string     `json:"program"`
+	ProgramID   *int       `json:"program_id"`
+	Package     string     `json:"package"`
+	PlanID      *int       `json:"plan_id"`
+	Status      string     `json:"status"`
+	AssignedTo  *int       `json:"assigned_to"` // Admin ID
+	NotifiedAt  *time.Time `json:"notified_at"` // When the lead notification email went out
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// LeadFilter holds the optional filters for listing leads
+type LeadFilter struct {
+	Search     string // Matches name, email, phone number or program
+	Status     string
+	AssignedTo int
+	Limit      int
+	Offset     int
+}
+
+// LeadNote is a timestamped admin note on a lead
+type LeadNote struct {
+	ID        int       `json:"id"`
+	LeadID    int       `json:"lead_id"`
+	AdminID   *int      `json:"admin_id"`
+	AdminName string    `json:"admin_name"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LeadStatusChange is an entry in a lead's status history
+type LeadStatusChange struct {
+	ID         int       `json:"id"`
+	LeadID     int       `json:"lead_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	AdminID    *int      `json:"admin_id"` // Nil for automatic changes
+	AdminName  string    `json:"admin_name"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// LeadDetail is a lead with its notes and status history
+type LeadDetail struct {
+	Lead
+	Notes   []LeadNote         `json:"notes"`
+	History []LeadStatusChange `json:"history"`
+}
+
+// LeadStatusRequest represents the payload to move a lead to a new status
+type LeadStatusRequest struct {
+	Status string `json:"status" binding:"required"`
+	Note   string `json:"note"` // Optional note stored with the change
+}
+
+// LeadNoteRequest represents the payload to add a note to a lead
+type LeadNoteRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// LeadAssignRequest represents the payload to assign a lead; a nil admin unassigns it
+type LeadAssignRequest struct {
+	AdminID *int `json:"admin_id"`
+}
+
+// CustomerExportFilter holds the optional filters for exporting customers.
+// Each exported row is a registration, a payment, or a registration joined
+// with one of its payments.
+type CustomerExportFilter struct {
+	From          *time.Time // Registration date, or payment date for unmatched payments
+	To            *time.Time
+	ProgramID     int
+	Status        string // Lead pipeline status
+	PaymentStatus string // Order status
+}
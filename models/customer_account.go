@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// CustomerAccount is a paying customer's login identity, separate from admins
+type CustomerAccount struct {
+	ID          int        `json:"id"`
+	Email       string     `json:"email"`
+	FullName    string     `json:"full_name"`
+	IsActive    bool       `json:"is_active"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// MagicLinkRequest represents the payload to email a customer a login link
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// MagicLinkVerifyRequest represents the payload to exchange a login link token for a session
+type MagicLinkVerifyRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// CustomerLoginResponse represents a customer login response
+type CustomerLoginResponse struct {
+	Token        string          `json:"token"`
+	RefreshToken string          `json:"refresh_token"`
+	Customer     CustomerAccount `json:"customer"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "testing"
+
+func TestIsValidLeadStatus(t *testing.T) {
+	for _, status := range []string{LeadStatusNew, LeadStatusContacted, LeadStatusEnrolled, LeadStatusLost} {
+		if !IsValidLeadStatus(status) {
+			t.Errorf("IsValidLeadStatus(%q) = false, want true", status)
+		}
+	}
+	for _, status := range []string{"", "won", "New"} {
+		if IsValidLeadStatus(status) {
+			t.Errorf("IsValidLeadStatus(%q) = true, want false", status)
+		}
+	}
+}
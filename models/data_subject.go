@@ -0,0 +1,68 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Data subject audit actions
+const (
+	DataSubjectActionFind      = "find"
+	DataSubjectActionExport    = "export"
+	DataSubjectActionAnonymize = "anonymize"
+)
+
+// DataSubjectRequest identifies the person a data subject request is about
+type DataSubjectRequest struct {
+	Email  string `json:"email" binding:"required,email"`
+	Reason string `json:"reason"` // e.g. the ticket or letter the request came from
+}
+
+// DataSubjectRecords is everything stored about one email address
+type DataSubjectRecords struct {
+	Email             string                 `json:"email"`
+	CustomerAccounts  []CustomerAccount      `json:"customer_accounts"`
+	CheckIns          []CheckIn              `json:"check_ins"`
+	Leads             []LeadDetail           `json:"leads"`
+	Orders            []Order                `json:"orders"`
+	Subscriptions     []Subscription         `json:"subscriptions"`
+	Invoices          []Invoice              `json:"invoices"`
+	CouponRedemptions []CouponRedemption     `json:"coupon_redemptions"`
+	Testimonials      []Testimonial          `json:"testimonials"`
+	WebhookEvents     []WebhookEvent         `json:"webhook_events"`
+	Emails            []OutboxEmail          `json:"emails"`
+	Newsletter        []NewsletterSubscriber `json:"newsletter"`
+	GeneratedAt       time.Time              `json:"generated_at"`
+}
+
+// DataSubjectAnonymizeResult counts the records anonymized for an email.
+// Orders, invoices and other financial records are kept with the personal
+// details replaced.
+type DataSubjectAnonymizeResult struct {
+	CustomerAccounts  int `json:"customer_accounts"`
+	SectionProgress   int `json:"section_progress"` // Deleted
+	CheckIns          int `json:"check_ins"`        // Deleted
+	Leads             int `json:"leads"`
+	LeadNotes         int `json:"lead_notes"`
+	Orders            int `json:"orders"`
+	Subscriptions     int `json:"subscriptions"`
+	Invoices          int `json:"invoices"`
+	CouponRedemptions int `json:"coupon_redemptions"`
+	Testimonials      int `json:"testimonials"`
+	WebhookEvents     int `json:"webhook_events"`
+	Emails            int `json:"emails"`     // Deleted
+	Newsletter        int `json:"newsletter"` // Deleted
+}
+
+// DataSubjectAuditEntry records an action taken on a person's data. The email
+// is kept only as a hash so the trail outlives anonymization.
+type DataSubjectAuditEntry struct {
+	ID        int             `json:"id"`
+	Action    string          `json:"action"`
+	EmailHash string          `json:"email_hash"`
+	Reason    string          `json:"reason"`
+	AdminID   *int            `json:"admin_id"`
+	AdminName string          `json:"admin_name"`
+	Details   json.RawMessage `json:"details"`
+	CreatedAt time.Time       `json:"created_at"`
+}
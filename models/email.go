@@ -0,0 +1,91 @@
+package models
+
+import "time"
+
+// Email outbox statuses
+const (
+	EmailStatusPending = "pending" // Waiting for its first attempt or a retry
+	EmailStatusSent    = "sent"
+	EmailStatusDead    = "dead" // Gave up after the maximum attempts; can be resent by an admin
+)
+
+// Kinds of outgoing email
+const (
+	EmailKindLeadNotification  = "lead_notification"
+	EmailKindOrderConfirmation = "order_confirmation"
+	EmailKindMagicLink         = "magic_link"
+	EmailKindRegistrationAck   = "registration_acknowledgement"
+	EmailKindWelcome           = "welcome"
+
+	// Newsletter
+	EmailKindNewsletterConfirmation = "newsletter_confirmation"
+	EmailKindNewsletter             = "newsletter" // Issues written by admins; not a template
+
+	// Internal notifications, sent to the recipients of the notification rules
+	EmailKindPaymentNotification     = "payment_notification"
+	EmailKindRefundNotification      = "refund_notification"
+	EmailKindTestimonialNotification = "testimonial_notification"
+)
+
+// OutboxEmail is a message queued in the email outbox. The message itself is
+// not exposed, and is dropped once sent.
+type OutboxEmail struct {
+	ID            int        `json:"id"`
+	Kind          string     `json:"kind"`
+	ReferenceID   *int       `json:"reference_id"`   // The record the email is about, e.g. a lead, invoice or refund
+	CustomerEmail string     `json:"customer_email"` // The person the email is about, for data subject requests
+	Recipients    []string   `json:"recipients"`
+	Subject       string     `json:"subject"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	ExpiresAt     *time.Time `json:"expires_at"` // Not sent after this, e.g. for login links
+	SentAt        *time.Time `json:"sent_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// EmailTemplate is the subject, HTML body and plain-text body of one kind of
+// email. Subjects and plain-text bodies are text/template templates; HTML
+// bodies are html/template templates.
+type EmailTemplate struct {
+	Key         string     `json:"key"` // The email kind
+	Description string     `json:"description"`
+	Subject     string     `json:"subject"`
+	HTMLBody    string     `json:"html_body"`
+	TextBody    string     `json:"text_body"`
+	Variables   []string   `json:"variables"`  // Fields available to the templates
+	IsDefault   bool       `json:"is_default"` // True until an admin edits the template
+	Enabled     bool       `json:"enabled"`    // Disabled kinds of email are not sent
+	Required    bool       `json:"required"`   // Cannot be disabled, e.g. login links
+	UpdatedBy   *int       `json:"updated_by"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+}
+
+// EmailTemplateRequest represents the payload to edit an email template
+type EmailTemplateRequest struct {
+	Subject  string `json:"subject" binding:"required"`
+	HTMLBody string `json:"html_body" binding:"required"`
+	TextBody string `json:"text_body" binding:"required"`
+}
+
+// EmailTemplateEnabledRequest represents the payload to switch a kind of email on or off
+type EmailTemplateEnabledRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// EmailTemplatePreviewRequest holds unsaved template changes to preview.
+// Empty fields fall back to the current template.
+type EmailTemplatePreviewRequest struct {
+	Subject  string `json:"subject"`
+	HTMLBody string `json:"html_body"`
+	TextBody string `json:"text_body"`
+}
+
+// EmailTemplatePreview is a template rendered with sample data
+type EmailTemplatePreview struct {
+	Subject  string `json:"subject"`
+	HTMLBody string `json:"html_body"`
+	TextBody string `json:"text_body"`
+}
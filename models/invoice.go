@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Invoice is the receipt issued for a paid order. The PDF itself is only
+// served through the download endpoint.
+type Invoice struct {
+	ID            int        `json:"id"`
+	InvoiceNumber string     `json:"invoice_number"`
+	OrderID       int        `json:"order_id"`
+	Reference     string     `json:"reference"`
+	CustomerEmail string     `json:"customer_email"`
+	ProgramName   string     `json:"program_name"`
+	PlanName      string     `json:"plan_name"`
+	Subtotal      int64      `json:"subtotal"` // Plan price before discounts, in minor units
+	Discount      int64      `json:"discount"`
+	Amount        int64      `json:"amount"` // Amount charged, in minor units
+	Currency      string     `json:"currency"`
+	IssuedAt      time.Time  `json:"issued_at"`
+	EmailedAt     *time.Time `json:"emailed_at"`
+}
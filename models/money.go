@@ -0,0 +1,168 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used when a price does not name a currency
+const DefaultCurrency = "NGN"
+
+// SupportedCurrencies maps the ISO 4217 codes we accept to their display symbols
+var SupportedCurrencies = map[string]string{
+	"NGN": "₦",
+	"USD": "$",
+	"GBP": "£",
+	"EUR": "€",
+	"GHS": "GH₵",
+	"KES": "KSh",
+	"ZAR": "R",
+}
+
+// Money represents an amount in minor units (e.g. kobo) with an ISO 4217 currency
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// moneyJSON is the wire format of Money
+type moneyJSON struct {
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+	Formatted string `json:"formatted,omitempty"`
+}
+
+// MarshalJSON encodes Money with a human readable "formatted" field
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{
+		Amount:    m.Amount,
+		Currency:  m.Currency,
+		Formatted: m.String(),
+	})
+}
+
+// UnmarshalJSON accepts {"amount": 2500000, "currency": "NGN"} with the amount
+// in minor units, or a legacy display string such as "₦25,000"
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		parsed, err := ParseMoney(text)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+
+	var raw moneyJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return errors.New("price must be an object with amount and currency, or a price string")
+	}
+
+	m.Amount = raw.Amount
+	m.Currency = strings.ToUpper(strings.TrimSpace(raw.Currency))
+	if m.Currency == "" {
+		m.Currency = DefaultCurrency
+	}
+	return nil
+}
+
+// Validate checks that the amount is positive and the currency is supported
+func (m Money) Validate() error {
+	if m.Amount <= 0 {
+		return errors.New("price amount must be greater than zero")
+	}
+	if _, ok := SupportedCurrencies[m.Currency]; !ok {
+		return fmt.Errorf("unsupported currency %q", m.Currency)
+	}
+	return nil
+}
+
+// String formats the amount for display, e.g. "₦25,000.00"
+func (m Money) String() string {
+	symbol, ok := SupportedCurrencies[m.Currency]
+	if !ok {
+		symbol = m.Currency + " "
+	}
+	return m.format(symbol)
+}
+
+// CodeString formats the amount with its ISO code instead of a symbol, e.g.
+// "NGN 25,000.00", for output that cannot render currency symbols
+func (m Money) CodeString() string {
+	return m.format(m.Currency + " ")
+}
+
+func (m Money) format(symbol string) string {
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	whole := strconv.FormatInt(amount/100, 10)
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+
+	return fmt.Sprintf("%s%s%s.%02d", sign, symbol, whole, amount%100)
+}
+
+var moneyNumberPattern = regexp.MustCompile(`[0-9][0-9,]*(\.[0-9]+)?`)
+
+// moneyCurrencyMarkers maps currency markers used in free-text prices to ISO
+// codes. Codes are checked before symbols so "USD" is not read as "$".
+var moneyCurrencyMarkers = []struct {
+	marker   string
+	currency string
+}{
+	{"NGN", "NGN"}, {"USD", "USD"}, {"GBP", "GBP"}, {"EUR", "EUR"},
+	{"GHS", "GHS"}, {"KES", "KES"}, {"ZAR", "ZAR"}, {"KSH", "KES"},
+	{"₦", "NGN"}, {"$", "USD"}, {"£", "GBP"}, {"€", "EUR"}, {"₵", "GHS"},
+}
+
+// moneyRandPattern matches the rand symbol, a lone "R" written right before
+// the amount, which cannot be found by substring like the other markers
+var moneyRandPattern = regexp.MustCompile(`(^|[^A-Z])R ?[0-9]`)
+
+// ParseMoney converts a free-text price such as "₦25,000", "NGN 25,000.00" or
+// "KSh 1,500" into Money. It recognises the ISO code and display symbol of
+// every supported currency; prices without a currency marker are assumed to
+// be NGN.
+func ParseMoney(price string) (Money, error) {
+	number := moneyNumberPattern.FindString(price)
+	if number == "" {
+		return Money{}, fmt.Errorf("no amount found in %q", price)
+	}
+
+	currency := DefaultCurrency
+	upper := strings.ToUpper(price)
+	found := false
+	for _, m := range moneyCurrencyMarkers {
+		if strings.Contains(upper, m.marker) {
+			currency, found = m.currency, true
+			break
+		}
+	}
+	if !found && moneyRandPattern.MatchString(upper) {
+		currency = "ZAR"
+	}
+
+	whole, fraction, _ := strings.Cut(strings.ReplaceAll(number, ",", ""), ".")
+	if len(fraction) > 2 {
+		return Money{}, fmt.Errorf("too many decimal places in %q", price)
+	}
+	fraction = (fraction + "00")[:2]
+
+	amount, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount in %q", price)
+	}
+
+	return Money{Amount: amount, Currency: currency}, nil
+}
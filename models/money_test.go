@@ -0,0 +1,128 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		price string
+		want  Money
+	}{
+		{"₦25,000", Money{2500000, "NGN"}},
+		{"NGN 25,000.00", Money{2500000, "NGN"}},
+		{"25000", Money{2500000, "NGN"}},
+		{"$49.99", Money{4999, "USD"}},
+		{"USD 49.9", Money{4990, "USD"}},
+		{"£1,200", Money{120000, "GBP"}},
+		{"€15.50", Money{1550, "EUR"}},
+		{"GH₵300", Money{30000, "GHS"}},
+		{"KES 1,500", Money{150000, "KES"}},
+		{"zar 99", Money{9900, "ZAR"}},
+		{"KSh1,500", Money{150000, "KES"}},
+		{"Ksh 1,500.50", Money{150050, "KES"}},
+		{"R99.99", Money{9999, "ZAR"}},
+		{"From R 1,200", Money{120000, "ZAR"}},
+		{"EUR 10", Money{1000, "EUR"}},
+		{"Price: 500", Money{50000, "NGN"}},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.price)
+		if err != nil {
+			t.Errorf("ParseMoney(%q) error = %v", tt.price, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMoney(%q) = %+v, want %+v", tt.price, got, tt.want)
+		}
+	}
+
+	for _, price := range []string{"", "free", "₦25.005"} {
+		if got, err := ParseMoney(price); err == nil {
+			t.Errorf("ParseMoney(%q) = %+v, want an error", price, got)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{Money{2500000, "NGN"}, "₦25,000.00"},
+		{Money{4999, "USD"}, "$49.99"},
+		{Money{5, "GBP"}, "£0.05"},
+		{Money{123456789, "KES"}, "KSh1,234,567.89"},
+		{Money{-150000, "ZAR"}, "-R1,500.00"},
+		{Money{100, "XYZ"}, "XYZ 1.00"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.money, got, tt.want)
+		}
+	}
+
+	// Every display symbol reads back as its currency
+	for currency := range SupportedCurrencies {
+		money := Money{Amount: 123456, Currency: currency}
+		parsed, err := ParseMoney(money.String())
+		if err != nil || parsed != money {
+			t.Errorf("ParseMoney(%q) = %+v, %v; want %+v", money.String(), parsed, err, money)
+		}
+	}
+
+	if got := (Money{2500000, "NGN"}).CodeString(); got != "NGN 25,000.00" {
+		t.Errorf("CodeString() = %q, want %q", got, "NGN 25,000.00")
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	encoded, err := json.Marshal(Money{2500000, "NGN"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"amount":2500000,"currency":"NGN","formatted":"₦25,000.00"}`; string(encoded) != want {
+		t.Errorf("Marshal = %s, want %s", encoded, want)
+	}
+
+	tests := []struct {
+		input string
+		want  Money
+	}{
+		{`{"amount":2500000,"currency":"NGN"}`, Money{2500000, "NGN"}},
+		{`{"amount":4999,"currency":" usd "}`, Money{4999, "USD"}},
+		{`{"amount":2500000}`, Money{2500000, "NGN"}},
+		{`"₦25,000"`, Money{2500000, "NGN"}},
+	}
+	for _, tt := range tests {
+		var got Money
+		if err := json.Unmarshal([]byte(tt.input), &got); err != nil {
+			t.Errorf("Unmarshal(%s) error = %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Unmarshal(%s) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{`"free"`, `[2500000]`, `true`} {
+		var got Money
+		if err := json.Unmarshal([]byte(input), &got); err == nil {
+			t.Errorf("Unmarshal(%s) = %+v, want an error", input, got)
+		}
+	}
+}
+
+func TestMoneyValidate(t *testing.T) {
+	if err := (Money{2500000, "NGN"}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	for _, m := range []Money{{0, "NGN"}, {-100, "USD"}, {100, "XYZ"}, {100, ""}} {
+		if err := m.Validate(); err == nil {
+			t.Errorf("%+v.Validate() succeeded, want an error", m)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Newsletter subscriber statuses
+const (
+	NewsletterStatusPending      = "pending" // Signed up but has not confirmed by email yet
+	NewsletterStatusSubscribed   = "subscribed"
+	NewsletterStatusUnsubscribed = "unsubscribed"
+)
+
+// NewsletterSubscriber is someone who signed up for the newsletter
+type NewsletterSubscriber struct {
+	ID             int        `json:"id"`
+	Email          string     `json:"email"`
+	Status         string     `json:"status"`
+	ProgramIDs     []int      `json:"program_ids"` // Programs they are interested in; empty for the general newsletter only
+	ConfirmedAt    *time.Time `json:"confirmed_at"`
+	UnsubscribedAt *time.Time `json:"unsubscribed_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// NewsletterSubscribeRequest represents a newsletter sign-up
+type NewsletterSubscribeRequest struct {
+	Email      string `json:"email" binding:"required,email,max=255"`
+	ProgramIDs []int  `json:"program_ids"`
+}
+
+// NewsletterConfirmRequest represents the payload to confirm a sign-up
+type NewsletterConfirmRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// NewsletterUnsubscribeRequest represents the payload to unsubscribe with a
+// signed link
+type NewsletterUnsubscribeRequest struct {
+	Email string `json:"email" binding:"required"`
+	Token string `json:"token" binding:"required"`
+}
+
+// NewsletterFilter selects a segment of subscribers
+type NewsletterFilter struct {
+	Status    string
+	ProgramID int // Subscribers interested in the program
+}
+
+// NewsletterSendRequest represents a newsletter issue to send to the
+// confirmed subscribers of a segment. The subject and plain-text body are
+// text/template templates and the HTML body an html/template template.
+type NewsletterSendRequest struct {
+	Subject   string `json:"subject" binding:"required"`
+	HTMLBody  string `json:"html_body" binding:"required"`
+	TextBody  string `json:"text_body" binding:"required"`
+	ProgramID *int   `json:"program_id"` // Only subscribers interested in this program; nil sends to everyone
+}
+
+// NewsletterSendResult reports how many copies of an issue were queued
+type NewsletterSendResult struct {
+	Queued int `json:"queued"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Events that send internal notification emails
+const (
+	NotificationEventNewLead         = "new_lead"
+	NotificationEventPaymentReceived = "payment_received"
+	NotificationEventRefund          = "refund"
+	NotificationEventNewTestimonial  = "new_testimonial"
+)
+
+// NotificationEvents lists the events in display order
+var NotificationEvents = []string{
+	NotificationEventNewLead,
+	NotificationEventPaymentReceived,
+	NotificationEventRefund,
+	NotificationEventNewTestimonial,
+}
+
+// IsNotificationEvent reports whether event is a known notification event
+func IsNotificationEvent(event string) bool {
+	for _, e := range NotificationEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationRule routes the notification for an event to a set of
+// addresses. Rules for a program replace the general rules for that program's
+// events.
+type NotificationRule struct {
+	ID         int       `json:"id"`
+	Event      string    `json:"event"`
+	ProgramID  *int      `json:"program_id"` // Nil applies to every program without its own rules
+	Recipients []string  `json:"recipients"`
+	CC         []string  `json:"cc"`
+	BCC        []string  `json:"bcc"`
+	IsActive   bool      `json:"is_active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// NotificationRuleRequest represents the payload to create or update a notification rule
+type NotificationRuleRequest struct {
+	Event      string   `json:"event" binding:"required"`
+	ProgramID  *int     `json:"program_id"`
+	Recipients []string `json:"recipients" binding:"required"`
+	CC         []string `json:"cc"`
+	BCC        []string `json:"bcc"`
+	IsActive   *bool    `json:"is_active"` // Defaults to true
+}
@@ -0,0 +1,16 @@
+package models
+
+import "testing"
+
+func TestIsNotificationEvent(t *testing.T) {
+	for _, event := range NotificationEvents {
+		if !IsNotificationEvent(event) {
+			t.Errorf("IsNotificationEvent(%q) = false", event)
+		}
+	}
+	for _, event := range []string{"", "lead", "New_Lead"} {
+		if IsNotificationEvent(event) {
+			t.Errorf("IsNotificationEvent(%q) = true", event)
+		}
+	}
+}
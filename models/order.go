@@ -0,0 +1,174 @@
+package models
+
+import "time"
+
+// Order statuses
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusPaid      = "paid"
+	OrderStatusFailed    = "failed"
+	OrderStatusAbandoned = "abandoned"
+
+	// OrderStatusAmountMismatch flags a charge whose amount or currency does
+	// not match the purchased plan; it must be reviewed before fulfilment
+	OrderStatusAmountMismatch = "amount_mismatch"
+
+	OrderStatusPartiallyRefunded = "partially_refunded"
+	OrderStatusRefunded          = "refunded"
+	OrderStatusDisputed          = "disputed"
+)
+
+// IsValidOrderStatus reports whether status is an order status
+func IsValidOrderStatus(status string) bool {
+	switch status {
+	case OrderStatusPending, OrderStatusPaid, OrderStatusFailed, OrderStatusAbandoned, OrderStatusAmountMismatch,
+		OrderStatusPartiallyRefunded, OrderStatusRefunded, OrderStatusDisputed:
+		return true
+	}
+	return false
+}
+
+// paymentTransitions is the state machine for order payments. A successful
+// charge only moves an order that is still awaiting payment; once money has
+// been received, only refunds and disputes move it.
+var paymentTransitions = map[string][]string{
+	OrderStatusPending:           {OrderStatusPaid, OrderStatusAmountMismatch},
+	OrderStatusFailed:            {OrderStatusPaid, OrderStatusAmountMismatch},
+	OrderStatusAbandoned:         {OrderStatusPaid, OrderStatusAmountMismatch},
+	OrderStatusPaid:              {OrderStatusPartiallyRefunded, OrderStatusRefunded, OrderStatusDisputed},
+	OrderStatusAmountMismatch:    {OrderStatusPartiallyRefunded, OrderStatusRefunded, OrderStatusDisputed},
+	OrderStatusPartiallyRefunded: {OrderStatusPartiallyRefunded, OrderStatusRefunded, OrderStatusDisputed},
+	OrderStatusDisputed:          {OrderStatusPaid, OrderStatusPartiallyRefunded, OrderStatusRefunded, OrderStatusDisputed},
+}
+
+// CanTransitionPayment reports whether an order may move between two payment states
+func CanTransitionPayment(from, to string) bool {
+	for _, allowed := range paymentTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRefundable reports whether refunds may be issued for an order in this status
+func IsRefundable(status string) bool {
+	return status == OrderStatusPaid ||
+		status == OrderStatusAmountMismatch ||
+		status == OrderStatusPartiallyRefunded
+}
+
+// OrderStatusesGrantingAccess lists, as SQL string literals for an IN clause,
+// the order statuses that entitle the customer to the program content. Every
+// query deciding access uses it so they cannot drift apart.
+const OrderStatusesGrantingAccess = `'` + OrderStatusPaid + `', '` + OrderStatusPartiallyRefunded + `', '` + OrderStatusDisputed + `'`
+
+// OrderStatusesAwaitingPayment lists, as SQL string literals for an IN clause,
+// the order statuses a successful charge may still change
+const OrderStatusesAwaitingPayment = `'` + OrderStatusPending + `', '` + OrderStatusFailed + `', '` + OrderStatusAbandoned + `'`
+
+// RefundStatusFor returns the payment status implied by the amount refunded so far
+func RefundStatusFor(amount, refunded int64) string {
+	switch {
+	case refunded <= 0:
+		return OrderStatusPaid
+	case refunded < amount:
+		return OrderStatusPartiallyRefunded
+	default:
+		return OrderStatusRefunded
+	}
+}
+
+// Order represents a payment made through Paystack for a program plan
+type Order struct {
+	ID             int        `json:"id"`
+	Reference      string     `json:"reference"`
+	Amount         int64      `json:"amount"`          // Amount charged, in minor units (kobo)
+	ExpectedAmount *int64     `json:"expected_amount"` // Price of the purchased plan, when known
+	RefundedAmount int64      `json:"refunded_amount"` // Sum of processed refunds, in minor units
+	Currency       string     `json:"currency"`
+	CustomerEmail  string     `json:"customer_email"`
+	ProgramID      *int       `json:"program_id"`
+	PlanID         *int       `json:"plan_id"`
+	ProgramName    string     `json:"program_name"`
+	PlanName       string     `json:"plan_name"`
+	LeadID         *int       `json:"lead_id"` // Registration the payment was matched to
+	Status         string     `json:"status"`
+	PaidAt         *time.Time `json:"paid_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// OrderFilter represents the filters available when listing orders
+type OrderFilter struct {
+	Status    string
+	Email     string
+	Reference string
+	ProgramID int
+	PlanID    int
+	From      *time.Time
+	To        *time.Time
+	Unmatched bool // Only paid orders not yet linked to a lead
+	Limit     int
+	Offset    int
+}
+
+// LinkLeadRequest represents the payload to link an order to a lead by hand
+type LinkLeadRequest struct {
+	LeadID int `json:"lead_id" binding:"required"`
+}
+
+// ReconciliationRun records the outcome of one payment reconciliation pass
+type ReconciliationRun struct {
+	ID         int        `json:"id"`
+	Trigger    string     `json:"trigger"` // "scheduled" or "manual"
+	Checked    int        `json:"checked"`
+	Paid       int        `json:"paid"`
+	Failed     int        `json:"failed"`
+	Abandoned  int        `json:"abandoned"`
+	Unchanged  int        `json:"unchanged"`
+	Errors     []string   `json:"errors"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+// Refund statuses
+const (
+	RefundStatusPending   = "pending"
+	RefundStatusProcessed = "processed"
+	RefundStatusFailed    = "failed"
+)
+
+// Refund represents a full or partial refund of an order
+type Refund struct {
+	ID               int       `json:"id"`
+	OrderID          int       `json:"order_id"`
+	Reference        string    `json:"reference"` // Original transaction reference
+	PaystackRefundID string    `json:"paystack_refund_id"`
+	Amount           int64     `json:"amount"`
+	Currency         string    `json:"currency"`
+	Status           string    `json:"status"`
+	Reason           string    `json:"reason"`
+	InitiatedBy      *int      `json:"initiated_by"` // Admin ID, nil when started outside the admin panel
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// RefundRequest represents the payload to refund an order
+type RefundRequest struct {
+	Amount int64  `json:"amount"` // In minor units; 0 refunds the remaining balance
+	Reason string `json:"reason"`
+}
+
+// Dispute represents a chargeback raised against an order
+type Dispute struct {
+	ID                int       `json:"id"`
+	OrderID           int       `json:"order_id"`
+	Reference         string    `json:"reference"`
+	PaystackDisputeID string    `json:"paystack_dispute_id"`
+	Status            string    `json:"status"`
+	Resolution        string    `json:"resolution"`
+	Category          string    `json:"category"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
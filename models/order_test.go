@@ -0,0 +1,64 @@
+package models
+
+import "testing"
+
+func TestCanTransitionPayment(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{OrderStatusPending, OrderStatusPaid, true},
+		{OrderStatusFailed, OrderStatusPaid, true},
+		{OrderStatusAbandoned, OrderStatusAmountMismatch, true},
+		{OrderStatusPaid, OrderStatusPartiallyRefunded, true},
+		{OrderStatusPaid, OrderStatusRefunded, true},
+		{OrderStatusAmountMismatch, OrderStatusDisputed, true},
+		{OrderStatusPartiallyRefunded, OrderStatusRefunded, true},
+		{OrderStatusDisputed, OrderStatusPaid, true},
+
+		// A charge seen again must not undo a refund or dispute
+		{OrderStatusRefunded, OrderStatusPaid, false},
+		{OrderStatusPartiallyRefunded, OrderStatusPaid, false},
+		{OrderStatusDisputed, OrderStatusAmountMismatch, false},
+		{OrderStatusRefunded, OrderStatusDisputed, false},
+		{OrderStatusPaid, OrderStatusAmountMismatch, false},
+		{OrderStatusPending, OrderStatusRefunded, false},
+	}
+
+	for _, tt := range tests {
+		if got := CanTransitionPayment(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransitionPayment(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestIsRefundable(t *testing.T) {
+	for _, status := range []string{OrderStatusPaid, OrderStatusAmountMismatch, OrderStatusPartiallyRefunded} {
+		if !IsRefundable(status) {
+			t.Errorf("IsRefundable(%q) = false", status)
+		}
+	}
+	for _, status := range []string{OrderStatusPending, OrderStatusFailed, OrderStatusRefunded, OrderStatusDisputed} {
+		if IsRefundable(status) {
+			t.Errorf("IsRefundable(%q) = true", status)
+		}
+	}
+}
+
+func TestRefundStatusFor(t *testing.T) {
+	tests := []struct {
+		amount, refunded int64
+		want             string
+	}{
+		{10000, 0, OrderStatusPaid},
+		{10000, 2500, OrderStatusPartiallyRefunded},
+		{10000, 10000, OrderStatusRefunded},
+		{10000, 12000, OrderStatusRefunded},
+	}
+
+	for _, tt := range tests {
+		if got := RefundStatusFor(tt.amount, tt.refunded); got != tt.want {
+			t.Errorf("RefundStatusFor(%d, %d) = %q, want %q", tt.amount, tt.refunded, got, tt.want)
+		}
+	}
+}
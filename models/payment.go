@@ -1,21 +1,169 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // PaystackWebhook represents the webhook payload from Paystack
 type PaystackWebhook struct {
-	Event string             `json:"event"`
+	Event string              `json:"event"`
 	Data  PaystackWebhookData `json:"data"`
 }
 
 // PaystackWebhookData represents the data inside the webhook
 type PaystackWebhookData struct {
-	Reference string                 `json:"reference"`
-	Amount    int                    `json:"amount"`
-	Status    string                 `json:"status"`
-	Customer  PaystackCustomer       `json:"customer"`
-	Metadata  map[string]interface{} `json:"metadata"`
+	Reference string           `json:"reference"`
+	Amount    int              `json:"amount"`
+	Currency  string           `json:"currency"`
+	Status    string           `json:"status"`
+	PaidAt    string           `json:"paid_at"`
+	Customer  PaystackCustomer `json:"customer"`
+	Plan      PaystackPlanRef  `json:"plan"` // Set on subscription charges
+	Metadata  PaystackMetadata `json:"metadata"`
+}
+
+// PaystackMetadata holds custom transaction metadata. Paystack returns it as
+// an object, as a JSON-encoded string, or as an empty string when unset.
+type PaystackMetadata map[string]interface{}
+
+// UnmarshalJSON decodes metadata in any of the shapes Paystack sends
+func (m *PaystackMetadata) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		data = []byte(text)
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		// Anything that is not an object carries no usable metadata
+		*m = nil
+		return nil
+	}
+
+	*m = values
+	return nil
 }
 
 // PaystackCustomer represents customer info from Paystack
 type PaystackCustomer struct {
-	Email string `json:"email"`
-}
\ No newline at end of file
+	Email        string `json:"email"`
+	CustomerCode string `json:"customer_code"`
+}
+
+// MetadataString returns a metadata value as a trimmed string
+func (d PaystackWebhookData) MetadataString(key string) string {
+	value, ok := d.Metadata[key]
+	if !ok || value == nil {
+		return ""
+	}
+
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return strings.TrimSpace(fmt.Sprint(v))
+	}
+}
+
+// MetadataInt returns a metadata value as an int, accepting numbers or numeric strings
+func (d PaystackWebhookData) MetadataInt(key string) int {
+	value, err := strconv.Atoi(d.MetadataString(key))
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// Webhook event ledger statuses
+const (
+	WebhookStatusProcessing = "processing"
+	WebhookStatusProcessed  = "processed"
+	WebhookStatusFailed     = "failed"
+)
+
+// WebhookEvent represents a Paystack webhook delivery recorded in the ledger
+type WebhookEvent struct {
+	ID          int             `json:"id"`
+	Event       string          `json:"event"`
+	Reference   string          `json:"reference"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	LastError   string          `json:"last_error"`
+	ProcessedAt *time.Time      `json:"processed_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// CheckoutRequest represents the payload to start a Paystack checkout
+type CheckoutRequest struct {
+	ProgramID int    `json:"program_id" binding:"required"`
+	PlanID    int    `json:"plan_id" binding:"required"`
+	Email     string `json:"email" binding:"required,email"`
+	Coupon    string `json:"coupon"` // Optional discount code
+}
+
+// CheckoutResponse represents a started checkout tied to a pending order
+type CheckoutResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+	AccessCode       string `json:"access_code"`
+	Reference        string `json:"reference"`
+	OrderID          int    `json:"order_id"`
+	Amount           int64  `json:"amount"`
+	Currency         string `json:"currency"`
+	Discount         int64  `json:"discount,omitempty"`
+	Coupon           string `json:"coupon,omitempty"`
+}
+
+// PaystackInitializeRequest represents the payload sent to Paystack's initialize API
+type PaystackInitializeRequest struct {
+	Email       string                 `json:"email"`
+	Amount      int64                  `json:"amount"` // In minor units (kobo)
+	Currency    string                 `json:"currency,omitempty"`
+	Reference   string                 `json:"reference"`
+	CallbackURL string                 `json:"callback_url,omitempty"`
+	Plan        string                 `json:"plan,omitempty"` // Paystack plan code for recurring plans
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// PaystackInitializeResponse represents the data returned by Paystack's initialize API
+type PaystackInitializeResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+	AccessCode       string `json:"access_code"`
+	Reference        string `json:"reference"`
+}
+
+// PaystackRefundData represents the data of refund.* webhooks and of the refund API
+type PaystackRefundData struct {
+	ID                   json.Number `json:"id"`
+	TransactionReference string      `json:"transaction_reference"`
+	Amount               int64       `json:"amount"`
+	Currency             string      `json:"currency"`
+	Status               string      `json:"status"`
+}
+
+// PaystackDisputeData represents the data of charge.dispute.* webhooks
+type PaystackDisputeData struct {
+	ID           json.Number `json:"id"`
+	Status       string      `json:"status"`
+	Resolution   string      `json:"resolution"`
+	Category     string      `json:"category"`
+	RefundAmount int64       `json:"refund_amount"`
+	Transaction  struct {
+		Reference string `json:"reference"`
+	} `json:"transaction"`
+}
+
+// PaystackRefundRequest represents the payload sent to Paystack's refund API
+type PaystackRefundRequest struct {
+	Transaction  string `json:"transaction"` // Transaction reference
+	Amount       int64  `json:"amount,omitempty"`
+	Currency     string `json:"currency,omitempty"`
+	MerchantNote string `json:"merchant_note,omitempty"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPaystackWebhookDataMetadata(t *testing.T) {
+	data := PaystackWebhookData{Metadata: map[string]interface{}{
+		"program":    "  Gut Reset ",
+		"program_id": float64(12),
+		"plan_id":    "7",
+		"plan":       nil,
+		"enrolled":   true,
+	}}
+
+	stringTests := []struct {
+		key, want string
+	}{
+		{"program", "Gut Reset"},
+		{"program_id", "12"},
+		{"plan_id", "7"},
+		{"plan", ""},
+		{"enrolled", "true"},
+		{"missing", ""},
+	}
+	for _, tt := range stringTests {
+		if got := data.MetadataString(tt.key); got != tt.want {
+			t.Errorf("MetadataString(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+
+	intTests := []struct {
+		key  string
+		want int
+	}{
+		{"program_id", 12},
+		{"plan_id", 7},
+		{"program", 0},
+		{"missing", 0},
+	}
+	for _, tt := range intTests {
+		if got := data.MetadataInt(tt.key); got != tt.want {
+			t.Errorf("MetadataInt(%q) = %d, want %d", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestPaystackMetadataUnmarshal(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string // MetadataString("program_id")
+	}{
+		{`{"metadata":{"program_id":12}}`, "12"},
+		{`{"metadata":"{\"program_id\":\"12\"}"}`, "12"},
+		{`{"metadata":""}`, ""},
+		{`{"metadata":null}`, ""},
+		{`{"metadata":[1,2]}`, ""},
+		{`{}`, ""},
+	}
+
+	for _, tt := range tests {
+		var data PaystackWebhookData
+		if err := json.Unmarshal([]byte(tt.input), &data); err != nil {
+			t.Errorf("Unmarshal(%s) error = %v", tt.input, err)
+			continue
+		}
+		if got := data.MetadataString("program_id"); got != tt.want {
+			t.Errorf("Unmarshal(%s): program_id = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
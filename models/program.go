@@ -34,7 +34,9 @@ type ProgramPricingPlan struct {
 	ProgramID int       `json:"program_id"`
 	Name      string    `json:"name"`
 	Subtitle  string    `json:"subtitle"`
-	Price     string    `json:"price"`
+	Price     Money     `json:"price"`
+	Interval  string    `json:"interval"`
+	PlanCode  string    `json:"paystack_plan_code,omitempty"`
 	Features  []string  `json:"features"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
@@ -54,9 +56,11 @@ type CreateProgramRequest struct {
 
 // PricingPlanRequest represents a pricing plan in the request
 type PricingPlanRequest struct {
+	ID       int      `json:"id,omitempty"` // Existing plan to update when the program is updated; zero adds a plan
 	Name     string   `json:"name"`
 	Subtitle string   `json:"subtitle"`
-	Price    string   `json:"price"`
+	Price    Money    `json:"price"`
+	Interval string   `json:"interval"` // one_off (default), monthly or quarterly
 	Features []string `json:"features"`
 }
 
@@ -64,4 +68,128 @@ type PricingPlanRequest struct {
 type ProgramResponse struct {
 	Program      Program              `json:"program"`
 	PricingPlans []ProgramPricingPlan `json:"pricing_plans"`
-}
\ No newline at end of file
+}
+
+// ProgramSummary holds the marketing fields of a program, which are public.
+// The full content, including the intro section, is only served to admins and
+// entitled customers.
+type ProgramSummary struct {
+	ID               int       `json:"id"`
+	Name             string    `json:"name"`
+	ShortDescription string    `json:"short_description"`
+	MainImageURL     string    `json:"main_image_url"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// Summary returns the marketing fields of a program
+func (p Program) Summary() ProgramSummary {
+	return ProgramSummary{
+		ID:               p.ID,
+		Name:             p.Name,
+		ShortDescription: p.ShortDescription,
+		MainImageURL:     p.MainImageURL,
+		CreatedAt:        p.CreatedAt,
+		UpdatedAt:        p.UpdatedAt,
+	}
+}
+
+// PublicProgramResponse represents a program's marketing fields with its pricing plans
+type PublicProgramResponse struct {
+	Program      ProgramSummary       `json:"program"`
+	PricingPlans []ProgramPricingPlan `json:"pricing_plans"`
+}
+
+// Program access sources
+const (
+	ProgramAccessPurchase     = "purchase"
+	ProgramAccessSubscription = "subscription"
+)
+
+// EnrolledProgram is a program a customer is entitled to, with its full content
+type EnrolledProgram struct {
+	Program            Program    `json:"program"`
+	PlanName           string     `json:"plan_name"`
+	Access             string     `json:"access"` // purchase or subscription
+	SubscriptionStatus string     `json:"subscription_status,omitempty"`
+	AccessUntil        *time.Time `json:"access_until,omitempty"` // Set for cancelled subscriptions still in their paid period
+	EnrolledAt         time.Time  `json:"enrolled_at"`
+}
+
+// Program sections customers work through, in order
+const (
+	ProgramSectionIntro       = "intro"
+	ProgramSectionWhatCauses  = "what_causes"
+	ProgramSectionHealthRisks = "health_risks"
+	ProgramSectionStrategies  = "strategies"
+	ProgramSectionConclusion  = "conclusion"
+)
+
+// ProgramSections lists the program sections in order
+var ProgramSections = []string{
+	ProgramSectionIntro,
+	ProgramSectionWhatCauses,
+	ProgramSectionHealthRisks,
+	ProgramSectionStrategies,
+	ProgramSectionConclusion,
+}
+
+// IsValidProgramSection reports whether section is a known program section
+func IsValidProgramSection(section string) bool {
+	for _, known := range ProgramSections {
+		if section == known {
+			return true
+		}
+	}
+	return false
+}
+
+// SectionCompletion records when a customer completed a program section
+type SectionCompletion struct {
+	Section     string    `json:"section"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// ProgramProgress is a customer's progress through a program
+type ProgramProgress struct {
+	ProgramID         int                 `json:"program_id"`
+	CompletedSections []SectionCompletion `json:"completed_sections"`
+	TotalSections     int                 `json:"total_sections"`
+	PercentComplete   int                 `json:"percent_complete"`
+}
+
+// CheckIn is a customer's daily check-in for a program
+type CheckIn struct {
+	ID          int       `json:"id"`
+	ProgramID   int       `json:"program_id"`
+	Date        string    `json:"date"` // YYYY-MM-DD
+	WeightKg    *float64  `json:"weight_kg"`
+	EnergyLevel *int      `json:"energy_level"` // 1 to 10
+	Symptoms    []string  `json:"symptoms"`
+	Notes       string    `json:"notes"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CheckInRequest represents the payload to record a daily check-in. Recording
+// a second check-in for the same day replaces the first.
+type CheckInRequest struct {
+	Date        string   `json:"date"` // YYYY-MM-DD, defaults to today
+	WeightKg    *float64 `json:"weight_kg" binding:"omitempty,gt=0,lt=500"`
+	EnergyLevel *int     `json:"energy_level" binding:"omitempty,min=1,max=10"`
+	Symptoms    []string `json:"symptoms" binding:"max=20,dive,max=100"`
+	Notes       string   `json:"notes" binding:"max=2000"`
+}
+
+// ProgramCompletionStats summarizes customer progress through a program
+type ProgramCompletionStats struct {
+	ProgramID          int            `json:"program_id"`
+	ProgramName        string         `json:"program_name"`
+	EnrolledCustomers  int            `json:"enrolled_customers"`  // Distinct paying emails
+	StartedCustomers   int            `json:"started_customers"`   // Completed at least one section
+	CompletedCustomers int            `json:"completed_customers"` // Completed every section
+	AverageCompletion  float64        `json:"average_completion"`  // Percent, over customers who started
+	SectionCompletions map[string]int `json:"section_completions"` // Customers per completed section
+	CheckIns           int            `json:"check_ins"`
+	ActiveLast7Days    int            `json:"active_last_7_days"` // Customers with progress or a check-in in the last week
+}
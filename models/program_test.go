@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPublicProgramResponseHidesContent(t *testing.T) {
+	program := Program{
+		ID:               3,
+		Name:             "Gut Reset",
+		ShortDescription: "Four weeks to a calmer gut",
+		WhatCauses:       "paid content",
+		HealthRisks:      "paid content",
+		Strategies:       "paid content",
+		Conclusion:       "paid content",
+	}
+
+	body, err := json.Marshal(PublicProgramResponse{Program: program.Summary()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var response struct {
+		Program map[string]interface{} `json:"program"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Program["name"] != "Gut Reset" || response.Program["short_description"] != "Four weeks to a calmer gut" {
+		t.Errorf("summary = %v, want the marketing fields", response.Program)
+	}
+	for _, field := range []string{"what_causes", "health_risks", "strategies", "conclusion", "main_content_image_url", "intro_description"} {
+		if _, ok := response.Program[field]; ok {
+			t.Errorf("public program includes %s", field)
+		}
+	}
+}
+
+func TestIsValidProgramSection(t *testing.T) {
+	for _, section := range ProgramSections {
+		if !IsValidProgramSection(section) {
+			t.Errorf("IsValidProgramSection(%q) = false", section)
+		}
+	}
+	for _, section := range []string{"", "Intro", "pricing"} {
+		if IsValidProgramSection(section) {
+			t.Errorf("IsValidProgramSection(%q) = true", section)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Billing intervals for pricing plans
+const (
+	BillingIntervalOneOff    = "one_off"
+	BillingIntervalMonthly   = "monthly"
+	BillingIntervalQuarterly = "quarterly"
+)
+
+// IsValidBillingInterval reports whether interval is a supported billing interval
+func IsValidBillingInterval(interval string) bool {
+	switch interval {
+	case BillingIntervalOneOff, BillingIntervalMonthly, BillingIntervalQuarterly:
+		return true
+	}
+	return false
+}
+
+// Subscription statuses
+const (
+	SubscriptionStatusActive    = "active"
+	SubscriptionStatusPastDue   = "past_due"
+	SubscriptionStatusCancelled = "cancelled"
+)
+
+// Subscription represents a customer's recurring Paystack subscription
+type Subscription struct {
+	ID               int        `json:"id"`
+	SubscriptionCode string     `json:"subscription_code"`
+	EmailToken       string     `json:"-"`
+	CustomerEmail    string     `json:"customer_email"`
+	CustomerCode     string     `json:"customer_code"`
+	PlanCode         string     `json:"plan_code"`
+	ProgramID        *int       `json:"program_id"`
+	PlanID           *int       `json:"plan_id"`
+	ProgramName      string     `json:"program_name"`
+	PlanName         string     `json:"plan_name"`
+	Status           string     `json:"status"`
+	NextPaymentDate  *time.Time `json:"next_payment_date"`
+	CancelledAt      *time.Time `json:"cancelled_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// PaystackSubscriptionData represents the data of subscription.* webhooks
+type PaystackSubscriptionData struct {
+	SubscriptionCode string           `json:"subscription_code"`
+	EmailToken       string           `json:"email_token"`
+	Status           string           `json:"status"`
+	NextPaymentDate  string           `json:"next_payment_date"`
+	Plan             PaystackPlanRef  `json:"plan"`
+	Customer         PaystackCustomer `json:"customer"`
+}
+
+// PaystackInvoiceData represents the data of invoice.* webhooks
+type PaystackInvoiceData struct {
+	Paid         bool                     `json:"paid"`
+	Status       string                   `json:"status"`
+	Subscription PaystackSubscriptionData `json:"subscription"`
+	Customer     PaystackCustomer         `json:"customer"`
+}
+
+// PaystackPlanRef identifies a Paystack plan inside webhook data
+type PaystackPlanRef struct {
+	PlanCode string `json:"plan_code"`
+	Name     string `json:"name"`
+}
+
+// UnmarshalJSON accepts a plan object, a bare plan code, or null/empty values
+func (p *PaystackPlanRef) UnmarshalJSON(data []byte) error {
+	var code string
+	if err := json.Unmarshal(data, &code); err == nil {
+		*p = PaystackPlanRef{PlanCode: code}
+		return nil
+	}
+
+	type plain PaystackPlanRef
+	var value plain
+	if err := json.Unmarshal(data, &value); err != nil {
+		*p = PaystackPlanRef{}
+		return nil
+	}
+	*p = PaystackPlanRef(value)
+	return nil
+}
+
+// PaystackPlanRequest represents the payload sent to Paystack's plan API
+type PaystackPlanRequest struct {
+	Name     string `json:"name"`
+	Amount   int64  `json:"amount"` // In minor units
+	Interval string `json:"interval"`
+	Currency string `json:"currency,omitempty"`
+}
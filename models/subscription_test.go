@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestIsValidBillingInterval(t *testing.T) {
+	for _, interval := range []string{BillingIntervalOneOff, BillingIntervalMonthly, BillingIntervalQuarterly} {
+		if !IsValidBillingInterval(interval) {
+			t.Errorf("IsValidBillingInterval(%q) = false", interval)
+		}
+	}
+	for _, interval := range []string{"", "weekly", "Monthly"} {
+		if IsValidBillingInterval(interval) {
+			t.Errorf("IsValidBillingInterval(%q) = true", interval)
+		}
+	}
+}
+
+func TestPaystackPlanRefUnmarshal(t *testing.T) {
+	tests := []struct {
+		input string
+		want  PaystackPlanRef
+	}{
+		{`{"plan":{"plan_code":"PLN_abc","name":"Monthly"}}`, PaystackPlanRef{PlanCode: "PLN_abc", Name: "Monthly"}},
+		{`{"plan":"PLN_abc"}`, PaystackPlanRef{PlanCode: "PLN_abc"}},
+		{`{"plan":{}}`, PaystackPlanRef{}},
+		{`{"plan":null}`, PaystackPlanRef{}},
+		{`{"plan":42}`, PaystackPlanRef{}},
+	}
+
+	for _, tt := range tests {
+		var data PaystackWebhookData
+		if err := json.Unmarshal([]byte(tt.input), &data); err != nil {
+			t.Errorf("Unmarshal(%s) error = %v", tt.input, err)
+			continue
+		}
+		if data.Plan != tt.want {
+			t.Errorf("Unmarshal(%s) plan = %+v, want %+v", tt.input, data.Plan, tt.want)
+		}
+	}
+}
@@ -2,30 +2,76 @@ package routes
 
 import (
 	"database/sql"
+	"plantbased-backend/config"
 	"plantbased-backend/handlers"
 	"plantbased-backend/middleware"
 	"plantbased-backend/services"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 // SetupRoutes configures all application routes
-func SetupRoutes(router *gin.Engine, db *sql.DB) {
+func SetupRoutes(router *gin.Engine, db *sql.DB) error {
 	// Initialize services
 	authService := services.NewAuthService(db)
 	adminService := services.NewAdminService(db)
-	programService := services.NewProgramService(db)
-	testimonialService := services.NewTestimonialService(db)
-	emailService := services.NewEmailService()
-	paymentService := services.NewPaymentService()
+	paystackClient := services.NewPaystackClient(config.AppConfig)
+	programService := services.NewProgramService(db, paystackClient)
+	mailer, err := services.NewMailer(config.AppConfig)
+	if err != nil {
+		return err
+	}
+	emailService := services.NewEmailService(
+		db, mailer, config.AppConfig.EmailMaxAttempts,
+		time.Duration(config.AppConfig.EmailWorkerIntervalSeconds)*time.Second,
+	)
+	testimonialService := services.NewTestimonialService(db, emailService)
+	notificationRuleService := services.NewNotificationRuleService(db, programService)
+	newsletterService := services.NewNewsletterService(db, emailService, programService)
+	customerService := services.NewCustomerService(db, emailService)
+	orderService := services.NewOrderService(db, programService)
+	couponService := services.NewCouponService(db, programService)
+	refundService := services.NewRefundService(db, paystackClient, orderService, emailService)
+	subscriptionService := services.NewSubscriptionService(db, programService)
+	invoiceService := services.NewInvoiceService(db, emailService, programService)
+	webhookService := services.NewWebhookService(
+		db, orderService, refundService, subscriptionService, invoiceService, customerService,
+	)
+	dataSubjectService := services.NewDataSubjectService(db, customerService)
+	customerAuthService := services.NewCustomerAuthService(db, emailService)
+	portalService := services.NewPortalService(db, customerAuthService, programService)
+	progressService := services.NewProgressService(db, portalService)
+	paymentService := services.NewPaymentService(paystackClient, programService, orderService, couponService)
+	reconciliationService := services.NewReconciliationService(
+		db, paystackClient, webhookService,
+		time.Duration(config.AppConfig.ReconcileAfterMinutes)*time.Minute,
+		time.Duration(config.AppConfig.ReconcileIntervalMinutes)*time.Minute,
+	)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
 	adminHandler := handlers.NewAdminHandler(adminService)
 	programHandler := handlers.NewProgramHandler(programService)
 	testimonialHandler := handlers.NewTestimonialHandler(testimonialService)
-	customerHandler := handlers.NewCustomerHandler(emailService)
-	paymentHandler := handlers.NewPaymentHandler(paymentService)
+	customerHandler := handlers.NewCustomerHandler(customerService)
+	paymentHandler := handlers.NewPaymentHandler(paymentService, webhookService)
+	orderHandler := handlers.NewOrderHandler(orderService, refundService, customerService)
+	reconciliationHandler := handlers.NewReconciliationHandler(reconciliationService)
+	subscriptionHandler := handlers.NewSubscriptionHandler(subscriptionService)
+	couponHandler := handlers.NewCouponHandler(couponService)
+	invoiceHandler := handlers.NewInvoiceHandler(invoiceService)
+	dataSubjectHandler := handlers.NewDataSubjectHandler(dataSubjectService)
+	customerAuthHandler := handlers.NewCustomerAuthHandler(customerAuthService)
+	portalHandler := handlers.NewPortalHandler(portalService)
+	progressHandler := handlers.NewProgressHandler(progressService)
+	emailHandler := handlers.NewEmailHandler(emailService)
+	notificationRuleHandler := handlers.NewNotificationRuleHandler(notificationRuleService)
+	newsletterHandler := handlers.NewNewsletterHandler(newsletterService)
+
+	// Start background jobs
+	reconciliationService.Start()
+	emailService.Start()
 
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
@@ -42,6 +88,32 @@ func SetupRoutes(router *gin.Engine, db *sql.DB) {
 			auth.POST("/refresh", authHandler.RefreshToken)
 		}
 
+		// Customer auth routes (public)
+		customerAuth := api.Group("/customer/auth")
+		{
+			customerAuth.POST("/magic-link", customerAuthHandler.RequestMagicLink)
+			customerAuth.POST("/verify", customerAuthHandler.VerifyMagicLink)
+			customerAuth.POST("/refresh", customerAuthHandler.RefreshToken)
+		}
+
+		// Customer portal (protected by customer tokens)
+		me := api.Group("/me")
+		me.Use(middleware.CustomerAuthMiddleware(customerAuthService))
+		{
+			me.GET("", portalHandler.GetAccount)
+			me.GET("/programs", portalHandler.GetPrograms)
+			me.GET("/programs/:id", portalHandler.GetProgramByID)
+			me.GET("/programs/:id/progress", progressHandler.GetProgress)
+			me.PUT("/programs/:id/progress/:section", progressHandler.CompleteSection)
+			me.DELETE("/programs/:id/progress/:section", progressHandler.UncompleteSection)
+			me.GET("/programs/:id/check-ins", progressHandler.GetCheckIns)
+			me.POST("/programs/:id/check-ins", progressHandler.RecordCheckIn)
+			me.GET("/orders", portalHandler.GetOrders)
+			me.GET("/receipts", portalHandler.GetReceipts)
+			me.GET("/receipts/:id/download", portalHandler.DownloadReceipt)
+			me.GET("/subscriptions", portalHandler.GetSubscriptions)
+		}
+
 		// Admin routes (protected)
 		admin := api.Group("/admin")
 		admin.Use(middleware.AuthMiddleware())
@@ -49,6 +121,80 @@ func SetupRoutes(router *gin.Engine, db *sql.DB) {
 			admin.GET("/profile", adminHandler.GetProfile)
 			admin.PUT("/profile", adminHandler.UpdateProfile)
 			admin.PUT("/change-password", adminHandler.ChangePassword)
+
+			// Programs with full content
+			admin.GET("/programs", programHandler.GetProgramsWithContent)
+			admin.GET("/programs/:id", programHandler.GetProgramWithContent)
+			admin.GET("/programs/stats", progressHandler.GetCompletionStats)
+			admin.GET("/programs/:id/stats", progressHandler.GetProgramCompletionStats)
+
+			// Leads
+			admin.GET("/leads", customerHandler.GetLeads)
+			admin.GET("/leads/:id", customerHandler.GetLeadByID)
+			admin.PUT("/leads/:id/status", customerHandler.UpdateLeadStatus)
+			admin.POST("/leads/:id/notes", customerHandler.AddLeadNote)
+			admin.PUT("/leads/:id/assign", customerHandler.AssignLead)
+			admin.GET("/customers/export", customerHandler.ExportCustomers)
+
+			// Data subject requests
+			admin.POST("/data-subjects/find", dataSubjectHandler.FindRecords)
+			admin.POST("/data-subjects/export", dataSubjectHandler.ExportRecords)
+			admin.POST("/data-subjects/anonymize", dataSubjectHandler.AnonymizeRecords)
+			admin.GET("/data-subjects/audit", dataSubjectHandler.GetAuditLog)
+
+			// Orders
+			admin.GET("/orders", orderHandler.GetOrders)
+			admin.GET("/orders/unmatched", orderHandler.GetUnmatchedOrders)
+			admin.POST("/orders/:id/link-lead", orderHandler.LinkLead)
+			admin.GET("/orders/:id", orderHandler.GetOrderByID)
+			admin.POST("/orders/:id/refund", orderHandler.RefundOrder)
+			admin.GET("/orders/:id/refunds", orderHandler.GetOrderRefunds)
+			admin.GET("/orders/:id/disputes", orderHandler.GetOrderDisputes)
+
+			// Subscriptions
+			admin.GET("/subscriptions", subscriptionHandler.GetSubscriptions)
+			admin.GET("/subscriptions/:id", subscriptionHandler.GetSubscriptionByID)
+
+			// Coupons
+			admin.GET("/coupons", couponHandler.GetCoupons)
+			admin.POST("/coupons", couponHandler.CreateCoupon)
+			admin.GET("/coupons/:id", couponHandler.GetCouponByID)
+			admin.PUT("/coupons/:id", couponHandler.UpdateCoupon)
+			admin.DELETE("/coupons/:id", couponHandler.DeleteCoupon)
+			admin.GET("/coupons/:id/redemptions", couponHandler.GetCouponRedemptions)
+
+			// Invoices
+			admin.GET("/invoices", invoiceHandler.GetInvoices)
+			admin.GET("/invoices/:id/download", invoiceHandler.DownloadInvoice)
+
+			// Paystack webhook ledger
+			admin.GET("/webhook-events", paymentHandler.GetWebhookEvents)
+			admin.POST("/webhook-events/:id/retry", paymentHandler.RetryWebhookEvent)
+
+			// Payment reconciliation
+			admin.POST("/reconciliation/run", reconciliationHandler.RunReconciliation)
+			admin.GET("/reconciliation/last", reconciliationHandler.GetLastRun)
+
+			// Email outbox
+			admin.GET("/emails", emailHandler.GetOutbox)
+			admin.GET("/emails/:id", emailHandler.GetOutboxEmail)
+			admin.POST("/emails/:id/resend", emailHandler.ResendEmail)
+			admin.GET("/email-templates", emailHandler.GetTemplates)
+			admin.GET("/email-templates/:key", emailHandler.GetTemplate)
+			admin.PUT("/email-templates/:key", emailHandler.UpdateTemplate)
+			admin.DELETE("/email-templates/:key", emailHandler.ResetTemplate)
+			admin.PUT("/email-templates/:key/enabled", emailHandler.SetTemplateEnabled)
+			admin.POST("/email-templates/:key/preview", emailHandler.PreviewTemplate)
+			admin.GET("/notification-rules", notificationRuleHandler.GetRules)
+			admin.POST("/notification-rules", notificationRuleHandler.CreateRule)
+			admin.GET("/notification-rules/:id", notificationRuleHandler.GetRule)
+			admin.PUT("/notification-rules/:id", notificationRuleHandler.UpdateRule)
+			admin.DELETE("/notification-rules/:id", notificationRuleHandler.DeleteRule)
+
+			// Newsletter
+			admin.GET("/newsletter/subscribers", newsletterHandler.GetSubscribers)
+			admin.GET("/newsletter/subscribers/export", newsletterHandler.ExportSubscribers)
+			admin.POST("/newsletter/send", newsletterHandler.SendNewsletter)
 		}
 
 		// Program routes
@@ -85,7 +231,21 @@ func SetupRoutes(router *gin.Engine, db *sql.DB) {
 		// Customer routes (public)
 		api.POST("/send-customer-details", customerHandler.SendCustomerDetails)
 
+		// Newsletter (public)
+		newsletter := api.Group("/newsletter")
+		{
+			newsletter.POST("/subscribe", newsletterHandler.Subscribe)
+			newsletter.POST("/confirm", newsletterHandler.Confirm)
+			newsletter.POST("/unsubscribe", newsletterHandler.Unsubscribe)
+		}
+
+		// Checkout (public)
+		api.POST("/checkout", paymentHandler.Checkout)
+		api.POST("/coupons/validate", couponHandler.ValidateCoupon)
+
 		// Payment webhook (public)
 		api.POST("/paystack-webhook", paymentHandler.HandleWebhook)
 	}
+
+	return nil
 }
\ No newline at end of file
@@ -0,0 +1,428 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"plantbased-backend/models"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCoupon is returned when a coupon is malformed or cannot be applied
+var ErrInvalidCoupon = errors.New("invalid coupon")
+
+var couponCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,50}$`)
+
+// redemptionCounts is the condition for a redemption to count toward a
+// coupon's limits. Orders that were paid count; a pending order holds its use
+// for an hour, long enough to finish paying, so concurrent checkouts cannot
+// redeem a coupon past its limits. Checkouts that failed, were abandoned or
+// were never completed give their use back.
+const redemptionCounts = `(
+	COALESCE(o.status, '') NOT IN ('pending', 'failed', 'abandoned')
+	OR (o.status = 'pending' AND o.created_at > NOW() - INTERVAL '1 hour'))`
+
+const countedRedemptions = `
+	SELECT COUNT(*) FROM coupon_redemptions r
+	LEFT JOIN orders o ON o.id = r.order_id
+	WHERE r.coupon_id = coupons.id AND ` + redemptionCounts
+
+const couponColumns = `id, code, description, discount_type, percent_off, amount_off, currency, program_ids,
+	max_redemptions, max_per_customer, expires_at, active, (` + countedRedemptions + `), created_at, updated_at`
+
+const redemptionColumns = `id, coupon_id, coupon_code, order_id, reference, customer_email,
+	original_amount, discount_amount, currency, created_at`
+
+// queryRower is implemented by both *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// CouponService manages discount codes and prices plans with them
+type CouponService struct {
+	DB             *sql.DB
+	programService *ProgramService
+}
+
+func NewCouponService(db *sql.DB, programService *ProgramService) *CouponService {
+	return &CouponService{
+		DB:             db,
+		programService: programService,
+	}
+}
+
+func scanCoupon(row rowScanner) (*models.Coupon, error) {
+	var c models.Coupon
+	var amountOff int64
+	var currency string
+	var programIDsJSON []byte
+	err := row.Scan(
+		&c.ID, &c.Code, &c.Description, &c.DiscountType, &c.PercentOff, &amountOff, &currency, &programIDsJSON,
+		&c.MaxRedemptions, &c.MaxPerCustomer, &c.ExpiresAt, &c.Active, &c.TimesRedeemed, &c.CreatedAt, &c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.DiscountType == models.DiscountTypeFixed {
+		c.AmountOff = &models.Money{Amount: amountOff, Currency: currency}
+	}
+	c.ProgramIDs = []int{}
+	json.Unmarshal(programIDsJSON, &c.ProgramIDs)
+	return &c, nil
+}
+
+func scanRedemption(row rowScanner) (*models.CouponRedemption, error) {
+	var r models.CouponRedemption
+	err := row.Scan(
+		&r.ID, &r.CouponID, &r.CouponCode, &r.OrderID, &r.Reference, &r.CustomerEmail,
+		&r.OriginalAmount, &r.DiscountAmount, &r.Currency, &r.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// validateCoupon checks a coupon request and normalizes its code
+func (s *CouponService) validateCoupon(req *models.CouponRequest) error {
+	req.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+	if !couponCodePattern.MatchString(req.Code) {
+		return fmt.Errorf("%w: code must be 3-50 letters, digits, dashes or underscores", ErrInvalidCoupon)
+	}
+
+	switch req.DiscountType {
+	case models.DiscountTypePercentage:
+		if req.PercentOff < 1 || req.PercentOff > 99 {
+			return fmt.Errorf("%w: percent_off must be between 1 and 99", ErrInvalidCoupon)
+		}
+		req.AmountOff = nil
+	case models.DiscountTypeFixed:
+		if req.AmountOff == nil {
+			return fmt.Errorf("%w: amount_off is required for fixed discounts", ErrInvalidCoupon)
+		}
+		if err := req.AmountOff.Validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidCoupon, err)
+		}
+		req.PercentOff = 0
+	default:
+		return fmt.Errorf("%w: discount_type must be percentage or fixed", ErrInvalidCoupon)
+	}
+
+	if req.MaxRedemptions != nil && *req.MaxRedemptions < 1 {
+		return fmt.Errorf("%w: max_redemptions must be at least 1", ErrInvalidCoupon)
+	}
+	if req.MaxPerCustomer != nil && *req.MaxPerCustomer < 1 {
+		return fmt.Errorf("%w: max_per_customer must be at least 1", ErrInvalidCoupon)
+	}
+
+	if req.ProgramIDs == nil {
+		req.ProgramIDs = []int{}
+	}
+	for _, programID := range req.ProgramIDs {
+		if _, err := s.programService.GetProgramByID(programID); err != nil {
+			return fmt.Errorf("%w: program %d does not exist", ErrInvalidCoupon, programID)
+		}
+	}
+
+	return nil
+}
+
+// couponArgs returns the column values stored for a coupon request
+func couponArgs(req models.CouponRequest) (amountOff int64, currency string, programIDs string, active bool) {
+	currency = models.DefaultCurrency
+	if req.AmountOff != nil {
+		amountOff, currency = req.AmountOff.Amount, req.AmountOff.Currency
+	}
+	programIDsJSON, _ := json.Marshal(req.ProgramIDs)
+	active = req.Active == nil || *req.Active
+	return amountOff, currency, string(programIDsJSON), active
+}
+
+// CreateCoupon creates a new coupon
+func (s *CouponService) CreateCoupon(req models.CouponRequest) (*models.Coupon, error) {
+	if err := s.validateCoupon(&req); err != nil {
+		return nil, err
+	}
+	if err := s.checkCodeAvailable(req.Code, 0); err != nil {
+		return nil, err
+	}
+
+	amountOff, currency, programIDs, active := couponArgs(req)
+	return scanCoupon(s.DB.QueryRow(`
+		INSERT INTO coupons (
+			code, description, discount_type, percent_off, amount_off, currency, program_ids,
+			max_redemptions, max_per_customer, expires_at, active
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING `+couponColumns,
+		req.Code, req.Description, req.DiscountType, req.PercentOff, amountOff, currency, programIDs,
+		req.MaxRedemptions, req.MaxPerCustomer, req.ExpiresAt, active,
+	))
+}
+
+// UpdateCoupon replaces the settings of a coupon
+func (s *CouponService) UpdateCoupon(id int, req models.CouponRequest) (*models.Coupon, error) {
+	if err := s.validateCoupon(&req); err != nil {
+		return nil, err
+	}
+	if err := s.checkCodeAvailable(req.Code, id); err != nil {
+		return nil, err
+	}
+
+	amountOff, currency, programIDs, active := couponArgs(req)
+	coupon, err := scanCoupon(s.DB.QueryRow(`
+		UPDATE coupons
+		SET code = $1, description = $2, discount_type = $3, percent_off = $4, amount_off = $5, currency = $6,
+			program_ids = $7, max_redemptions = $8, max_per_customer = $9, expires_at = $10, active = $11,
+			updated_at = NOW()
+		WHERE id = $12
+		RETURNING `+couponColumns,
+		req.Code, req.Description, req.DiscountType, req.PercentOff, amountOff, currency, programIDs,
+		req.MaxRedemptions, req.MaxPerCustomer, req.ExpiresAt, active, id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, errors.New("coupon not found")
+	}
+	return coupon, err
+}
+
+// checkCodeAvailable makes sure no other coupon uses the code
+func (s *CouponService) checkCodeAvailable(code string, id int) error {
+	var exists bool
+	err := s.DB.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM coupons WHERE code = $1 AND id <> $2)
+	`, code, id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%w: code %s is already in use", ErrInvalidCoupon, code)
+	}
+	return nil
+}
+
+// DeleteCoupon deletes a coupon. Its redemptions are kept for the order history.
+func (s *CouponService) DeleteCoupon(id int) error {
+	result, err := s.DB.Exec("DELETE FROM coupons WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return errors.New("coupon not found")
+	}
+
+	return nil
+}
+
+// GetCoupons retrieves all coupons, newest first
+func (s *CouponService) GetCoupons() ([]models.Coupon, error) {
+	rows, err := s.DB.Query("SELECT " + couponColumns + " FROM coupons ORDER BY created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	coupons := []models.Coupon{}
+	for rows.Next() {
+		coupon, err := scanCoupon(rows)
+		if err != nil {
+			return nil, err
+		}
+		coupons = append(coupons, *coupon)
+	}
+
+	return coupons, rows.Err()
+}
+
+// GetCouponByID retrieves a single coupon
+func (s *CouponService) GetCouponByID(id int) (*models.Coupon, error) {
+	coupon, err := scanCoupon(s.DB.QueryRow("SELECT "+couponColumns+" FROM coupons WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, errors.New("coupon not found")
+	}
+	return coupon, err
+}
+
+// GetRedemptions retrieves the redemptions of a coupon, newest first
+func (s *CouponService) GetRedemptions(couponID int) ([]models.CouponRedemption, error) {
+	rows, err := s.DB.Query(`
+		SELECT `+redemptionColumns+` FROM coupon_redemptions WHERE coupon_id = $1 ORDER BY created_at DESC
+	`, couponID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	redemptions := []models.CouponRedemption{}
+	for rows.Next() {
+		redemption, err := scanRedemption(rows)
+		if err != nil {
+			return nil, err
+		}
+		redemptions = append(redemptions, *redemption)
+	}
+
+	return redemptions, rows.Err()
+}
+
+// Quote prices a pricing plan with a coupon. The email is optional; when
+// given, the per-customer limit is checked too.
+func (s *CouponService) Quote(code string, programID, planID int, email string) (*models.CouponQuote, error) {
+	plan, err := s.programService.GetPricingPlanByID(programID, planID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCoupon, err)
+	}
+
+	coupon, err := s.findCoupon(s.DB, code, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.apply(s.DB, coupon, plan, email)
+}
+
+// Redeem records a coupon against the pending order created for it. Limits
+// are checked again with the coupon locked, so concurrent checkouts cannot
+// redeem it past its limits, and the discount is recomputed for the order's
+// plan. The coupon is rejected if the order was not priced with that
+// discount, for instance because the coupon was edited since it was quoted.
+func (s *CouponService) Redeem(code string, order *models.Order) error {
+	if order.ProgramID == nil || order.PlanID == nil {
+		return fmt.Errorf("%w: order %d has no pricing plan", ErrInvalidCoupon, order.ID)
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	coupon, err := s.findCoupon(tx, code, true)
+	if err != nil {
+		return err
+	}
+
+	plan, err := s.programService.GetPricingPlanByID(*order.ProgramID, *order.PlanID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCoupon, err)
+	}
+
+	quote, err := s.apply(tx, coupon, plan, order.CustomerEmail)
+	if err != nil {
+		return err
+	}
+	if quote.DiscountedPrice.Amount != order.Amount || quote.DiscountedPrice.Currency != order.Currency {
+		return fmt.Errorf("%w: coupon %s no longer gives the quoted price", ErrInvalidCoupon, coupon.Code)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO coupon_redemptions (
+			coupon_id, coupon_code, order_id, reference, customer_email, original_amount, discount_amount, currency
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, coupon.ID, coupon.Code, order.ID, order.Reference, strings.ToLower(order.CustomerEmail),
+		quote.OriginalPrice.Amount, quote.Discount.Amount, quote.OriginalPrice.Currency)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// findCoupon looks up a coupon by code, optionally locking it for the transaction
+func (s *CouponService) findCoupon(q queryRower, code string, lock bool) (*models.Coupon, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	// Lock first, then read in a separate statement so the redemption count
+	// includes anything committed while we waited for the lock
+	if lock {
+		var id int
+		err := q.QueryRow("SELECT id FROM coupons WHERE code = $1 FOR UPDATE", code).Scan(&id)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("%w: coupon not found", ErrInvalidCoupon)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	coupon, err := scanCoupon(q.QueryRow("SELECT "+couponColumns+" FROM coupons WHERE code = $1", code))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: coupon not found", ErrInvalidCoupon)
+	}
+	return coupon, err
+}
+
+// apply checks that a coupon can be used on a plan and computes the discount
+func (s *CouponService) apply(q queryRower, coupon *models.Coupon, plan *models.ProgramPricingPlan, email string) (*models.CouponQuote, error) {
+	if !coupon.Active {
+		return nil, fmt.Errorf("%w: coupon is not active", ErrInvalidCoupon)
+	}
+	if coupon.ExpiresAt != nil && time.Now().After(*coupon.ExpiresAt) {
+		return nil, fmt.Errorf("%w: coupon has expired", ErrInvalidCoupon)
+	}
+	if coupon.MaxRedemptions != nil && coupon.TimesRedeemed >= *coupon.MaxRedemptions {
+		return nil, fmt.Errorf("%w: coupon has reached its usage limit", ErrInvalidCoupon)
+	}
+
+	if len(coupon.ProgramIDs) > 0 {
+		allowed := false
+		for _, programID := range coupon.ProgramIDs {
+			if programID == plan.ProgramID {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, fmt.Errorf("%w: coupon does not apply to this program", ErrInvalidCoupon)
+		}
+	}
+
+	if plan.Interval != "" && plan.Interval != models.BillingIntervalOneOff {
+		return nil, fmt.Errorf("%w: coupons cannot be applied to recurring plans", ErrInvalidCoupon)
+	}
+
+	if coupon.MaxPerCustomer != nil && email != "" {
+		var used int
+		err := q.QueryRow(`
+			SELECT COUNT(*) FROM coupon_redemptions r
+			LEFT JOIN orders o ON o.id = r.order_id
+			WHERE r.coupon_id = $1 AND r.customer_email = $2 AND `+redemptionCounts+`
+		`, coupon.ID, strings.ToLower(email)).Scan(&used)
+		if err != nil {
+			return nil, err
+		}
+		if used >= *coupon.MaxPerCustomer {
+			return nil, fmt.Errorf("%w: coupon has already been used by this customer", ErrInvalidCoupon)
+		}
+	}
+
+	price := plan.Price
+	var discount int64
+	switch coupon.DiscountType {
+	case models.DiscountTypePercentage:
+		discount = price.Amount * int64(coupon.PercentOff) / 100
+	case models.DiscountTypeFixed:
+		if coupon.AmountOff.Currency != price.Currency {
+			return nil, fmt.Errorf("%w: coupon is only valid for %s prices", ErrInvalidCoupon, coupon.AmountOff.Currency)
+		}
+		discount = coupon.AmountOff.Amount
+	}
+
+	if discount >= price.Amount {
+		return nil, fmt.Errorf("%w: coupon cannot cover the full price of this plan", ErrInvalidCoupon)
+	}
+
+	return &models.CouponQuote{
+		Code:            coupon.Code,
+		ProgramID:       plan.ProgramID,
+		PlanID:          plan.ID,
+		OriginalPrice:   price,
+		Discount:        models.Money{Amount: discount, Currency: price.Currency},
+		DiscountedPrice: models.Money{Amount: price.Amount - discount, Currency: price.Currency},
+	}, nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"database/sql/driver"
+	"errors"
+	"plantbased-backend/models"
+	"testing"
+	"time"
+)
+
+func TestValidateCoupon(t *testing.T) {
+	s := &CouponService{}
+
+	req := models.CouponRequest{Code: " welcome-10 ", DiscountType: models.DiscountTypePercentage, PercentOff: 10,
+		AmountOff: &models.Money{Amount: 100, Currency: "NGN"}}
+	if err := s.validateCoupon(&req); err != nil {
+		t.Fatalf("validateCoupon error = %v", err)
+	}
+	if req.Code != "WELCOME-10" || req.AmountOff != nil || req.ProgramIDs == nil {
+		t.Errorf("normalized request = %+v", req)
+	}
+
+	one, zero := 1, 0
+	invalid := []models.CouponRequest{
+		{Code: "AB", DiscountType: models.DiscountTypePercentage, PercentOff: 10},
+		{Code: "NO SPACES", DiscountType: models.DiscountTypePercentage, PercentOff: 10},
+		{Code: "HALF", DiscountType: models.DiscountTypePercentage, PercentOff: 0},
+		{Code: "FREE", DiscountType: models.DiscountTypePercentage, PercentOff: 100},
+		{Code: "FIXED", DiscountType: models.DiscountTypeFixed},
+		{Code: "FIXED", DiscountType: models.DiscountTypeFixed, AmountOff: &models.Money{Amount: 500, Currency: "XYZ"}},
+		{Code: "BOGO", DiscountType: "bogo"},
+		{Code: "LIMIT", DiscountType: models.DiscountTypePercentage, PercentOff: 10, MaxRedemptions: &zero},
+		{Code: "LIMIT", DiscountType: models.DiscountTypePercentage, PercentOff: 10, MaxRedemptions: &one, MaxPerCustomer: &zero},
+	}
+	for _, req := range invalid {
+		if err := s.validateCoupon(&req); !errors.Is(err, ErrInvalidCoupon) {
+			t.Errorf("validateCoupon(%+v) error = %v, want ErrInvalidCoupon", req, err)
+		}
+	}
+}
+
+func TestCouponApply(t *testing.T) {
+	s := &CouponService{}
+	plan := &models.ProgramPricingPlan{ID: 3, ProgramID: 2, Price: models.Money{Amount: 2500000, Currency: "NGN"}}
+	past := time.Now().Add(-time.Hour)
+	limit := 5
+
+	quote, err := s.apply(nil, &models.Coupon{Code: "TEN", DiscountType: models.DiscountTypePercentage, PercentOff: 10, Active: true}, plan, "")
+	if err != nil {
+		t.Fatalf("percentage coupon error = %v", err)
+	}
+	if quote.Discount.Amount != 250000 || quote.DiscountedPrice.Amount != 2250000 || quote.PlanID != 3 {
+		t.Errorf("percentage quote = %+v", quote)
+	}
+
+	quote, err = s.apply(nil, &models.Coupon{Code: "OFF5K", DiscountType: models.DiscountTypeFixed,
+		AmountOff: &models.Money{Amount: 500000, Currency: "NGN"}, Active: true}, plan, "")
+	if err != nil {
+		t.Fatalf("fixed coupon error = %v", err)
+	}
+	if quote.DiscountedPrice != (models.Money{Amount: 2000000, Currency: "NGN"}) {
+		t.Errorf("fixed quote = %+v", quote)
+	}
+
+	rejected := map[string]*models.Coupon{
+		"inactive":       {DiscountType: models.DiscountTypePercentage, PercentOff: 10},
+		"expired":        {DiscountType: models.DiscountTypePercentage, PercentOff: 10, Active: true, ExpiresAt: &past},
+		"used up":        {DiscountType: models.DiscountTypePercentage, PercentOff: 10, Active: true, MaxRedemptions: &limit, TimesRedeemed: 5},
+		"other program":  {DiscountType: models.DiscountTypePercentage, PercentOff: 10, Active: true, ProgramIDs: []int{7}},
+		"other currency": {DiscountType: models.DiscountTypeFixed, AmountOff: &models.Money{Amount: 500, Currency: "USD"}, Active: true},
+		"full price":     {DiscountType: models.DiscountTypeFixed, AmountOff: &models.Money{Amount: 2500000, Currency: "NGN"}, Active: true},
+	}
+	for name, coupon := range rejected {
+		if _, err := s.apply(nil, coupon, plan, ""); !errors.Is(err, ErrInvalidCoupon) {
+			t.Errorf("%s: apply error = %v, want ErrInvalidCoupon", name, err)
+		}
+	}
+
+	recurring := *plan
+	recurring.Interval = models.BillingIntervalMonthly
+	if _, err := s.apply(nil, &models.Coupon{DiscountType: models.DiscountTypePercentage, PercentOff: 10, Active: true}, &recurring, ""); !errors.Is(err, ErrInvalidCoupon) {
+		t.Errorf("recurring plan: apply error = %v, want ErrInvalidCoupon", err)
+	}
+}
+
+func TestCouponApplyPerCustomerLimit(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.on("FROM coupon_redemptions", []string{"count"}, []driver.Value{int64(1)})
+
+	limit := 1
+	coupon := &models.Coupon{ID: 4, DiscountType: models.DiscountTypePercentage, PercentOff: 10, Active: true, MaxPerCustomer: &limit}
+	plan := &models.ProgramPricingPlan{ProgramID: 2, Price: models.Money{Amount: 2500000, Currency: "NGN"}}
+
+	s := &CouponService{DB: db}
+	if _, err := s.apply(db, coupon, plan, "Ada@Example.com"); !errors.Is(err, ErrInvalidCoupon) {
+		t.Errorf("apply for a customer at the limit error = %v, want ErrInvalidCoupon", err)
+	}
+	if counts := fake.ran("FROM coupon_redemptions"); len(counts) != 1 || counts[0].args[1] != "ada@example.com" {
+		t.Errorf("per-customer count queries = %+v", counts)
+	}
+
+	// Without an email the limit cannot be checked yet
+	if _, err := s.apply(db, coupon, plan, ""); err != nil {
+		t.Errorf("apply without an email error = %v", err)
+	}
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/url"
+	"plantbased-backend/config"
+	"plantbased-backend/models"
+	"plantbased-backend/utils"
+	"time"
+)
+
+// ErrInvalidMagicLink is returned for unknown, used or expired login links
+var ErrInvalidMagicLink = errors.New("invalid or expired login link")
+
+// maxMagicLinksPerWindow limits how many login links an account can be sent
+// within one link lifetime
+const maxMagicLinksPerWindow = 5
+
+const customerAccountColumns = `id, email, full_name, is_active, last_login_at, created_at, updated_at`
+
+// CustomerAuthService manages customer accounts and their passwordless
+// magic-link login. Customer tokens are separate from admin tokens.
+type CustomerAuthService struct {
+	DB           *sql.DB
+	emailService *EmailService
+}
+
+func NewCustomerAuthService(db *sql.DB, emailService *EmailService) *CustomerAuthService {
+	return &CustomerAuthService{
+		DB:           db,
+		emailService: emailService,
+	}
+}
+
+func scanCustomerAccount(row rowScanner) (*models.CustomerAccount, error) {
+	var a models.CustomerAccount
+	err := row.Scan(&a.ID, &a.Email, &a.FullName, &a.IsActive, &a.LastLoginAt, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// hashLoginToken returns the hex SHA-256 of a login token. Only hashes are
+// stored, so a database leak does not expose usable links.
+func hashLoginToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// RequestMagicLink emails a login link to the account for an email. Accounts
+// are opened on first request for anyone with a paid order. Unknown emails are
+// ignored without an error so the endpoint cannot be used to probe for customers.
+func (s *CustomerAuthService) RequestMagicLink(email string) error {
+	account, err := s.findOrOpenAccount(normalizeEmail(email))
+	if err != nil || account == nil || !account.IsActive {
+		return err
+	}
+
+	ttl := time.Duration(config.AppConfig.MagicLinkTTLMinutes) * time.Minute
+
+	var recent int
+	err = s.DB.QueryRow(`
+		SELECT COUNT(*) FROM customer_login_tokens WHERE customer_id = $1 AND created_at > $2
+	`, account.ID, time.Now().Add(-ttl)).Scan(&recent)
+	if err != nil {
+		return err
+	}
+	if recent >= maxMagicLinksPerWindow {
+		log.Printf("Magic link limit reached for customer %d", account.ID)
+		return nil
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	if _, err := s.DB.Exec(`
+		INSERT INTO customer_login_tokens (customer_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+	`, account.ID, hashLoginToken(token), time.Now().Add(ttl)); err != nil {
+		return err
+	}
+
+	link := config.AppConfig.CustomerLoginURL + "?token=" + url.QueryEscape(token)
+
+	// Queued rather than sent, so response times do not reveal whether the account exists
+	return s.emailService.SendMagicLink(s.DB, *account, link, ttl)
+}
+
+// findOrOpenAccount returns the account for an email, opening one if the email
+// has an order that grants access. It returns nil when the email is not a paying customer.
+func (s *CustomerAuthService) findOrOpenAccount(email string) (*models.CustomerAccount, error) {
+	account, err := scanCustomerAccount(s.DB.QueryRow("SELECT "+customerAccountColumns+" FROM customer_accounts WHERE email = $1", email))
+	if err != sql.ErrNoRows {
+		return account, err
+	}
+
+	var paid bool
+	err = s.DB.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM orders
+			WHERE LOWER(customer_email) = $1 AND status IN (`+models.OrderStatusesGrantingAccess+`)
+		)
+	`, email).Scan(&paid)
+	if err != nil || !paid {
+		return nil, err
+	}
+
+	// Take the name from the customer's latest registration, if any
+	var fullName string
+	err = s.DB.QueryRow(`
+		SELECT full_name FROM leads WHERE email = $1 ORDER BY created_at DESC LIMIT 1
+	`, email).Scan(&fullName)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return scanCustomerAccount(s.DB.QueryRow(`
+		INSERT INTO customer_accounts (email, full_name) VALUES ($1, $2)
+		ON CONFLICT (email) DO UPDATE SET updated_at = customer_accounts.updated_at
+		RETURNING `+customerAccountColumns,
+		email, fullName,
+	))
+}
+
+// VerifyMagicLink exchanges a login link token for customer tokens. Each link
+// works once.
+func (s *CustomerAuthService) VerifyMagicLink(token string) (*models.CustomerLoginResponse, error) {
+	var customerID int
+	err := s.DB.QueryRow(`
+		UPDATE customer_login_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING customer_id
+	`, hashLoginToken(token)).Scan(&customerID)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidMagicLink
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := scanCustomerAccount(s.DB.QueryRow(`
+		UPDATE customer_accounts SET last_login_at = NOW() WHERE id = $1
+		RETURNING `+customerAccountColumns,
+		customerID,
+	))
+	if err != nil {
+		return nil, err
+	}
+	if !account.IsActive {
+		return nil, ErrInvalidMagicLink
+	}
+
+	return s.issueTokens(account)
+}
+
+func (s *CustomerAuthService) issueTokens(account *models.CustomerAccount) (*models.CustomerLoginResponse, error) {
+	token, err := utils.GenerateCustomerToken(account.ID, account.Email, utils.CustomerAccessToken, 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := utils.GenerateCustomerToken(account.ID, account.Email, utils.CustomerRefreshToken, 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CustomerLoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		Customer:     *account,
+	}, nil
+}
+
+// RefreshToken generates a new customer access token from a refresh token
+func (s *CustomerAuthService) RefreshToken(refreshToken string) (string, error) {
+	claims, err := utils.ValidateCustomerToken(refreshToken, utils.CustomerRefreshToken)
+	if err != nil {
+		return "", errors.New("invalid or expired refresh token")
+	}
+
+	account, err := s.GetAccountByID(claims.CustomerID)
+	if err != nil {
+		return "", err
+	}
+	if !account.IsActive {
+		return "", errors.New("account is inactive")
+	}
+
+	return utils.GenerateCustomerToken(account.ID, account.Email, utils.CustomerAccessToken, 24*time.Hour)
+}
+
+// IsAccountActive reports whether a customer account exists and is active.
+// It is checked on every authenticated request so that deactivating an
+// account takes effect before its access token expires.
+func (s *CustomerAuthService) IsAccountActive(id int) (bool, error) {
+	var active bool
+	err := s.DB.QueryRow("SELECT is_active FROM customer_accounts WHERE id = $1", id).Scan(&active)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return active, err
+}
+
+// GetAccountByID retrieves a customer account
+func (s *CustomerAuthService) GetAccountByID(id int) (*models.CustomerAccount, error) {
+	account, err := scanCustomerAccount(s.DB.QueryRow("SELECT "+customerAccountColumns+" FROM customer_accounts WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, errors.New("customer account not found")
+	}
+	return account, err
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+func TestRequestMagicLinkIgnoresUnknownEmails(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.on("FROM customer_accounts", nil)
+	fake.on("FROM orders", []string{"exists"}, []driver.Value{false})
+
+	s := &CustomerAuthService{DB: db}
+	if err := s.RequestMagicLink(" Nobody@Example.com "); err != nil {
+		t.Fatalf("RequestMagicLink error = %v, want nil", err)
+	}
+
+	lookups := fake.ran("FROM customer_accounts")
+	if len(lookups) != 1 || lookups[0].args[0] != "nobody@example.com" {
+		t.Errorf("account lookups = %v", lookups)
+	}
+	if opened := fake.ran("INSERT INTO customer_accounts"); len(opened) != 0 {
+		t.Errorf("%d accounts opened, want none", len(opened))
+	}
+	if tokens := fake.ran("INSERT INTO customer_login_tokens"); len(tokens) != 0 {
+		t.Errorf("%d login tokens issued, want none", len(tokens))
+	}
+}
+
+func TestVerifyMagicLinkRejectsUnknownTokens(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.on("UPDATE customer_login_tokens", nil)
+
+	s := &CustomerAuthService{DB: db}
+	if _, err := s.VerifyMagicLink("not-a-token"); !errors.Is(err, ErrInvalidMagicLink) {
+		t.Errorf("VerifyMagicLink error = %v, want ErrInvalidMagicLink", err)
+	}
+
+	// Only the hash of a token is ever sent to the database
+	updates := fake.ran("UPDATE customer_login_tokens")
+	if len(updates) != 1 || updates[0].args[0] != hashLoginToken("not-a-token") {
+		t.Errorf("token updates = %v", updates)
+	}
+}
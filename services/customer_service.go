@@ -0,0 +1,667 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/mail"
+	"plantbased-backend/models"
+	"plantbased-backend/utils"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Lead errors
+var (
+	ErrInvalidLead   = errors.New("invalid lead update")
+	ErrLeadNotFound  = errors.New("lead not found")
+	ErrInvalidExport = errors.New("invalid export")
+)
+
+const leadColumns = `id, full_name, email, nationality, country_code, phone_number, program, program_id,
+	package, plan_id, status, assigned_to, notified_at, created_at, updated_at`
+
+// registrationAckInterval is the least time between registration
+// acknowledgements to one address. The acknowledgement repeats the name the
+// visitor typed, so without a limit the form could be used to send our mail
+// with text of anyone's choosing to any inbox, as often as they like.
+const registrationAckInterval = time.Hour
+
+// ValidationError carries field-level errors keyed by JSON field name
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	messages := make([]string, len(names))
+	for i, name := range names {
+		messages[i] = name + ": " + e.Fields[name]
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// CustomerService stores customer registrations (leads) and tracks their
+// follow-up through the lead pipeline
+type CustomerService struct {
+	DB           *sql.DB
+	emailService *EmailService
+}
+
+func NewCustomerService(db *sql.DB, emailService *EmailService) *CustomerService {
+	return &CustomerService{
+		DB:           db,
+		emailService: emailService,
+	}
+}
+
+func scanLead(row rowScanner) (*models.Lead, error) {
+	var l models.Lead
+	err := row.Scan(
+		&l.ID, &l.FullName, &l.Email, &l.Nationality, &l.CountryCode, &l.PhoneNumber, &l.Program, &l.ProgramID,
+		&l.Package, &l.PlanID, &l.Status, &l.AssignedTo, &l.NotifiedAt, &l.CreatedAt, &l.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// CreateLead validates and normalizes a customer registration and stores it
+// along with the lead notification and customer acknowledgement emails. The
+// acknowledgement is skipped when one went to the same address within
+// registrationAckInterval. Invalid details are reported as a *ValidationError.
+func (s *CustomerService) CreateLead(details models.CustomerDetails) (*models.Lead, error) {
+	lead, err := s.normalizeDetails(details)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	lead, err = scanLead(tx.QueryRow(`
+		INSERT INTO leads (full_name, email, nationality, country_code, phone_number, program, program_id, package, plan_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING `+leadColumns,
+		lead.FullName, lead.Email, lead.Nationality, lead.CountryCode, lead.PhoneNumber,
+		lead.Program, lead.ProgramID, lead.Package, lead.PlanID,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	// Queue the lead notification and the customer's acknowledgement with the
+	// lead so they are sent even if the mail server is down right now
+	if err := s.emailService.SendLeadNotification(tx, *lead); err != nil {
+		return nil, err
+	}
+	var acknowledged bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM email_outbox
+			WHERE kind = $1 AND LOWER(customer_email) = $2 AND created_at > $3
+		)
+	`, models.EmailKindRegistrationAck, lead.Email, time.Now().Add(-registrationAckInterval)).Scan(&acknowledged)
+	if err != nil {
+		return nil, err
+	}
+	if !acknowledged {
+		if err := s.emailService.SendRegistrationAcknowledgement(tx, *lead); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return lead, nil
+}
+
+// normalizeDetails checks registration details and returns them in canonical
+// form: the contact details as normalizeContact returns them, and the program
+// and package names as stored in the database
+func (s *CustomerService) normalizeDetails(details models.CustomerDetails) (*models.Lead, error) {
+	lead, fields := normalizeContact(details)
+
+	var programID int
+	err := s.DB.QueryRow(`
+		SELECT id, name FROM programs WHERE LOWER(name) = LOWER($1) ORDER BY id LIMIT 1
+	`, strings.TrimSpace(details.Program)).Scan(&programID, &lead.Program)
+	switch {
+	case err == sql.ErrNoRows:
+		fields["program"] = "unknown program"
+	case err != nil:
+		return nil, err
+	default:
+		lead.ProgramID = &programID
+
+		var planID int
+		err = s.DB.QueryRow(`
+			SELECT id, name FROM program_pricing_plans
+			WHERE program_id = $1 AND LOWER(name) = LOWER($2)
+			ORDER BY id LIMIT 1
+		`, programID, strings.TrimSpace(details.Package)).Scan(&planID, &lead.Package)
+		if err == sql.ErrNoRows {
+			fields["package"] = "unknown package for " + lead.Program
+		} else if err != nil {
+			return nil, err
+		} else {
+			lead.PlanID = &planID
+		}
+	}
+
+	if len(fields) > 0 {
+		return nil, &ValidationError{Fields: fields}
+	}
+	return &lead, nil
+}
+
+// normalizeContact checks the personal details of a registration and returns
+// them in canonical form: a lower-case email, the ISO country for the
+// nationality and an E.164 phone number. Phone numbers without a country code
+// are read as local to the country of residence, falling back to the
+// nationality when none is given. Problems are returned keyed by JSON field.
+func normalizeContact(details models.CustomerDetails) (models.Lead, map[string]string) {
+	fields := map[string]string{}
+	lead := models.Lead{
+		FullName: strings.Join(strings.Fields(details.FullName), " "),
+		Email:    strings.ToLower(strings.TrimSpace(details.Email)),
+	}
+
+	if lead.FullName == "" {
+		fields["fullName"] = "full name is required"
+	}
+
+	if !isValidEmail(lead.Email) {
+		fields["email"] = "must be a valid email address"
+	}
+
+	country, ok := utils.LookupCountry(details.Nationality)
+	if ok {
+		lead.Nationality = country.Name
+		lead.CountryCode = country.Alpha2
+	} else {
+		fields["nationality"] = "must be a country name, nationality or ISO 3166 country code"
+	}
+
+	var phoneCountry *utils.Country
+	if ok {
+		phoneCountry = &country
+	}
+	if strings.TrimSpace(details.Residence) != "" {
+		residence, found := utils.LookupCountry(details.Residence)
+		if !found {
+			fields["countryOfResidence"] = "must be a country name or ISO 3166 country code"
+			return lead, fields
+		}
+		phoneCountry = &residence
+	}
+
+	phone, err := utils.NormalizePhone(details.PhoneNumber, phoneCountry)
+	if err != nil {
+		fields["phoneNumber"] = err.Error()
+	}
+	lead.PhoneNumber = phone
+
+	return lead, fields
+}
+
+// isValidEmail accepts a bare address (no display name) with a dotted domain
+func isValidEmail(email string) bool {
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return false
+	}
+	domain := email[strings.LastIndex(email, "@")+1:]
+	return strings.Contains(domain, ".") && !strings.HasSuffix(domain, ".")
+}
+
+// GetLeads retrieves leads matching the filter, newest first
+func (s *CustomerService) GetLeads(filter models.LeadFilter) ([]models.Lead, error) {
+	var conditions []string
+	var args []interface{}
+
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Search != "" {
+		addCondition("(full_name ILIKE $%[1]d OR email ILIKE $%[1]d OR phone_number ILIKE $%[1]d OR program ILIKE $%[1]d)", "%"+filter.Search+"%")
+	}
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+	if filter.AssignedTo != 0 {
+		addCondition("assigned_to = $%d", filter.AssignedTo)
+	}
+
+	query := "SELECT " + leadColumns + " FROM leads"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at DESC"
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	leads := []models.Lead{}
+	for rows.Next() {
+		lead, err := scanLead(rows)
+		if err != nil {
+			return nil, err
+		}
+		leads = append(leads, *lead)
+	}
+
+	return leads, rows.Err()
+}
+
+// GetLeadByID retrieves a single lead
+func (s *CustomerService) GetLeadByID(id int) (*models.Lead, error) {
+	lead, err := scanLead(s.DB.QueryRow("SELECT "+leadColumns+" FROM leads WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrLeadNotFound
+	}
+	return lead, err
+}
+
+// GetLeadDetail retrieves a lead with its notes and status history
+func (s *CustomerService) GetLeadDetail(id int) (*models.LeadDetail, error) {
+	lead, err := s.GetLeadByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := models.LeadDetail{
+		Lead:    *lead,
+		Notes:   []models.LeadNote{},
+		History: []models.LeadStatusChange{},
+	}
+
+	noteRows, err := s.DB.Query(`
+		SELECT n.id, n.lead_id, n.admin_id, COALESCE(a.full_name, ''), n.body, n.created_at
+		FROM lead_notes n
+		LEFT JOIN admins a ON a.id = n.admin_id
+		WHERE n.lead_id = $1
+		ORDER BY n.created_at ASC
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer noteRows.Close()
+
+	for noteRows.Next() {
+		var note models.LeadNote
+		if err := noteRows.Scan(&note.ID, &note.LeadID, &note.AdminID, &note.AdminName, &note.Body, &note.CreatedAt); err != nil {
+			return nil, err
+		}
+		detail.Notes = append(detail.Notes, note)
+	}
+	if err := noteRows.Err(); err != nil {
+		return nil, err
+	}
+
+	historyRows, err := s.DB.Query(`
+		SELECT h.id, h.lead_id, h.from_status, h.to_status, h.admin_id, COALESCE(a.full_name, ''), h.created_at
+		FROM lead_status_history h
+		LEFT JOIN admins a ON a.id = h.admin_id
+		WHERE h.lead_id = $1
+		ORDER BY h.created_at ASC
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer historyRows.Close()
+
+	for historyRows.Next() {
+		var change models.LeadStatusChange
+		err := historyRows.Scan(
+			&change.ID, &change.LeadID, &change.FromStatus, &change.ToStatus,
+			&change.AdminID, &change.AdminName, &change.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		detail.History = append(detail.History, change)
+	}
+
+	return &detail, historyRows.Err()
+}
+
+// ChangeStatus moves a lead to a new status, recording the change and an
+// optional note. An admin ID of zero records an automatic change.
+func (s *CustomerService) ChangeStatus(id int, req models.LeadStatusRequest, adminID int) (*models.Lead, error) {
+	if !models.IsValidLeadStatus(req.Status) {
+		return nil, fmt.Errorf("%w: status must be one of new, contacted, enrolled or lost", ErrInvalidLead)
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := setLeadStatus(tx, id, req.Status, adminID); err != nil {
+		return nil, err
+	}
+
+	if note := strings.TrimSpace(req.Note); note != "" {
+		if err := insertLeadNote(tx, id, note, adminID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetLeadByID(id)
+}
+
+// setLeadStatus updates a lead's status and appends to its history. Setting
+// the current status again is a no-op.
+func setLeadStatus(tx *sql.Tx, id int, status string, adminID int) error {
+	var current string
+	err := tx.QueryRow("SELECT status FROM leads WHERE id = $1 FOR UPDATE", id).Scan(&current)
+	if err == sql.ErrNoRows {
+		return ErrLeadNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if current == status {
+		return nil
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE leads SET status = $1, updated_at = NOW() WHERE id = $2
+	`, status, id); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO lead_status_history (lead_id, from_status, to_status, admin_id)
+		VALUES ($1, $2, $3, $4)
+	`, id, current, status, nullableInt(adminID))
+	return err
+}
+
+func insertLeadNote(tx *sql.Tx, id int, body string, adminID int) error {
+	_, err := tx.Exec(`
+		INSERT INTO lead_notes (lead_id, admin_id, body) VALUES ($1, $2, $3)
+	`, id, nullableInt(adminID), body)
+	return err
+}
+
+// AddNote adds a timestamped admin note to a lead
+func (s *CustomerService) AddNote(id int, req models.LeadNoteRequest, adminID int) (*models.LeadNote, error) {
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return nil, fmt.Errorf("%w: note body is required", ErrInvalidLead)
+	}
+
+	if _, err := s.GetLeadByID(id); err != nil {
+		return nil, err
+	}
+
+	note := models.LeadNote{LeadID: id, Body: body}
+	err := s.DB.QueryRow(`
+		INSERT INTO lead_notes (lead_id, admin_id, body) VALUES ($1, $2, $3)
+		RETURNING id, admin_id, created_at
+	`, id, nullableInt(adminID), body).Scan(&note.ID, &note.AdminID, &note.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if adminID != 0 {
+		s.DB.QueryRow("SELECT full_name FROM admins WHERE id = $1", adminID).Scan(&note.AdminName)
+	}
+	return &note, nil
+}
+
+// AssignLead assigns a lead to an active admin, or unassigns it when adminID is nil
+func (s *CustomerService) AssignLead(id int, adminID *int) (*models.Lead, error) {
+	if adminID != nil {
+		var active bool
+		err := s.DB.QueryRow("SELECT is_active FROM admins WHERE id = $1", *adminID).Scan(&active)
+		if err == sql.ErrNoRows || (err == nil && !active) {
+			return nil, fmt.Errorf("%w: admin %d does not exist or is inactive", ErrInvalidLead, *adminID)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	lead, err := scanLead(s.DB.QueryRow(`
+		UPDATE leads SET assigned_to = $1, updated_at = NOW() WHERE id = $2
+		RETURNING `+leadColumns,
+		adminID, id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrLeadNotFound
+	}
+	return lead, err
+}
+
+// MatchOrder links a paid order to the registration submitted with the same
+// email and marks that lead enrolled. Orders without a matching lead are left
+// unlinked and show up in the unmatched payments queue.
+func (s *CustomerService) MatchOrder(order *models.Order) (*models.Lead, error) {
+	if order.Status != models.OrderStatusPaid || order.LeadID != nil || order.CustomerEmail == "" {
+		return nil, nil
+	}
+
+	// Prefer the most recent registration that has not enrolled yet
+	var leadID int
+	err := s.DB.QueryRow(`
+		SELECT id FROM leads
+		WHERE email = $1
+		ORDER BY (status = $2), created_at DESC
+		LIMIT 1
+	`, strings.ToLower(order.CustomerEmail), models.LeadStatusEnrolled).Scan(&leadID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.linkOrder(order.ID, leadID, 0); err != nil {
+		return nil, err
+	}
+	return s.GetLeadByID(leadID)
+}
+
+// LinkOrder links an unmatched order to a lead by hand (admin only)
+func (s *CustomerService) LinkOrder(orderID, leadID, adminID int) (*models.Lead, error) {
+	if _, err := s.GetLeadByID(leadID); err != nil {
+		return nil, err
+	}
+
+	if err := s.linkOrder(orderID, leadID, adminID); err != nil {
+		return nil, err
+	}
+	return s.GetLeadByID(leadID)
+}
+
+// linkOrder sets the order's lead and moves the lead to enrolled
+func (s *CustomerService) linkOrder(orderID, leadID, adminID int) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow("SELECT status FROM orders WHERE id = $1 FOR UPDATE", orderID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return errors.New("order not found")
+	}
+	if err != nil {
+		return err
+	}
+	if status != models.OrderStatusPaid {
+		return fmt.Errorf("%w: only paid orders can be linked to a lead", ErrInvalidLead)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE orders SET lead_id = $1, updated_at = NOW() WHERE id = $2
+	`, leadID, orderID); err != nil {
+		return err
+	}
+
+	if err := setLeadStatus(tx, leadID, models.LeadStatusEnrolled, adminID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// customerExportColumn is a column available in customer exports
+type customerExportColumn struct {
+	key    string
+	header string
+	expr   string // SQL expression returning text
+}
+
+// customerExportColumns lists the export columns in their default order
+var customerExportColumns = []customerExportColumn{
+	{"lead_id", "Lead ID", "l.id::text"},
+	{"registered_at", "Registered At", "TO_CHAR(l.created_at, 'YYYY-MM-DD HH24:MI:SS')"},
+	{"full_name", "Full Name", "l.full_name"},
+	{"email", "Email", "COALESCE(l.email, o.customer_email)"},
+	{"phone_number", "Phone Number", "l.phone_number"},
+	{"nationality", "Nationality", "l.nationality"},
+	{"country_code", "Country Code", "l.country_code"},
+	{"program", "Program", "COALESCE(NULLIF(o.program_name, ''), l.program)"},
+	{"package", "Package", "COALESCE(NULLIF(o.plan_name, ''), l.package)"},
+	{"status", "Lead Status", "l.status"},
+	{"order_id", "Order ID", "o.id::text"},
+	{"reference", "Payment Reference", "o.reference"},
+	{"payment_status", "Payment Status", "o.status"},
+	{"amount", "Amount", "TO_CHAR(o.amount / 100.0, 'FM999999999999990.00')"},
+	{"currency", "Currency", "o.currency"},
+	{"paid_at", "Paid At", "TO_CHAR(o.paid_at, 'YYYY-MM-DD HH24:MI:SS')"},
+}
+
+// CustomerExportColumnKeys returns the keys of the available export columns
+func CustomerExportColumnKeys() []string {
+	keys := make([]string, len(customerExportColumns))
+	for i, column := range customerExportColumns {
+		keys[i] = column.key
+	}
+	return keys
+}
+
+// ExportCustomers streams registrations joined with their payments, one row
+// at a time, to write. The first row is the header. Columns are selected by
+// key; an empty selection exports every column.
+func (s *CustomerService) ExportCustomers(filter models.CustomerExportFilter, columnKeys []string, write func(row []string) error) error {
+	columns := customerExportColumns
+	if len(columnKeys) > 0 {
+		columns = make([]customerExportColumn, 0, len(columnKeys))
+		for _, key := range columnKeys {
+			found := false
+			for _, column := range customerExportColumns {
+				if column.key == strings.TrimSpace(key) {
+					columns = append(columns, column)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("%w: unknown column %q", ErrInvalidExport, key)
+			}
+		}
+	}
+
+	var conditions []string
+	var args []interface{}
+
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.From != nil {
+		addCondition("COALESCE(l.created_at, o.created_at) >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("COALESCE(l.created_at, o.created_at) < $%d", *filter.To)
+	}
+	if filter.ProgramID != 0 {
+		addCondition("(l.program_id = $%[1]d OR o.program_id = $%[1]d)", filter.ProgramID)
+	}
+	if filter.Status != "" {
+		addCondition("l.status = $%d", filter.Status)
+	}
+	if filter.PaymentStatus != "" {
+		addCondition("o.status = $%d", filter.PaymentStatus)
+	}
+
+	header := make([]string, len(columns))
+	selects := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.header
+		selects[i] = "COALESCE(" + column.expr + ", '')"
+	}
+
+	query := "SELECT " + strings.Join(selects, ", ") + `
+		FROM leads l
+		FULL OUTER JOIN orders o ON o.lead_id = l.id`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY COALESCE(l.created_at, o.created_at), l.id, o.id"
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if err := write(header); err != nil {
+		return err
+	}
+
+	values := make([]string, len(columns))
+	targets := make([]interface{}, len(columns))
+	for i := range values {
+		targets[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(targets...); err != nil {
+			return err
+		}
+		if err := write(values); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"database/sql/driver"
+	"errors"
+	"plantbased-backend/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCreateLeadNormalizesDetails(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.on("FROM programs", []string{"id", "name"}, []driver.Value{int64(2), "Gut Reset"})
+	fake.on("FROM program_pricing_plans", []string{"id", "name"}, []driver.Value{int64(5), "Standard"})
+	errStop := errors.New("stop after the insert")
+	fake.fail("INSERT INTO leads", errStop)
+
+	s := &CustomerService{DB: db}
+	_, err := s.CreateLead(models.CustomerDetails{
+		FullName:    "  Adaeze   Okafor ",
+		Email:       " Adaeze@Example.COM ",
+		Nationality: "Nigerian ",
+		PhoneNumber: "0803 123 4567",
+		Program:     "gut reset",
+		Package:     " standard",
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("CreateLead error = %v", err)
+	}
+
+	inserts := fake.ran("INSERT INTO leads")
+	if len(inserts) != 1 {
+		t.Fatalf("%d inserts, want 1", len(inserts))
+	}
+	want := []driver.Value{"Adaeze Okafor", "adaeze@example.com", "Nigeria", "NG", "+2348031234567", "Gut Reset", int64(2), "Standard", int64(5)}
+	for i, value := range want {
+		if inserts[0].args[i] != value {
+			t.Errorf("insert args = %v, want %v", inserts[0].args, want)
+			break
+		}
+	}
+}
+
+func TestCreateLeadReportsInvalidFields(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.on("FROM programs", []string{"id", "name"})
+
+	s := &CustomerService{DB: db}
+	_, err := s.CreateLead(models.CustomerDetails{
+		FullName:    " ",
+		Email:       "Adaeze <adaeze@example.com>",
+		Nationality: "Atlantean",
+		PhoneNumber: "0803 123 4567",
+		Program:     "Moon Diet",
+		Package:     "Standard",
+	})
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("CreateLead error = %v, want a *ValidationError", err)
+	}
+	for _, field := range []string{"fullName", "email", "nationality", "phoneNumber", "program"} {
+		if validationErr.Fields[field] == "" {
+			t.Errorf("no error for %s in %v", field, validationErr.Fields)
+		}
+	}
+	if inserts := fake.ran("INSERT INTO leads"); len(inserts) != 0 {
+		t.Errorf("%d inserts, want none", len(inserts))
+	}
+}
+
+func TestNormalizeContact(t *testing.T) {
+	lead, fields := normalizeContact(models.CustomerDetails{
+		FullName:    "  Adaeze   Okafor ",
+		Email:       " Adaeze@Example.COM ",
+		Nationality: "nigerian",
+		PhoneNumber: "0803 123 4567",
+	})
+	if len(fields) > 0 {
+		t.Fatalf("unexpected field errors: %v", fields)
+	}
+	if lead.FullName != "Adaeze Okafor" || lead.Email != "adaeze@example.com" {
+		t.Errorf("name and email = %q, %q", lead.FullName, lead.Email)
+	}
+	if lead.Nationality != "Nigeria" || lead.CountryCode != "NG" {
+		t.Errorf("nationality = %q (%s), want Nigeria (NG)", lead.Nationality, lead.CountryCode)
+	}
+	if lead.PhoneNumber != "+2348031234567" {
+		t.Errorf("phone = %q, want +2348031234567", lead.PhoneNumber)
+	}
+
+	// A Nigerian living in the UK gives a UK number in national format
+	lead, fields = normalizeContact(models.CustomerDetails{
+		FullName:    "Adaeze Okafor",
+		Email:       "adaeze@example.com",
+		Nationality: "Nigeria",
+		Residence:   "United Kingdom",
+		PhoneNumber: "07911 123456",
+	})
+	if len(fields) > 0 {
+		t.Fatalf("unexpected field errors: %v", fields)
+	}
+	if lead.CountryCode != "NG" || lead.PhoneNumber != "+447911123456" {
+		t.Errorf("country and phone = %s, %q; want NG, +447911123456", lead.CountryCode, lead.PhoneNumber)
+	}
+
+	// International numbers do not depend on either country
+	lead, _ = normalizeContact(models.CustomerDetails{
+		FullName: "Adaeze Okafor", Email: "adaeze@example.com", Nationality: "NG", Residence: "GB",
+		PhoneNumber: "+234 803 123 4567",
+	})
+	if lead.PhoneNumber != "+2348031234567" {
+		t.Errorf("phone = %q, want +2348031234567", lead.PhoneNumber)
+	}
+
+	_, fields = normalizeContact(models.CustomerDetails{
+		FullName:    " ",
+		Email:       "Adaeze <adaeze@example.com>",
+		Nationality: "Atlantean",
+		Residence:   "Atlantis",
+		PhoneNumber: "0803 123 4567",
+	})
+	for _, field := range []string{"fullName", "email", "nationality", "countryOfResidence"} {
+		if fields[field] == "" {
+			t.Errorf("no error for %s in %v", field, fields)
+		}
+	}
+	if _, ok := fields["phoneNumber"]; ok {
+		t.Errorf("phone reported as invalid when only the residence is: %v", fields)
+	}
+
+	// Without any country a national number cannot be read
+	_, fields = normalizeContact(models.CustomerDetails{
+		FullName: "Adaeze Okafor", Email: "adaeze@example.com", Nationality: "Atlantean", PhoneNumber: "0803 123 4567",
+	})
+	if fields["phoneNumber"] == "" {
+		t.Errorf("no phone error without a country: %v", fields)
+	}
+}
+
+func TestGetLeadsFilter(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.on("FROM leads", nil)
+
+	s := &CustomerService{DB: db}
+	leads, err := s.GetLeads(models.LeadFilter{Search: "ada", Limit: 20, Offset: 40})
+	if err != nil {
+		t.Fatalf("GetLeads error = %v", err)
+	}
+	if leads == nil || len(leads) != 0 {
+		t.Errorf("GetLeads = %v, want an empty list", leads)
+	}
+
+	queries := fake.ran("FROM leads")
+	if len(queries) != 1 {
+		t.Fatalf("%d queries, want 1", len(queries))
+	}
+	query := queries[0].query
+	if !strings.Contains(query, "ILIKE $1") || !strings.Contains(query, "LIMIT $2") || !strings.Contains(query, "OFFSET $3") {
+		t.Errorf("query = %q", query)
+	}
+	if args := queries[0].args; len(args) != 3 || args[0] != "%ada%" || args[1] != int64(20) || args[2] != int64(40) {
+		t.Errorf("args = %v", args)
+	}
+}
+
+func TestChangeStatusRecordsHistory(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.on("SELECT status FROM leads", []string{"status"}, []driver.Value{models.LeadStatusNew})
+	errStop := errors.New("stop after the commit")
+	fake.fail("FROM leads WHERE id", errStop)
+
+	s := &CustomerService{DB: db}
+	_, err := s.ChangeStatus(7, models.LeadStatusRequest{Status: models.LeadStatusContacted, Note: " Called back "}, 3)
+	if !errors.Is(err, errStop) {
+		t.Fatalf("ChangeStatus error = %v", err)
+	}
+
+	history := fake.ran("INSERT INTO lead_status_history")
+	if len(history) != 1 {
+		t.Fatalf("%d history entries, want 1", len(history))
+	}
+	if args := history[0].args; args[0] != int64(7) || args[1] != models.LeadStatusNew || args[2] != models.LeadStatusContacted || args[3] != int64(3) {
+		t.Errorf("history args = %v", args)
+	}
+
+	notes := fake.ran("INSERT INTO lead_notes")
+	if len(notes) != 1 || notes[0].args[2] != "Called back" {
+		t.Errorf("notes = %v, want one trimmed note", notes)
+	}
+}
+
+func TestChangeStatusToCurrentStatus(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.on("SELECT status FROM leads", []string{"status"}, []driver.Value{models.LeadStatusLost})
+	errStop := errors.New("stop after the commit")
+	fake.fail("FROM leads WHERE id", errStop)
+
+	s := &CustomerService{DB: db}
+	if _, err := s.ChangeStatus(7, models.LeadStatusRequest{Status: models.LeadStatusLost}, 3); !errors.Is(err, errStop) {
+		t.Fatalf("ChangeStatus error = %v", err)
+	}
+	if updates := fake.ran("UPDATE leads"); len(updates) != 0 {
+		t.Errorf("%d updates, want none", len(updates))
+	}
+	if history := fake.ran("INSERT INTO lead_status_history"); len(history) != 0 {
+		t.Errorf("%d history entries, want none", len(history))
+	}
+}
+
+func TestChangeStatusRejectsUnknownStatus(t *testing.T) {
+	db, _ := newFakeDB(t)
+	s := &CustomerService{DB: db}
+	if _, err := s.ChangeStatus(7, models.LeadStatusRequest{Status: "won"}, 3); !errors.Is(err, ErrInvalidLead) {
+		t.Errorf("ChangeStatus error = %v, want ErrInvalidLead", err)
+	}
+}
+
+func TestMatchOrderSkipsIneligibleOrders(t *testing.T) {
+	db, fake := newFakeDB(t)
+	s := &CustomerService{DB: db}
+
+	leadID := 4
+	orders := []models.Order{
+		{ID: 1, Status: models.OrderStatusPending, CustomerEmail: "ada@example.com"},
+		{ID: 2, Status: models.OrderStatusPaid, CustomerEmail: "ada@example.com", LeadID: &leadID},
+		{ID: 3, Status: models.OrderStatusPaid},
+	}
+	for _, order := range orders {
+		lead, err := s.MatchOrder(&order)
+		if lead != nil || err != nil {
+			t.Errorf("MatchOrder(order %d) = %v, %v; want nil, nil", order.ID, lead, err)
+		}
+	}
+	if queries := fake.ran("FROM leads"); len(queries) != 0 {
+		t.Errorf("%d lead queries, want none", len(queries))
+	}
+}
+
+func TestMatchOrderWithoutLead(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.on("SELECT id FROM leads", []string{"id"})
+
+	s := &CustomerService{DB: db}
+	order := &models.Order{ID: 9, Status: models.OrderStatusPaid, CustomerEmail: "Ada@Example.com"}
+	lead, err := s.MatchOrder(order)
+	if lead != nil || err != nil {
+		t.Fatalf("MatchOrder = %v, %v; want nil, nil", lead, err)
+	}
+
+	queries := fake.ran("SELECT id FROM leads")
+	if len(queries) != 1 || queries[0].args[0] != "ada@example.com" {
+		t.Errorf("queries = %v, want one by lowercased email", queries)
+	}
+	if updates := fake.ran("UPDATE orders"); len(updates) != 0 {
+		t.Errorf("%d order updates, want none", len(updates))
+	}
+}
+
+func TestMatchOrderLinksLead(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.on("SELECT id FROM leads", []string{"id"}, []driver.Value{int64(4)})
+	fake.on("SELECT status FROM orders", []string{"status"}, []driver.Value{models.OrderStatusPaid})
+	fake.on("SELECT status FROM leads", []string{"status"}, []driver.Value{models.LeadStatusContacted})
+	errStop := errors.New("stop after the commit")
+	fake.fail("FROM leads WHERE id", errStop)
+
+	s := &CustomerService{DB: db}
+	order := &models.Order{ID: 9, Status: models.OrderStatusPaid, CustomerEmail: "ada@example.com"}
+	if _, err := s.MatchOrder(order); !errors.Is(err, errStop) {
+		t.Fatalf("MatchOrder error = %v", err)
+	}
+
+	updates := fake.ran("UPDATE orders SET lead_id")
+	if len(updates) != 1 || updates[0].args[0] != int64(4) || updates[0].args[1] != int64(9) {
+		t.Errorf("order updates = %v", updates)
+	}
+	history := fake.ran("INSERT INTO lead_status_history")
+	if len(history) != 1 || history[0].args[2] != models.LeadStatusEnrolled || history[0].args[3] != nil {
+		t.Errorf("history = %v, want one automatic change to enrolled", history)
+	}
+}
+
+func TestLinkOrderRequiresLead(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.on("FROM leads WHERE id", nil)
+
+	s := &CustomerService{DB: db}
+	if _, err := s.LinkOrder(9, 4, 2); !errors.Is(err, ErrLeadNotFound) {
+		t.Errorf("LinkOrder error = %v, want ErrLeadNotFound", err)
+	}
+	if updates := fake.ran("UPDATE orders"); len(updates) != 0 {
+		t.Errorf("%d order updates, want none", len(updates))
+	}
+}
+
+func TestExportCustomers(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.on("FROM leads l", []string{"email", "reference"},
+		[]driver.Value{"ada@example.com", "PB-1"},
+		[]driver.Value{"tunde@example.com", ""},
+	)
+
+	s := &CustomerService{DB: db}
+	var rows [][]string
+	err := s.ExportCustomers(models.CustomerExportFilter{Status: models.LeadStatusEnrolled}, []string{"email", " reference"}, func(row []string) error {
+		rows = append(rows, append([]string(nil), row...))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportCustomers error = %v", err)
+	}
+
+	want := [][]string{
+		{"Email", "Payment Reference"},
+		{"ada@example.com", "PB-1"},
+		{"tunde@example.com", ""},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+
+	queries := fake.ran("FROM leads l")
+	if len(queries) != 1 || !strings.Contains(queries[0].query, "l.status = $1") || queries[0].args[0] != models.LeadStatusEnrolled {
+		t.Errorf("queries = %v", queries)
+	}
+}
+
+func TestExportCustomersRejectsUnknownColumn(t *testing.T) {
+	db, fake := newFakeDB(t)
+	s := &CustomerService{DB: db}
+
+	err := s.ExportCustomers(models.CustomerExportFilter{}, []string{"email", "password"}, func([]string) error {
+		t.Error("row written for an invalid export")
+		return nil
+	})
+	if !errors.Is(err, ErrInvalidExport) {
+		t.Errorf("ExportCustomers error = %v, want ErrInvalidExport", err)
+	}
+	if queries := fake.ran("FROM leads"); len(queries) != 0 {
+		t.Errorf("%d queries, want none", len(queries))
+	}
+}
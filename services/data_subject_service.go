@@ -0,0 +1,469 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"plantbased-backend/models"
+	"strings"
+	"time"
+)
+
+// DataSubjectService finds, exports and anonymizes everything stored about a
+// person, identified by email, and keeps an audit trail of those actions
+type DataSubjectService struct {
+	DB              *sql.DB
+	customerService *CustomerService
+}
+
+func NewDataSubjectService(db *sql.DB, customerService *CustomerService) *DataSubjectService {
+	return &DataSubjectService{
+		DB:              db,
+		customerService: customerService,
+	}
+}
+
+// hashEmail returns the SHA-256 of a normalized email, used to refer to a
+// person in the audit trail without storing the address
+func hashEmail(email string) string {
+	sum := sha256.Sum256([]byte(normalizeEmail(email)))
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// outboxEmailMatch matches outbox email about or addressed to the email in $1
+const outboxEmailMatch = `(customer_email = $1
+	OR EXISTS (SELECT 1 FROM jsonb_array_elements_text(recipients) r WHERE LOWER(r) = $1))`
+
+// queryEach runs a query and calls fn for every row
+func (s *DataSubjectService) queryEach(query string, args []interface{}, fn func(rows *sql.Rows) error) error {
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// FindRecords collects every record tied to an email, without auditing
+func (s *DataSubjectService) FindRecords(email string) (*models.DataSubjectRecords, error) {
+	email = normalizeEmail(email)
+	args := []interface{}{email}
+	records := models.DataSubjectRecords{
+		Email:             email,
+		CustomerAccounts:  []models.CustomerAccount{},
+		CheckIns:          []models.CheckIn{},
+		Leads:             []models.LeadDetail{},
+		Orders:            []models.Order{},
+		Subscriptions:     []models.Subscription{},
+		Invoices:          []models.Invoice{},
+		CouponRedemptions: []models.CouponRedemption{},
+		Testimonials:      []models.Testimonial{},
+		WebhookEvents:     []models.WebhookEvent{},
+		Emails:            []models.OutboxEmail{},
+		Newsletter:        []models.NewsletterSubscriber{},
+		GeneratedAt:       time.Now(),
+	}
+
+	err := s.queryEach(`
+		SELECT `+customerAccountColumns+` FROM customer_accounts WHERE email = $1
+	`, args, func(rows *sql.Rows) error {
+		account, err := scanCustomerAccount(rows)
+		if err != nil {
+			return err
+		}
+		records.CustomerAccounts = append(records.CustomerAccounts, *account)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.queryEach(`
+		SELECT `+checkInColumns+` FROM program_check_ins
+		WHERE customer_id IN (SELECT id FROM customer_accounts WHERE email = $1)
+		ORDER BY check_in_date
+	`, args, func(rows *sql.Rows) error {
+		checkIn, err := scanCheckIn(rows)
+		if err != nil {
+			return err
+		}
+		records.CheckIns = append(records.CheckIns, *checkIn)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var leadIDs []int
+	err = s.queryEach("SELECT id FROM leads WHERE LOWER(email) = $1 ORDER BY created_at", args, func(rows *sql.Rows) error {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		leadIDs = append(leadIDs, id)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range leadIDs {
+		detail, err := s.customerService.GetLeadDetail(id)
+		if err != nil {
+			return nil, err
+		}
+		records.Leads = append(records.Leads, *detail)
+	}
+
+	err = s.queryEach(`
+		SELECT `+orderColumns+` FROM orders WHERE LOWER(customer_email) = $1 ORDER BY created_at
+	`, args, func(rows *sql.Rows) error {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return err
+		}
+		records.Orders = append(records.Orders, *order)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.queryEach(`
+		SELECT `+subscriptionColumns+` FROM subscriptions WHERE LOWER(customer_email) = $1 ORDER BY created_at
+	`, args, func(rows *sql.Rows) error {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return err
+		}
+		records.Subscriptions = append(records.Subscriptions, *subscription)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.queryEach(`
+		SELECT `+invoiceColumns+` FROM invoices WHERE LOWER(customer_email) = $1 ORDER BY sequence
+	`, args, func(rows *sql.Rows) error {
+		invoice, err := scanInvoice(rows)
+		if err != nil {
+			return err
+		}
+		records.Invoices = append(records.Invoices, *invoice)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.queryEach(`
+		SELECT `+redemptionColumns+` FROM coupon_redemptions WHERE LOWER(customer_email) = $1 ORDER BY created_at
+	`, args, func(rows *sql.Rows) error {
+		redemption, err := scanRedemption(rows)
+		if err != nil {
+			return err
+		}
+		records.CouponRedemptions = append(records.CouponRedemptions, *redemption)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.queryEach(`
+		SELECT id, name, location, review, avatar, created_at, updated_at
+		FROM testimonials WHERE LOWER(email) = $1 ORDER BY created_at
+	`, args, func(rows *sql.Rows) error {
+		var t models.Testimonial
+		if err := rows.Scan(&t.ID, &t.Name, &t.Location, &t.Review, &t.Avatar, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return err
+		}
+		records.Testimonials = append(records.Testimonials, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Paystack webhook payloads carry the customer's details too
+	err = s.queryEach(`
+		SELECT `+webhookEventColumns+` FROM webhook_events
+		WHERE LOWER(payload #>> '{data,customer,email}') = $1
+		ORDER BY created_at
+	`, args, func(rows *sql.Rows) error {
+		event, err := scanWebhookEvent(rows)
+		if err != nil {
+			return err
+		}
+		records.WebhookEvents = append(records.WebhookEvents, *event)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.queryEach(`
+		SELECT `+outboxEmailColumns+` FROM email_outbox
+		WHERE `+outboxEmailMatch+`
+		ORDER BY created_at
+	`, args, func(rows *sql.Rows) error {
+		email, err := scanOutboxEmail(rows)
+		if err != nil {
+			return err
+		}
+		records.Emails = append(records.Emails, *email)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.queryEach(`
+		SELECT `+newsletterSubscriberColumns+` FROM newsletter_subscribers WHERE email = $1
+	`, args, func(rows *sql.Rows) error {
+		sub, err := scanNewsletterSubscriber(rows)
+		if err != nil {
+			return err
+		}
+		records.Newsletter = append(records.Newsletter, *sub)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &records, nil
+}
+
+// Anonymize replaces the personal details stored for an email. Orders,
+// invoices, subscriptions and coupon redemptions are kept for accounting, with
+// the email swapped for a placeholder; invoice PDFs are re-rendered with it.
+// Registrations keep their program and status but lose name, contact details
+// and notes; testimonials keep their review but lose the reviewer's identity.
+func (s *DataSubjectService) Anonymize(req models.DataSubjectRequest, adminID int) (*models.DataSubjectAnonymizeResult, error) {
+	email := normalizeEmail(req.Email)
+
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	placeholder := "anonymized-" + hex.EncodeToString(token) + "@anonymized.invalid"
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var result models.DataSubjectAnonymizeResult
+	exec := func(count *int, query string, args ...interface{}) error {
+		res, err := tx.Exec(query, args...)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		*count = int(affected)
+		return nil
+	}
+
+	steps := []struct {
+		count *int
+		query string
+		args  []interface{}
+	}{
+		// Login links and notes first, while their owners can still be found by email
+		{new(int), `
+			DELETE FROM customer_login_tokens
+			WHERE customer_id IN (SELECT id FROM customer_accounts WHERE email = $1)
+		`, []interface{}{email}},
+		// Progress and check-ins hold health details, so they are deleted outright
+		{&result.SectionProgress, `
+			DELETE FROM program_section_progress
+			WHERE customer_id IN (SELECT id FROM customer_accounts WHERE email = $1)
+		`, []interface{}{email}},
+		{&result.CheckIns, `
+			DELETE FROM program_check_ins
+			WHERE customer_id IN (SELECT id FROM customer_accounts WHERE email = $1)
+		`, []interface{}{email}},
+		// Queued and sent email is deleted too, including anything not sent yet
+		{&result.Emails, `
+			DELETE FROM email_outbox WHERE ` + outboxEmailMatch,
+			[]interface{}{email}},
+		// Newsletter sign-ups rest on consent, so nothing is kept
+		{&result.Newsletter, `
+			DELETE FROM newsletter_subscribers WHERE email = $1
+		`, []interface{}{email}},
+		{&result.CustomerAccounts, `
+			UPDATE customer_accounts SET email = $2, full_name = '', is_active = false, updated_at = NOW()
+			WHERE email = $1
+		`, []interface{}{email, placeholder}},
+		{&result.LeadNotes, `
+			UPDATE lead_notes SET body = '[redacted]'
+			WHERE lead_id IN (SELECT id FROM leads WHERE LOWER(email) = $1)
+		`, []interface{}{email}},
+		{&result.Leads, `
+			UPDATE leads SET full_name = '', email = $2, nationality = '', country_code = '', phone_number = '',
+				anonymized_at = NOW(), updated_at = NOW()
+			WHERE LOWER(email) = $1
+		`, []interface{}{email, placeholder}},
+		{&result.Orders, `
+			UPDATE orders SET customer_email = $2, updated_at = NOW() WHERE LOWER(customer_email) = $1
+		`, []interface{}{email, placeholder}},
+		{&result.Subscriptions, `
+			UPDATE subscriptions SET customer_email = $2, updated_at = NOW() WHERE LOWER(customer_email) = $1
+		`, []interface{}{email, placeholder}},
+		{&result.CouponRedemptions, `
+			UPDATE coupon_redemptions SET customer_email = $2 WHERE LOWER(customer_email) = $1
+		`, []interface{}{email, placeholder}},
+		{&result.Testimonials, `
+			UPDATE testimonials SET name = 'Anonymous', location = '', avatar = '', email = '', updated_at = NOW()
+			WHERE LOWER(email) = $1
+		`, []interface{}{email}},
+		{&result.WebhookEvents, `
+			UPDATE webhook_events
+			SET payload = jsonb_set(payload #- '{data,authorization}', '{data,customer}',
+				jsonb_build_object('email', $2::text)), updated_at = NOW()
+			WHERE LOWER(payload #>> '{data,customer,email}') = $1
+		`, []interface{}{email, placeholder}},
+	}
+	for _, step := range steps {
+		if err := exec(step.count, step.query, step.args...); err != nil {
+			return nil, err
+		}
+	}
+
+	// Re-render invoice PDFs so the stored documents match
+	var invoices []models.Invoice
+	rows, err := tx.Query("SELECT "+invoiceColumns+" FROM invoices WHERE LOWER(customer_email) = $1 FOR UPDATE", email)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		invoice, err := scanInvoice(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		invoices = append(invoices, *invoice)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, invoice := range invoices {
+		invoice.CustomerEmail = placeholder
+		if _, err := tx.Exec(`
+			UPDATE invoices SET customer_email = $1, pdf = $2 WHERE id = $3
+		`, placeholder, renderInvoicePDF(invoice), invoice.ID); err != nil {
+			return nil, err
+		}
+	}
+	result.Invoices = len(invoices)
+
+	// Audit in the same transaction so every anonymization is on record
+	if err := recordAudit(tx, models.DataSubjectActionAnonymize, req, adminID, result); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Find collects every record tied to an email for an admin, recording the
+// lookup (or export) in the audit trail
+func (s *DataSubjectService) Find(req models.DataSubjectRequest, action string, adminID int) (*models.DataSubjectRecords, error) {
+	records, err := s.FindRecords(req.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{
+		"customer_accounts":  len(records.CustomerAccounts),
+		"check_ins":          len(records.CheckIns),
+		"leads":              len(records.Leads),
+		"orders":             len(records.Orders),
+		"subscriptions":      len(records.Subscriptions),
+		"invoices":           len(records.Invoices),
+		"coupon_redemptions": len(records.CouponRedemptions),
+		"testimonials":       len(records.Testimonials),
+		"webhook_events":     len(records.WebhookEvents),
+		"emails":             len(records.Emails),
+		"newsletter":         len(records.Newsletter),
+	}
+	if err := recordAudit(s.DB, action, req, adminID, counts); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// execer covers *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// recordAudit appends an entry to the data subject audit trail
+func recordAudit(db execer, action string, req models.DataSubjectRequest, adminID int, details interface{}) error {
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO data_subject_audit_log (action, email_hash, reason, admin_id, details)
+		VALUES ($1, $2, $3, $4, $5)
+	`, action, hashEmail(req.Email), strings.TrimSpace(req.Reason), nullableInt(adminID), string(detailsJSON))
+	return err
+}
+
+// GetAuditLog retrieves the audit trail, newest first. When email is given,
+// only entries for that person are returned.
+func (s *DataSubjectService) GetAuditLog(email string) ([]models.DataSubjectAuditEntry, error) {
+	query := `
+		SELECT l.id, l.action, l.email_hash, l.reason, l.admin_id, COALESCE(a.full_name, ''), l.details, l.created_at
+		FROM data_subject_audit_log l
+		LEFT JOIN admins a ON a.id = l.admin_id`
+	var args []interface{}
+	if email != "" {
+		query += " WHERE l.email_hash = $1"
+		args = append(args, hashEmail(email))
+	}
+	query += " ORDER BY l.created_at DESC"
+
+	entries := []models.DataSubjectAuditEntry{}
+	err := s.queryEach(query, args, func(rows *sql.Rows) error {
+		var entry models.DataSubjectAuditEntry
+		var details []byte
+		err := rows.Scan(
+			&entry.ID, &entry.Action, &entry.EmailHash, &entry.Reason,
+			&entry.AdminID, &entry.AdminName, &details, &entry.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		entry.Details = details
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"encoding/json"
+	"plantbased-backend/models"
+	"strings"
+	"testing"
+)
+
+func TestHashEmail(t *testing.T) {
+	want := hashEmail("ada@example.com")
+	if len(want) != 64 {
+		t.Fatalf("hashEmail = %q, want a hex SHA-256", want)
+	}
+	if got := hashEmail("  Ada@Example.COM "); got != want {
+		t.Errorf("hashEmail differs for the same address written differently: %s != %s", got, want)
+	}
+	if got := hashEmail("tunde@example.com"); got == want {
+		t.Error("hashEmail is the same for different addresses")
+	}
+}
+
+func TestRecordAudit(t *testing.T) {
+	db, fake := newFakeDB(t)
+
+	req := models.DataSubjectRequest{Email: "Ada@Example.com", Reason: " Ticket 42 "}
+	if err := recordAudit(db, models.DataSubjectActionExport, req, 0, map[string]int{"orders": 2}); err != nil {
+		t.Fatalf("recordAudit error = %v", err)
+	}
+
+	entries := fake.ran("INSERT INTO data_subject_audit_log")
+	if len(entries) != 1 {
+		t.Fatalf("%d audit entries, want 1", len(entries))
+	}
+	args := entries[0].args
+	if args[0] != models.DataSubjectActionExport || args[1] != hashEmail("ada@example.com") || args[2] != "Ticket 42" || args[3] != nil {
+		t.Errorf("audit args = %v", args)
+	}
+	if strings.Contains(args[1].(string), "ada") {
+		t.Error("audit entry stores the email")
+	}
+
+	var details map[string]int
+	if err := json.Unmarshal([]byte(args[4].(string)), &details); err != nil || details["orders"] != 2 {
+		t.Errorf("details = %v (%v)", args[4], err)
+	}
+}
+
+func TestGetAuditLogFiltersByEmailHash(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.on("FROM data_subject_audit_log", nil)
+
+	s := &DataSubjectService{DB: db}
+	entries, err := s.GetAuditLog("Ada@Example.com")
+	if err != nil {
+		t.Fatalf("GetAuditLog error = %v", err)
+	}
+	if entries == nil || len(entries) != 0 {
+		t.Errorf("GetAuditLog = %v, want an empty list", entries)
+	}
+
+	queries := fake.ran("FROM data_subject_audit_log")
+	if len(queries) != 1 || !strings.Contains(queries[0].query, "l.email_hash = $1") || queries[0].args[0] != hashEmail("ada@example.com") {
+		t.Errorf("queries = %v", queries)
+	}
+}
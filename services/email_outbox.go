@@ -0,0 +1,324 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"plantbased-backend/models"
+	"time"
+)
+
+// Email outbox errors
+var (
+	ErrEmailNotFound    = errors.New("email not found")
+	ErrEmailAlreadySent = errors.New("email has already been sent")
+)
+
+const (
+	outboxBatchSize = 20
+	// How long a claimed message is held before it may be claimed again, in
+	// case the process dies mid-send
+	outboxLease     = 10 * time.Minute
+	outboxRetryBase = time.Minute
+	outboxRetryMax  = 6 * time.Hour
+)
+
+const outboxEmailColumns = `id, kind, reference_id, customer_email, recipients, subject, status, attempts,
+	last_error, next_attempt_at, expires_at, sent_at, created_at, updated_at`
+
+// outboxSentUpdates record delivery on the record an email is about, keyed by kind
+var outboxSentUpdates = map[string]string{
+	models.EmailKindLeadNotification:  "UPDATE leads SET notified_at = NOW(), updated_at = NOW() WHERE id = $1",
+	models.EmailKindOrderConfirmation: "UPDATE invoices SET emailed_at = NOW() WHERE id = $1",
+}
+
+func scanOutboxEmail(row rowScanner) (*models.OutboxEmail, error) {
+	var e models.OutboxEmail
+	var recipientsJSON []byte
+	err := row.Scan(
+		&e.ID, &e.Kind, &e.ReferenceID, &e.CustomerEmail, &recipientsJSON, &e.Subject, &e.Status, &e.Attempts,
+		&e.LastError, &e.NextAttemptAt, &e.ExpiresAt, &e.SentAt, &e.CreatedAt, &e.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	e.Recipients = []string{}
+	json.Unmarshal(recipientsJSON, &e.Recipients)
+	return &e, nil
+}
+
+// outgoingEmail is a message to add to the outbox
+type outgoingEmail struct {
+	kind           string
+	referenceID    *int
+	customerEmail  string
+	to             []string
+	cc             []string
+	bcc            []string // Delivered to but never written into the message
+	subject        string
+	message        []byte
+	unsubscribeURL string // Adds List-Unsubscribe headers to bulk mail
+	expiresAt      *time.Time
+}
+
+// queue adds a message to the outbox. Pass the transaction making the change
+// the email is about so both are stored or neither is.
+func (s *EmailService) queue(db execer, email outgoingEmail) error {
+	// The envelope goes to every recipient, including blind copies
+	recipients := append(append(append([]string{}, email.to...), email.cc...), email.bcc...)
+	recipientsJSON, _ := json.Marshal(recipients)
+
+	_, err := db.Exec(`
+		INSERT INTO email_outbox (kind, reference_id, customer_email, recipients, subject, message, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, email.kind, email.referenceID, normalizeEmail(email.customerEmail), string(recipientsJSON),
+		email.subject, email.message, email.expiresAt)
+	if err != nil {
+		return err
+	}
+
+	// Messages queued in a transaction that has not committed yet are picked
+	// up on the next tick instead
+	s.wakeWorker()
+	return nil
+}
+
+func (s *EmailService) wakeWorker() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start sends queued email in the background until the process exits. The
+// worker runs on a fixed interval and whenever a message is queued.
+func (s *EmailService) Start() {
+	if s.interval <= 0 {
+		log.Println("Email outbox worker disabled (interval is not positive)")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-s.wake:
+			}
+
+			if err := s.ProcessOutbox(); err != nil {
+				log.Printf("Email outbox processing failed: %v", err)
+			}
+		}
+	}()
+}
+
+// ProcessOutbox sends every message that is due, in batches
+func (s *EmailService) ProcessOutbox() error {
+	if !s.running.TryLock() {
+		return nil
+	}
+	defer s.running.Unlock()
+
+	for {
+		claimed, err := s.claimDue()
+		if err != nil {
+			return err
+		}
+
+		for _, email := range claimed {
+			s.deliver(email)
+		}
+
+		if len(claimed) < outboxBatchSize {
+			return nil
+		}
+	}
+}
+
+// claimedEmail is a due message leased to this worker
+type claimedEmail struct {
+	id          int
+	kind        string
+	referenceID *int
+	recipients  []string
+	message     []byte
+	attempts    int
+	expired     bool
+}
+
+// claimDue leases a batch of due messages by pushing their next attempt past
+// the lease, so other instances skip them while they are being sent
+func (s *EmailService) claimDue() ([]claimedEmail, error) {
+	rows, err := s.DB.Query(`
+		UPDATE email_outbox SET next_attempt_at = NOW() + $1 * INTERVAL '1 second', updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM email_outbox
+			WHERE status = $2 AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, kind, reference_id, recipients, message, attempts,
+			COALESCE(expires_at <= NOW(), false)
+	`, int(outboxLease.Seconds()), models.EmailStatusPending, outboxBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claimed []claimedEmail
+	for rows.Next() {
+		var email claimedEmail
+		var recipientsJSON []byte
+		err := rows.Scan(
+			&email.id, &email.kind, &email.referenceID, &recipientsJSON, &email.message, &email.attempts, &email.expired,
+		)
+		if err != nil {
+			return nil, err
+		}
+		json.Unmarshal(recipientsJSON, &email.recipients)
+		claimed = append(claimed, email)
+	}
+
+	return claimed, rows.Err()
+}
+
+// deliver sends one claimed message and records the outcome. Failures are
+// retried with exponential backoff until the maximum attempts are used up,
+// after which the message is dead-lettered.
+func (s *EmailService) deliver(email claimedEmail) {
+	var err error
+	switch {
+	case email.expired:
+		err = errors.New("expired before it could be sent")
+	case len(email.message) == 0:
+		err = errors.New("message is empty")
+	default:
+		err = s.mailer.Send(mailFrom().Address, email.recipients, email.message)
+	}
+
+	if err == nil {
+		if err := s.markSent(email); err != nil {
+			log.Printf("Failed to record email %d as sent: %v", email.id, err)
+		}
+		return
+	}
+
+	attempts := email.attempts + 1
+	if email.expired || attempts >= s.maxAttempts {
+		log.Printf("Giving up on email %d after %d attempts: %v", email.id, attempts, err)
+		_, err = s.DB.Exec(`
+			UPDATE email_outbox SET status = $1, attempts = $2, last_error = $3, updated_at = NOW()
+			WHERE id = $4
+		`, models.EmailStatusDead, attempts, err.Error(), email.id)
+	} else {
+		_, err = s.DB.Exec(`
+			UPDATE email_outbox
+			SET attempts = $1, last_error = $2, next_attempt_at = NOW() + $3 * INTERVAL '1 second', updated_at = NOW()
+			WHERE id = $4
+		`, attempts, err.Error(), int(outboxRetryDelay(attempts).Seconds()), email.id)
+	}
+	if err != nil {
+		log.Printf("Failed to record failure of email %d: %v", email.id, err)
+	}
+}
+
+// outboxRetryDelay doubles the wait after every failed attempt, up to a cap
+func outboxRetryDelay(attempts int) time.Duration {
+	delay := outboxRetryBase
+	for i := 1; i < attempts && delay < outboxRetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, outboxRetryMax)
+}
+
+// markSent records delivery. The message is dropped since it is no longer
+// needed and may hold attachments and personal details.
+func (s *EmailService) markSent(email claimedEmail) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE email_outbox
+		SET status = $1, attempts = attempts + 1, last_error = '', message = NULL, sent_at = NOW(), updated_at = NOW()
+		WHERE id = $2
+	`, models.EmailStatusSent, email.id)
+	if err != nil {
+		return err
+	}
+
+	if update, ok := outboxSentUpdates[email.kind]; ok && email.referenceID != nil {
+		if _, err := tx.Exec(update, *email.referenceID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetOutbox lists queued and sent email, newest first, optionally filtered by status
+func (s *EmailService) GetOutbox(status string) ([]models.OutboxEmail, error) {
+	query := "SELECT " + outboxEmailColumns + " FROM email_outbox"
+	var args []interface{}
+	if status != "" {
+		query += " WHERE status = $1"
+		args = append(args, status)
+	}
+	query += " ORDER BY created_at DESC LIMIT 200"
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	emails := []models.OutboxEmail{}
+	for rows.Next() {
+		email, err := scanOutboxEmail(rows)
+		if err != nil {
+			return nil, err
+		}
+		emails = append(emails, *email)
+	}
+
+	return emails, rows.Err()
+}
+
+// GetOutboxEmailByID retrieves a single outbox entry
+func (s *EmailService) GetOutboxEmailByID(id int) (*models.OutboxEmail, error) {
+	email, err := scanOutboxEmail(s.DB.QueryRow("SELECT "+outboxEmailColumns+" FROM email_outbox WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrEmailNotFound
+	}
+	return email, err
+}
+
+// ResendEmail queues an unsent message for immediate delivery with a fresh
+// set of attempts, typically after a dead-lettered message's cause is fixed
+func (s *EmailService) ResendEmail(id int) (*models.OutboxEmail, error) {
+	email, err := s.GetOutboxEmailByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if email.Status == models.EmailStatusSent {
+		return nil, ErrEmailAlreadySent
+	}
+
+	_, err = s.DB.Exec(`
+		UPDATE email_outbox SET status = $1, attempts = 0, next_attempt_at = NOW(), updated_at = NOW()
+		WHERE id = $2 AND status <> $3
+	`, models.EmailStatusPending, id, models.EmailStatusSent)
+	if err != nil {
+		return nil, err
+	}
+
+	s.wakeWorker()
+	return s.GetOutboxEmailByID(id)
+}
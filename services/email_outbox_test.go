@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+	"plantbased-backend/config"
+	"plantbased-backend/models"
+	"testing"
+	"time"
+)
+
+func TestOutboxRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, time.Minute},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{9, 256 * time.Minute},
+		{10, 6 * time.Hour},
+		{100, 6 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := outboxRetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("outboxRetryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestQueueStoresMessage(t *testing.T) {
+	db, fake := newFakeDB(t)
+	s := &EmailService{DB: db, wake: make(chan struct{}, 1)}
+
+	leadID := 3
+	err := s.queue(db, outgoingEmail{
+		kind:          models.EmailKindLeadNotification,
+		referenceID:   &leadID,
+		customerEmail: " Ada@Example.com",
+		to:            []string{"ceo@plantbased.example"},
+		subject:       "New Customer Registration: Adaeze Okafor",
+		message:       []byte("Subject: New Customer Registration\r\n\r\nHello"),
+	})
+	if err != nil {
+		t.Fatalf("queue error = %v", err)
+	}
+
+	inserts := fake.ran("INSERT INTO email_outbox")
+	if len(inserts) != 1 {
+		t.Fatalf("%d inserts, want 1", len(inserts))
+	}
+	args := inserts[0].args
+	if args[0] != models.EmailKindLeadNotification || args[1] != int64(3) || args[2] != "ada@example.com" || args[3] != `["ceo@plantbased.example"]` {
+		t.Errorf("insert args = %v", args)
+	}
+
+	select {
+	case <-s.wake:
+	default:
+		t.Error("worker not woken after queueing")
+	}
+}
+
+func TestQueueDeliversBlindCopies(t *testing.T) {
+	db, fake := newFakeDB(t)
+	s := &EmailService{DB: db, wake: make(chan struct{}, 1)}
+
+	err := s.queue(db, outgoingEmail{
+		kind:    models.EmailKindLeadNotification,
+		to:      []string{"sales@plantbased.example"},
+		cc:      []string{"ceo@plantbased.example"},
+		bcc:     []string{"audit@plantbased.example"},
+		message: []byte("Subject: hi\r\n\r\nHello"),
+	})
+	if err != nil {
+		t.Fatalf("queue error = %v", err)
+	}
+
+	inserts := fake.ran("INSERT INTO email_outbox")
+	want := `["sales@plantbased.example","ceo@plantbased.example","audit@plantbased.example"]`
+	if len(inserts) != 1 || inserts[0].args[3] != want {
+		t.Errorf("inserts = %v, want every recipient on the envelope", inserts)
+	}
+}
+
+func TestDeliverRecordsOutcome(t *testing.T) {
+	config.AppConfig = &config.Config{MailFrom: "hello@plantbased.example"}
+	db, fake := newFakeDB(t)
+	mailer := NewMemoryMailer()
+	s := &EmailService{DB: db, mailer: mailer, maxAttempts: 3}
+
+	leadID := 3
+	s.deliver(claimedEmail{
+		id:          5,
+		kind:        models.EmailKindLeadNotification,
+		referenceID: &leadID,
+		recipients:  []string{"ceo@plantbased.example"},
+		message:     []byte("Subject: hi\r\n\r\nHello"),
+	})
+	if sent := mailer.Messages(); len(sent) != 1 || sent[0].To[0] != "ceo@plantbased.example" {
+		t.Errorf("sent = %+v, want one message to the CEO", sent)
+	}
+	if updates := fake.ran("UPDATE leads SET notified_at"); len(updates) != 1 || updates[0].args[0] != int64(3) {
+		t.Errorf("lead updates = %v, want lead 3 marked notified", updates)
+	}
+
+	// An expired message is dead-lettered without being sent
+	s.deliver(claimedEmail{id: 6, kind: models.EmailKindMagicLink, recipients: []string{"ada@example.com"}, message: []byte("x"), expired: true})
+	if sent := mailer.Messages(); len(sent) != 1 {
+		t.Errorf("%d messages sent, want the expired one skipped", len(sent))
+	}
+	dead := fake.ran("SET status = $1, attempts = $2")
+	if len(dead) != 1 || dead[0].args[0] != models.EmailStatusDead || dead[0].args[3] != int64(6) {
+		t.Errorf("dead-letter updates = %v", dead)
+	}
+}
+
+func TestDeliverRetriesFailures(t *testing.T) {
+	config.AppConfig = &config.Config{MailFrom: "hello@plantbased.example"}
+	db, fake := newFakeDB(t)
+	s := &EmailService{DB: db, mailer: failingMailer{errors.New("connection refused")}, maxAttempts: 3}
+
+	s.deliver(claimedEmail{id: 5, recipients: []string{"ada@example.com"}, message: []byte("x"), attempts: 1})
+	retries := fake.ran("next_attempt_at = NOW() + $3")
+	if len(retries) != 1 {
+		t.Fatalf("%d retries scheduled, want 1", len(retries))
+	}
+	if args := retries[0].args; args[0] != int64(2) || args[1] != "connection refused" || args[2] != int64(120) {
+		t.Errorf("retry args = %v", args)
+	}
+
+	// The last attempt dead-letters the message
+	s.deliver(claimedEmail{id: 5, recipients: []string{"ada@example.com"}, message: []byte("x"), attempts: 2})
+	if dead := fake.ran("SET status = $1, attempts = $2"); len(dead) != 1 || dead[0].args[0] != models.EmailStatusDead {
+		t.Errorf("dead-letter updates = %v", dead)
+	}
+}
+
+// failingMailer fails every send with err
+type failingMailer struct {
+	err error
+}
+
+func (m failingMailer) Send(from string, to []string, message []byte) error {
+	return m.err
+}
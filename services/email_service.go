@@ -1,57 +1,405 @@
 package services
 
 import (
+	"bytes"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
-	"net/smtp"
-	"os"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"plantbased-backend/config"
 	"plantbased-backend/models"
+	"strings"
+	"sync"
+	"time"
 )
 
-type EmailService struct{}
+// EmailService builds outgoing email and queues it in the outbox, from where
+// a background worker delivers it through the mailer
+type EmailService struct {
+	DB          *sql.DB
+	mailer      Mailer
+	maxAttempts int
+	interval    time.Duration
+	wake        chan struct{}
+	running     sync.Mutex
+}
+
+func NewEmailService(db *sql.DB, mailer Mailer, maxAttempts int, interval time.Duration) *EmailService {
+	return &EmailService{
+		DB:          db,
+		mailer:      mailer,
+		maxAttempts: maxAttempts,
+		interval:    interval,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// EmailAttachment is a file attached to an outgoing email
+type EmailAttachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// compose renders the current template for an email and queues it, unless
+// admins have switched that kind of email off
+func (s *EmailService) compose(db execer, email outgoingEmail, data interface{}, attachments ...EmailAttachment) error {
+	tmpl, err := s.GetTemplate(email.kind)
+	if err != nil {
+		return err
+	}
+	if !tmpl.Enabled {
+		return nil
+	}
+
+	rendered, err := renderCurrentTemplate(tmpl, data)
+	if err != nil {
+		return err
+	}
+
+	email.subject = rendered.Subject
+	email.message, err = buildMessage(email, rendered, attachments...)
+	if err != nil {
+		return err
+	}
+
+	return s.queue(db, email)
+}
+
+// execQuerier covers *sql.DB and *sql.Tx for notifications, which read the
+// notification rules and queue the email in the same transaction
+type execQuerier interface {
+	execer
+	querier
+}
+
+// notify queues the internal notification for an event to the recipients of
+// the matching notification rules. Nothing is sent when no rule matches,
+// except that new lead notifications fall back to CEO_EMAIL.
+func (s *EmailService) notify(db execQuerier, event string, programID *int, email outgoingEmail, data interface{}) error {
+	routing, err := notificationRecipients(db, event, programID)
+	if err != nil {
+		return err
+	}
+	if len(routing.to) == 0 && event == models.NotificationEventNewLead && config.AppConfig.CEOEmail != "" {
+		routing.to = []string{config.AppConfig.CEOEmail}
+	}
+	if len(routing.to) == 0 {
+		log.Printf("No notification recipients for %s; %s email not sent", event, email.kind)
+		return nil
+	}
+
+	email.to, email.cc, email.bcc = routing.to, routing.cc, routing.bcc
+	return s.compose(db, email, data)
+}
+
+// SendLeadNotification queues the new lead notification for staff
+func (s *EmailService) SendLeadNotification(db execQuerier, lead models.Lead) error {
+	return s.notify(db, models.NotificationEventNewLead, lead.ProgramID, outgoingEmail{
+		kind:          models.EmailKindLeadNotification,
+		referenceID:   &lead.ID,
+		customerEmail: lead.Email,
+	}, leadNotificationData{
+		BusinessName: config.AppConfig.BusinessName,
+		Lead:         lead,
+	})
+}
+
+// SendPaymentNotification queues the payment received notification for staff
+func (s *EmailService) SendPaymentNotification(db execQuerier, order models.Order) error {
+	return s.notify(db, models.NotificationEventPaymentReceived, order.ProgramID, outgoingEmail{
+		kind:          models.EmailKindPaymentNotification,
+		referenceID:   &order.ID,
+		customerEmail: order.CustomerEmail,
+	}, paymentNotificationData{
+		BusinessName: config.AppConfig.BusinessName,
+		Order:        order,
+		Amount:       models.Money{Amount: order.Amount, Currency: order.Currency}.String(),
+	})
+}
+
+// SendRefundNotification queues the processed refund notification for staff
+func (s *EmailService) SendRefundNotification(db execQuerier, order models.Order, refund models.Refund) error {
+	return s.notify(db, models.NotificationEventRefund, order.ProgramID, outgoingEmail{
+		kind:          models.EmailKindRefundNotification,
+		referenceID:   &refund.ID,
+		customerEmail: order.CustomerEmail,
+	}, refundNotificationData{
+		BusinessName: config.AppConfig.BusinessName,
+		Order:        order,
+		Refund:       refund,
+		Amount:       models.Money{Amount: refund.Amount, Currency: refund.Currency}.String(),
+	})
+}
+
+// SendTestimonialNotification queues the new testimonial notification for staff
+func (s *EmailService) SendTestimonialNotification(db execQuerier, testimonial models.Testimonial, email string) error {
+	return s.notify(db, models.NotificationEventNewTestimonial, nil, outgoingEmail{
+		kind:          models.EmailKindTestimonialNotification,
+		referenceID:   &testimonial.ID,
+		customerEmail: email,
+	}, testimonialNotificationData{
+		BusinessName: config.AppConfig.BusinessName,
+		Testimonial:  testimonial,
+		Email:        email,
+	})
+}
+
+// SendRegistrationAcknowledgement queues the customer's confirmation that
+// their registration was received
+func (s *EmailService) SendRegistrationAcknowledgement(db execer, lead models.Lead) error {
+	return s.compose(db, outgoingEmail{
+		kind:          models.EmailKindRegistrationAck,
+		referenceID:   &lead.ID,
+		customerEmail: lead.Email,
+		to:            []string{lead.Email},
+	}, registrationAckData{
+		BusinessName: config.AppConfig.BusinessName,
+		Lead:         lead,
+	})
+}
+
+// SendOrderConfirmation queues the customer's payment confirmation with the
+// invoice PDF attached
+func (s *EmailService) SendOrderConfirmation(db execer, invoice models.Invoice, pdf []byte) error {
+	return s.compose(db, outgoingEmail{
+		kind:          models.EmailKindOrderConfirmation,
+		referenceID:   &invoice.ID,
+		customerEmail: invoice.CustomerEmail,
+		to:            []string{invoice.CustomerEmail},
+	}, orderConfirmationData{
+		BusinessName: config.AppConfig.BusinessName,
+		Invoice:      invoice,
+		AmountPaid:   models.Money{Amount: invoice.Amount, Currency: invoice.Currency}.String(),
+	}, EmailAttachment{
+		Filename:    invoice.InvoiceNumber + ".pdf",
+		ContentType: "application/pdf",
+		Data:        pdf,
+	})
+}
+
+// SendWelcome queues the welcome email for a customer's first paid order for
+// a program. The plan is nil when the order could not be matched to one.
+func (s *EmailService) SendWelcome(db execer, order models.Order, name string, program models.Program, plan *models.ProgramPricingPlan) error {
+	data := welcomeData{
+		BusinessName: config.AppConfig.BusinessName,
+		Name:         name,
+		Program:      program.Summary(),
+		PlanName:     order.PlanName,
+		Features:     []string{},
+		PortalURL:    config.AppConfig.CustomerLoginURL,
+	}
+	if plan != nil {
+		data.Features = plan.Features
+	}
+
+	return s.compose(db, outgoingEmail{
+		kind:          models.EmailKindWelcome,
+		referenceID:   &order.ID,
+		customerEmail: order.CustomerEmail,
+		to:            []string{order.CustomerEmail},
+	}, data)
+}
+
+// SendMagicLink queues a customer's single-use login link. It is not sent
+// once the link has expired.
+func (s *EmailService) SendMagicLink(db execer, account models.CustomerAccount, link string, ttl time.Duration) error {
+	expiresAt := time.Now().Add(ttl)
+	return s.compose(db, outgoingEmail{
+		kind:          models.EmailKindMagicLink,
+		referenceID:   &account.ID,
+		customerEmail: account.Email,
+		to:            []string{account.Email},
+		expiresAt:     &expiresAt,
+	}, magicLinkData{
+		BusinessName:     config.AppConfig.BusinessName,
+		Name:             account.FullName,
+		Link:             link,
+		ExpiresInMinutes: int(ttl.Minutes()),
+	})
+}
+
+// SendNewsletterConfirmation queues the double opt-in email for a newsletter
+// sign-up. It is not sent once the link has expired.
+func (s *EmailService) SendNewsletterConfirmation(db execer, subscriberID int, email, link string, ttl time.Duration) error {
+	expiresAt := time.Now().Add(ttl)
+	return s.compose(db, outgoingEmail{
+		kind:          models.EmailKindNewsletterConfirmation,
+		referenceID:   &subscriberID,
+		customerEmail: email,
+		to:            []string{email},
+		expiresAt:     &expiresAt,
+	}, newsletterConfirmationData{
+		BusinessName:   config.AppConfig.BusinessName,
+		Link:           link,
+		ExpiresInHours: int(ttl.Hours()),
+	})
+}
+
+// SendNewsletter queues one subscriber's copy of a rendered newsletter issue,
+// with one-click unsubscribe headers
+func (s *EmailService) SendNewsletter(db execer, subscriber models.NewsletterSubscriber, issue *renderedEmail, unsubscribeURL string) error {
+	email := outgoingEmail{
+		kind:           models.EmailKindNewsletter,
+		referenceID:    &subscriber.ID,
+		customerEmail:  subscriber.Email,
+		to:             []string{subscriber.Email},
+		subject:        issue.Subject,
+		unsubscribeURL: unsubscribeURL,
+	}
+
+	var err error
+	email.message, err = buildMessage(email, issue)
+	if err != nil {
+		return err
+	}
+	return s.queue(db, email)
+}
+
+// mailFrom returns the sender address. MAIL_FROM may be a bare address or
+// include a display name; the business name is used when it has none.
+func mailFrom() *mail.Address {
+	from, err := mail.ParseAddress(config.AppConfig.MailFrom)
+	if err != nil {
+		from = &mail.Address{Address: config.AppConfig.MailFrom}
+	}
+	if from.Name == "" {
+		from.Name = config.AppConfig.BusinessName
+	}
+	return from
+}
+
+// addressList formats addresses for a To or Cc header
+func addressList(addresses []string) string {
+	formatted := make([]string, len(addresses))
+	for i, address := range addresses {
+		formatted[i] = (&mail.Address{Address: address}).String()
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// messageID returns a unique Message-ID in the sender's domain
+func messageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 && at < len(from)-1 {
+		domain = from[at+1:]
+	}
 
-func NewEmailService() *EmailService {
-	return &EmailService{}
+	raw := make([]byte, 16)
+	rand.Read(raw)
+	return "<" + hex.EncodeToString(raw) + "@" + domain + ">"
 }
 
-func (s *EmailService) SendCustomerDetailsToCEO(details models.CustomerDetails) error {
-	from := os.Getenv("SMTP_EMAIL")
-	password := os.Getenv("SMTP_PASSWORD")
-	ceoEmail := os.Getenv("CEO_EMAIL")
+// buildMessage creates a MIME message with plain-text and HTML alternatives,
+// wrapped in multipart/mixed when there are attachments. Non-ASCII subjects
+// and names are encoded as RFC 2047 encoded-words. Blind copies are left out;
+// they are only added to the envelope.
+func buildMessage(email outgoingEmail, rendered *renderedEmail, attachments ...EmailAttachment) ([]byte, error) {
+	var buf bytes.Buffer
+	from := mailFrom()
 
-	smtpHost := "smtp.gmail.com"
-	smtpPort := "587"
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", addressList(email.to))
+	if len(email.cc) > 0 {
+		fmt.Fprintf(&buf, "Cc: %s\r\n", addressList(email.cc))
+	}
+	// Fold long encoded subjects between encoded-words to keep lines short
+	subject := mime.QEncoding.Encode("UTF-8", rendered.Subject)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", strings.ReplaceAll(subject, "?= =?", "?=\r\n =?"))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: %s\r\n", messageID(from.Address))
+	if email.unsubscribeURL != "" {
+		// One-click unsubscribe as described in RFC 8058
+		fmt.Fprintf(&buf, "List-Unsubscribe: <%s>\r\n", email.unsubscribeURL)
+		buf.WriteString("List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n")
+	}
+	buf.WriteString("MIME-Version: 1.0\r\n")
 
-	subject := fmt.Sprintf("New Customer Registration: %s", details.FullName)
-	body := fmt.Sprintf(`New customer has registered for PlantBased Meals:
+	// The alternatives are built first since their boundary goes in a header
+	var body bytes.Buffer
+	alternative := multipart.NewWriter(&body)
+	if err := writeAlternatives(alternative, rendered); err != nil {
+		return nil, err
+	}
+	alternativeType := mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": alternative.Boundary()})
 
-Full Name: %s
-Email: %s
-Phone Number: %s
-Nationality: %s
-Program: %s
-Package: %s
+	if len(attachments) == 0 {
+		fmt.Fprintf(&buf, "Content-Type: %s\r\n\r\n", alternativeType)
+		buf.Write(body.Bytes())
+		return buf.Bytes(), nil
+	}
 
-Best regards,
-PlantBased Meals System`,
-		details.FullName,
-		details.Email,
-		details.PhoneNumber,
-		details.Nationality,
-		details.Program,
-		details.Package,
-	)
+	mixed := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: %s\r\n\r\n", mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": mixed.Boundary()}))
 
-	message := []byte(fmt.Sprintf("Subject: %s\r\n\r\n%s", subject, body))
+	part, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {alternativeType},
+	})
+	if err != nil {
+		return nil, err
+	}
+	part.Write(body.Bytes())
 
-	auth := smtp.PlainAuth("", from, password, smtpHost)
+	for _, attachment := range attachments {
+		part, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(attachment.ContentType, map[string]string{"name": attachment.Filename})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
 
-	err := smtp.SendMail(
-		smtpHost+":"+smtpPort,
-		auth,
-		from,
-		[]string{ceoEmail},
-		message,
-	)
+		// Base64 with the 76 character line limit from RFC 2045
+		encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+		for len(encoded) > 76 {
+			part.Write([]byte(encoded[:76] + "\r\n"))
+			encoded = encoded[76:]
+		}
+		part.Write([]byte(encoded + "\r\n"))
+	}
 
-	return err
-}
\ No newline at end of file
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeAlternatives writes the plain-text and HTML parts, quoted-printable
+// encoded, with the preferred HTML part last as RFC 2046 requires
+func writeAlternatives(writer *multipart.Writer, email *renderedEmail) error {
+	parts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=UTF-8", email.Text},
+		{"text/html; charset=UTF-8", email.HTML},
+	}
+
+	for _, p := range parts {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return err
+		}
+
+		qp := quotedprintable.NewWriter(part)
+		if _, err := qp.Write([]byte(p.body)); err != nil {
+			return err
+		}
+		if err := qp.Close(); err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"mime"
+	"net/mail"
+	"plantbased-backend/config"
+	"plantbased-backend/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildMessage(t *testing.T) {
+	config.AppConfig = &config.Config{MailFrom: "hello@plantbased.example", BusinessName: "PlantBased"}
+
+	email := outgoingEmail{
+		to:             []string{"ada@example.com"},
+		cc:             []string{"ops@plantbased.example"},
+		bcc:            []string{"audit@plantbased.example"},
+		unsubscribeURL: "https://api.plantbased.example/api/v1/newsletter/unsubscribe?email=ada%40example.com&token=abc",
+	}
+	subject := "Your Ọ̀nà Àlàáfíà plan — payment received for ₦25,000.00, thank you for joining us"
+	rendered := &renderedEmail{Subject: subject, HTML: "<p>Hello</p>", Text: "Hello"}
+
+	raw, err := buildMessage(email, rendered)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("message does not parse: %v", err)
+	}
+
+	// RFC 2047: headers are ASCII, encoded-words are at most 75 characters,
+	// and the subject decodes back to the original
+	for _, line := range strings.Split(string(raw[:bytes.Index(raw, []byte("\r\n\r\n"))]), "\r\n") {
+		for _, r := range line {
+			if r > 127 {
+				t.Fatalf("header line is not ASCII: %q", line)
+			}
+		}
+		for _, word := range strings.Fields(line) {
+			if strings.HasPrefix(word, "=?") && len(word) > 75 {
+				t.Errorf("encoded-word longer than 75 characters: %q", word)
+			}
+		}
+	}
+	decoded, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded != subject {
+		t.Errorf("Subject decodes to %q, want %q", decoded, subject)
+	}
+
+	if got := msg.Header.Get("From"); got != `"PlantBased" <hello@plantbased.example>` {
+		t.Errorf("From = %q", got)
+	}
+	if got := msg.Header.Get("To"); got != "<ada@example.com>" {
+		t.Errorf("To = %q", got)
+	}
+	if got := msg.Header.Get("Cc"); got != "<ops@plantbased.example>" {
+		t.Errorf("Cc = %q", got)
+	}
+	if got := msg.Header.Get("Message-ID"); !strings.HasSuffix(got, "@plantbased.example>") {
+		t.Errorf("Message-ID = %q, want one in the sender's domain", got)
+	}
+	if _, ok := msg.Header["Bcc"]; ok {
+		t.Error("message has a Bcc header")
+	}
+	if bytes.Contains(raw, []byte("audit@plantbased.example")) {
+		t.Error("blind copy address appears in the message")
+	}
+
+	// RFC 8058 one-click unsubscribe
+	if got := msg.Header.Get("List-Unsubscribe"); got != "<"+email.unsubscribeURL+">" {
+		t.Errorf("List-Unsubscribe = %q", got)
+	}
+	if got := msg.Header.Get("List-Unsubscribe-Post"); got != "List-Unsubscribe=One-Click" {
+		t.Errorf("List-Unsubscribe-Post = %q", got)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Errorf("Content-Type = %q, want multipart/alternative", msg.Header.Get("Content-Type"))
+	}
+}
+
+func TestBuildMessageWithoutListHeaders(t *testing.T) {
+	config.AppConfig = &config.Config{MailFrom: "hello@plantbased.example", BusinessName: "PlantBased"}
+
+	raw, err := buildMessage(outgoingEmail{to: []string{"ada@example.com"}}, &renderedEmail{Subject: "Receipt", HTML: "<p>Hi</p>", Text: "Hi"},
+		EmailAttachment{Filename: "INV-0001.pdf", ContentType: "application/pdf", Data: []byte("%PDF-1.4")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("message does not parse: %v", err)
+	}
+	if got := msg.Header.Get("Subject"); got != "Receipt" {
+		t.Errorf("Subject = %q, want it unencoded", got)
+	}
+	if _, ok := msg.Header["Cc"]; ok {
+		t.Error("message has a Cc header without copies")
+	}
+	if _, ok := msg.Header["List-Unsubscribe"]; ok {
+		t.Error("transactional message has a List-Unsubscribe header")
+	}
+
+	mediaType, _, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Errorf("Content-Type = %q, want multipart/mixed", msg.Header.Get("Content-Type"))
+	}
+	if !bytes.Contains(raw, []byte("attachment; filename=INV-0001.pdf")) {
+		t.Error("attachment filename missing")
+	}
+}
+
+func TestComposeSkipsDisabledEmails(t *testing.T) {
+	config.AppConfig = &config.Config{MailFrom: "hello@plantbased.example", BusinessName: "PlantBased", MagicLinkTTLMinutes: 15}
+	db, fake := newFakeDB(t)
+	fake.on("FROM email_templates", nil)
+	fake.on("FROM email_settings", []string{"enabled"}, []driver.Value{false})
+
+	s := &EmailService{DB: db, wake: make(chan struct{}, 1)}
+	lead := models.Lead{ID: 3, FullName: "Adaeze Okafor", Email: "ada@example.com"}
+	if err := s.SendRegistrationAcknowledgement(db, lead); err != nil {
+		t.Fatalf("SendRegistrationAcknowledgement error = %v", err)
+	}
+	if queued := fake.ran("INSERT INTO email_outbox"); len(queued) != 0 {
+		t.Errorf("%d emails queued while switched off, want none", len(queued))
+	}
+
+	// Login links cannot be switched off
+	account := models.CustomerAccount{ID: 7, Email: "ada@example.com"}
+	if err := s.SendMagicLink(db, account, "https://plantbased.example/login?token=abc", 15*time.Minute); err != nil {
+		t.Fatalf("SendMagicLink error = %v", err)
+	}
+	if queued := fake.ran("INSERT INTO email_outbox"); len(queued) != 1 || queued[0].args[0] != models.EmailKindMagicLink {
+		t.Errorf("queued = %v, want the login link", queued)
+	}
+}
+
+func TestSendWelcomeListsPlanFeatures(t *testing.T) {
+	config.AppConfig = &config.Config{MailFrom: "hello@plantbased.example", BusinessName: "PlantBased", CustomerLoginURL: "https://plantbased.example/login"}
+	db, fake := newFakeDB(t)
+	fake.on("FROM email_templates", nil)
+	fake.on("FROM email_settings", nil)
+
+	s := &EmailService{DB: db, wake: make(chan struct{}, 1)}
+	order := models.Order{ID: 9, CustomerEmail: "ada@example.com", PlanName: "Standard"}
+	program := models.Program{ID: 3, Name: "Gut Reset"}
+	plan := &models.ProgramPricingPlan{Name: "Standard", Features: []string{"Weekly meal plans", "Daily check-ins"}}
+	if err := s.SendWelcome(db, order, "Adaeze", program, plan); err != nil {
+		t.Fatalf("SendWelcome error = %v", err)
+	}
+
+	queued := fake.ran("INSERT INTO email_outbox")
+	if len(queued) != 1 {
+		t.Fatalf("%d emails queued, want 1", len(queued))
+	}
+	args := queued[0].args
+	if args[0] != models.EmailKindWelcome || args[3] != `["ada@example.com"]` || args[4] != "Welcome to Gut Reset" {
+		t.Errorf("queued args = %v", args[:5])
+	}
+	message := string(args[5].([]byte))
+	for _, feature := range plan.Features {
+		if !strings.Contains(message, feature) {
+			t.Errorf("welcome email does not list %q", feature)
+		}
+	}
+}
+
+func TestNotifyRoutesByRules(t *testing.T) {
+	config.AppConfig = &config.Config{MailFrom: "hello@plantbased.example", BusinessName: "PlantBased", CEOEmail: "ceo@plantbased.example"}
+	db, fake := newFakeDB(t)
+	fake.on("FROM notification_rules", nil)
+	fake.on("FROM email_templates", nil)
+	fake.on("FROM email_settings", nil)
+
+	s := &EmailService{DB: db, wake: make(chan struct{}, 1)}
+
+	// Without rules, new leads still reach the CEO
+	lead := models.Lead{ID: 3, FullName: "Adaeze Okafor", Email: "ada@example.com"}
+	if err := s.SendLeadNotification(db, lead); err != nil {
+		t.Fatalf("SendLeadNotification error = %v", err)
+	}
+	queued := fake.ran("INSERT INTO email_outbox")
+	if len(queued) != 1 || queued[0].args[3] != `["ceo@plantbased.example"]` {
+		t.Fatalf("queued = %v, want one email to the CEO", queued)
+	}
+
+	// Other events are only sent to rule recipients
+	order := models.Order{ID: 9, CustomerEmail: "ada@example.com", Status: models.OrderStatusPaid}
+	if err := s.SendPaymentNotification(db, order); err != nil {
+		t.Fatalf("SendPaymentNotification error = %v", err)
+	}
+	if queued := fake.ran("INSERT INTO email_outbox"); len(queued) != 1 {
+		t.Errorf("%d emails queued, want the payment notification skipped", len(queued))
+	}
+}
@@ -0,0 +1,700 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"plantbased-backend/config"
+	"plantbased-backend/models"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Email template errors
+var (
+	ErrTemplateNotFound = errors.New("email template not found")
+	ErrInvalidTemplate  = errors.New("invalid email template")
+)
+
+// Data available to each template
+
+type leadNotificationData struct {
+	BusinessName string
+	Lead         models.Lead
+}
+
+type orderConfirmationData struct {
+	BusinessName string
+	Invoice      models.Invoice
+	AmountPaid   string // Formatted with the currency symbol
+}
+
+type magicLinkData struct {
+	BusinessName     string
+	Name             string // Empty when the account has no name
+	Link             string
+	ExpiresInMinutes int
+}
+
+type registrationAckData struct {
+	BusinessName string
+	Lead         models.Lead
+}
+
+type welcomeData struct {
+	BusinessName string
+	Name         string // Empty when the customer never registered
+	Program      models.ProgramSummary
+	PlanName     string
+	Features     []string
+	PortalURL    string
+}
+
+type newsletterConfirmationData struct {
+	BusinessName   string
+	Link           string
+	ExpiresInHours int
+}
+
+type paymentNotificationData struct {
+	BusinessName string
+	Order        models.Order
+	Amount       string // Formatted with the currency symbol
+}
+
+type refundNotificationData struct {
+	BusinessName string
+	Order        models.Order
+	Refund       models.Refund
+	Amount       string // The refunded amount, formatted with the currency symbol
+}
+
+type testimonialNotificationData struct {
+	BusinessName string
+	Testimonial  models.Testimonial
+	Email        string // Empty when the author gave none
+}
+
+// emailTemplateDefault is a built-in template, used until an admin edits it
+type emailTemplateDefault struct {
+	description string
+	subject     string
+	html        string
+	text        string
+	variables   []string
+	required    bool               // Cannot be switched off
+	sample      func() interface{} // Data for previews and for checking edits
+}
+
+// emailTemplateKeys lists the templates in display order
+var emailTemplateKeys = []string{
+	models.EmailKindLeadNotification,
+	models.EmailKindRegistrationAck,
+	models.EmailKindOrderConfirmation,
+	models.EmailKindWelcome,
+	models.EmailKindMagicLink,
+	models.EmailKindNewsletterConfirmation,
+	models.EmailKindPaymentNotification,
+	models.EmailKindRefundNotification,
+	models.EmailKindTestimonialNotification,
+}
+
+var defaultEmailTemplates = map[string]emailTemplateDefault{
+	models.EmailKindLeadNotification: {
+		description: "Sent to the new lead notification recipients when a customer registers",
+		subject:     `New Customer Registration: {{.Lead.FullName}}`,
+		html: `<p>New customer has registered for {{.BusinessName}}:</p>
+<table cellpadding="4">
+<tr><th align="left">Full Name</th><td>{{.Lead.FullName}}</td></tr>
+<tr><th align="left">Email</th><td>{{.Lead.Email}}</td></tr>
+<tr><th align="left">Phone Number</th><td>{{.Lead.PhoneNumber}}</td></tr>
+<tr><th align="left">Nationality</th><td>{{.Lead.Nationality}}</td></tr>
+<tr><th align="left">Program</th><td>{{.Lead.Program}}</td></tr>
+<tr><th align="left">Package</th><td>{{.Lead.Package}}</td></tr>
+</table>
+<p>Best regards,<br>{{.BusinessName}} System</p>
+`,
+		text: `New customer has registered for {{.BusinessName}}:
+
+Full Name: {{.Lead.FullName}}
+Email: {{.Lead.Email}}
+Phone Number: {{.Lead.PhoneNumber}}
+Nationality: {{.Lead.Nationality}}
+Program: {{.Lead.Program}}
+Package: {{.Lead.Package}}
+
+Best regards,
+{{.BusinessName}} System
+`,
+		variables: []string{
+			"BusinessName", "Lead.FullName", "Lead.Email", "Lead.PhoneNumber", "Lead.Nationality",
+			"Lead.CountryCode", "Lead.Program", "Lead.Package", "Lead.CreatedAt",
+		},
+		sample: func() interface{} {
+			return leadNotificationData{
+				BusinessName: config.AppConfig.BusinessName,
+				Lead: models.Lead{
+					FullName:    "Adaeze Okafor",
+					Email:       "adaeze@example.com",
+					PhoneNumber: "+2348012345678",
+					Nationality: "Nigeria",
+					CountryCode: "NG",
+					Program:     "30-Day Reset",
+					Package:     "Standard",
+					CreatedAt:   time.Now(),
+				},
+			}
+		},
+	},
+	models.EmailKindOrderConfirmation: {
+		description: "Sent to the customer when a payment is confirmed, with the invoice attached",
+		subject:     `Payment confirmed: {{.Invoice.ProgramName}}`,
+		html: `<p>Hello,</p>
+<p>Thank you for your payment. This email confirms your enrollment:</p>
+<table cellpadding="4">
+<tr><th align="left">Program</th><td>{{.Invoice.ProgramName}}</td></tr>
+<tr><th align="left">Plan</th><td>{{.Invoice.PlanName}}</td></tr>
+<tr><th align="left">Amount paid</th><td>{{.AmountPaid}}</td></tr>
+<tr><th align="left">Reference</th><td>{{.Invoice.Reference}}</td></tr>
+</table>
+<p>Your invoice {{.Invoice.InvoiceNumber}} is attached.</p>
+<p>Best regards,<br>{{.BusinessName}}</p>
+`,
+		text: `Hello,
+
+Thank you for your payment. This email confirms your enrollment:
+
+Program: {{.Invoice.ProgramName}}
+Plan: {{.Invoice.PlanName}}
+Amount paid: {{.AmountPaid}}
+Reference: {{.Invoice.Reference}}
+
+Your invoice {{.Invoice.InvoiceNumber}} is attached.
+
+Best regards,
+{{.BusinessName}}
+`,
+		variables: []string{
+			"BusinessName", "AmountPaid", "Invoice.InvoiceNumber", "Invoice.Reference", "Invoice.CustomerEmail",
+			"Invoice.ProgramName", "Invoice.PlanName", "Invoice.IssuedAt",
+		},
+		sample: func() interface{} {
+			invoice := models.Invoice{
+				InvoiceNumber: config.AppConfig.InvoicePrefix + "-000042",
+				Reference:     "PB-SAMPLE-REFERENCE",
+				CustomerEmail: "adaeze@example.com",
+				ProgramName:   "30-Day Reset",
+				PlanName:      "Standard",
+				Amount:        2500000,
+				Currency:      models.DefaultCurrency,
+				IssuedAt:      time.Now(),
+			}
+			return orderConfirmationData{
+				BusinessName: config.AppConfig.BusinessName,
+				Invoice:      invoice,
+				AmountPaid:   models.Money{Amount: invoice.Amount, Currency: invoice.Currency}.String(),
+			}
+		},
+	},
+	models.EmailKindMagicLink: {
+		description: "Sent to a customer who asks to log in",
+		subject:     `Your {{.BusinessName}} login link`,
+		html: `<p>Hello{{if .Name}} {{.Name}}{{end}},</p>
+<p>Use the link below to log in to your account. It expires in {{.ExpiresInMinutes}} minutes and can only be used once.</p>
+<p><a href="{{.Link}}">Log in to {{.BusinessName}}</a></p>
+<p>If you did not ask to log in, you can ignore this email.</p>
+<p>Best regards,<br>{{.BusinessName}}</p>
+`,
+		text: `Hello{{if .Name}} {{.Name}}{{end}},
+
+Use the link below to log in to your account. It expires in {{.ExpiresInMinutes}} minutes and can only be used once.
+
+{{.Link}}
+
+If you did not ask to log in, you can ignore this email.
+
+Best regards,
+{{.BusinessName}}
+`,
+		variables: []string{"BusinessName", "Name", "Link", "ExpiresInMinutes"},
+		required:  true,
+		sample: func() interface{} {
+			return magicLinkData{
+				BusinessName:     config.AppConfig.BusinessName,
+				Name:             "Adaeze Okafor",
+				Link:             config.AppConfig.CustomerLoginURL + "?token=sample",
+				ExpiresInMinutes: config.AppConfig.MagicLinkTTLMinutes,
+			}
+		},
+	},
+	models.EmailKindRegistrationAck: {
+		description: "Sent to a customer after they register, acknowledging their details",
+		subject:     `We received your registration for {{.Lead.Program}}`,
+		html: `<p>Hello {{.Lead.FullName}},</p>
+<p>Thank you for registering with {{.BusinessName}}. We have received your details:</p>
+<table cellpadding="4">
+<tr><th align="left">Program</th><td>{{.Lead.Program}}</td></tr>
+<tr><th align="left">Package</th><td>{{.Lead.Package}}</td></tr>
+<tr><th align="left">Phone Number</th><td>{{.Lead.PhoneNumber}}</td></tr>
+</table>
+<p>A member of our team will be in touch shortly.</p>
+<p>Best regards,<br>{{.BusinessName}}</p>
+`,
+		text: `Hello {{.Lead.FullName}},
+
+Thank you for registering with {{.BusinessName}}. We have received your details:
+
+Program: {{.Lead.Program}}
+Package: {{.Lead.Package}}
+Phone Number: {{.Lead.PhoneNumber}}
+
+A member of our team will be in touch shortly.
+
+Best regards,
+{{.BusinessName}}
+`,
+		variables: []string{
+			"BusinessName", "Lead.FullName", "Lead.Email", "Lead.PhoneNumber", "Lead.Nationality",
+			"Lead.Program", "Lead.Package", "Lead.CreatedAt",
+		},
+		sample: func() interface{} {
+			return registrationAckData{
+				BusinessName: config.AppConfig.BusinessName,
+				Lead: models.Lead{
+					FullName:    "Adaeze Okafor",
+					Email:       "adaeze@example.com",
+					PhoneNumber: "+2348012345678",
+					Nationality: "Nigeria",
+					CountryCode: "NG",
+					Program:     "30-Day Reset",
+					Package:     "Standard",
+					CreatedAt:   time.Now(),
+				},
+			}
+		},
+	},
+	models.EmailKindWelcome: {
+		description: "Sent to a customer when their first payment for a program is confirmed",
+		subject:     `Welcome to {{.Program.Name}}`,
+		html: `<p>Hello{{if .Name}} {{.Name}}{{end}},</p>
+<p>Welcome to <strong>{{.Program.Name}}</strong>{{if .PlanName}} ({{.PlanName}}){{end}}!</p>
+{{if .Program.ShortDescription}}<p>{{.Program.ShortDescription}}</p>
+{{end}}{{if .Features}}<p>Your plan includes:</p>
+<ul>
+{{range .Features}}<li>{{.}}</li>
+{{end}}</ul>
+{{end}}<p>Your program is ready in your account. Log in with your email address to get started:</p>
+<p><a href="{{.PortalURL}}">Go to my account</a></p>
+<p>Best regards,<br>{{.BusinessName}}</p>
+`,
+		text: `Hello{{if .Name}} {{.Name}}{{end}},
+
+Welcome to {{.Program.Name}}{{if .PlanName}} ({{.PlanName}}){{end}}!
+{{if .Program.ShortDescription}}
+{{.Program.ShortDescription}}
+{{end}}{{if .Features}}
+Your plan includes:
+{{range .Features}}- {{.}}
+{{end}}{{end}}
+Your program is ready in your account. Log in with your email address to get started:
+
+{{.PortalURL}}
+
+Best regards,
+{{.BusinessName}}
+`,
+		variables: []string{
+			"BusinessName", "Name", "Program.Name", "Program.ShortDescription", "Program.MainImageURL",
+			"PlanName", "Features", "PortalURL",
+		},
+		sample: func() interface{} {
+			return welcomeData{
+				BusinessName: config.AppConfig.BusinessName,
+				Name:         "Adaeze Okafor",
+				Program: models.ProgramSummary{
+					ID:               1,
+					Name:             "30-Day Reset",
+					ShortDescription: "A month of whole-food, plant-based meals and daily guidance.",
+				},
+				PlanName:  "Standard",
+				Features:  []string{"Weekly meal plans", "Shopping lists", "Daily check-ins"},
+				PortalURL: config.AppConfig.CustomerLoginURL,
+			}
+		},
+	},
+	models.EmailKindNewsletterConfirmation: {
+		description: "Sent to someone who signs up for the newsletter, to confirm their address",
+		subject:     `Confirm your {{.BusinessName}} newsletter subscription`,
+		html: `<p>Hello,</p>
+<p>Thank you for signing up for the {{.BusinessName}} newsletter. Please confirm your subscription:</p>
+<p><a href="{{.Link}}">Confirm my subscription</a></p>
+<p>The link expires in {{.ExpiresInHours}} hours. If you did not sign up, you can ignore this email and you will not hear from us again.</p>
+<p>Best regards,<br>{{.BusinessName}}</p>
+`,
+		text: `Hello,
+
+Thank you for signing up for the {{.BusinessName}} newsletter. Please confirm your subscription:
+
+{{.Link}}
+
+The link expires in {{.ExpiresInHours}} hours. If you did not sign up, you can ignore this email and you will not hear from us again.
+
+Best regards,
+{{.BusinessName}}
+`,
+		variables: []string{"BusinessName", "Link", "ExpiresInHours"},
+		required:  true,
+		sample: func() interface{} {
+			return newsletterConfirmationData{
+				BusinessName:   config.AppConfig.BusinessName,
+				Link:           config.AppConfig.NewsletterConfirmURL + "?token=sample",
+				ExpiresInHours: config.AppConfig.NewsletterConfirmTTLHours,
+			}
+		},
+	},
+	models.EmailKindPaymentNotification: {
+		description: "Sent to the payment notification recipients when a payment is confirmed",
+		subject:     `Payment received: {{.Amount}} for {{.Order.ProgramName}}`,
+		html: `<p>A payment has been received for {{.BusinessName}}:</p>
+<table cellpadding="4">
+<tr><th align="left">Customer</th><td>{{.Order.CustomerEmail}}</td></tr>
+<tr><th align="left">Program</th><td>{{.Order.ProgramName}}</td></tr>
+<tr><th align="left">Plan</th><td>{{.Order.PlanName}}</td></tr>
+<tr><th align="left">Amount</th><td>{{.Amount}}</td></tr>
+<tr><th align="left">Reference</th><td>{{.Order.Reference}}</td></tr>
+</table>
+<p>Best regards,<br>{{.BusinessName}} System</p>
+`,
+		text: `A payment has been received for {{.BusinessName}}:
+
+Customer: {{.Order.CustomerEmail}}
+Program: {{.Order.ProgramName}}
+Plan: {{.Order.PlanName}}
+Amount: {{.Amount}}
+Reference: {{.Order.Reference}}
+
+Best regards,
+{{.BusinessName}} System
+`,
+		variables: []string{
+			"BusinessName", "Amount", "Order.Reference", "Order.CustomerEmail", "Order.ProgramName",
+			"Order.PlanName", "Order.PaidAt",
+		},
+		sample: func() interface{} {
+			paidAt := time.Now()
+			order := models.Order{
+				Reference:     "PB-SAMPLE-REFERENCE",
+				Amount:        2500000,
+				Currency:      models.DefaultCurrency,
+				CustomerEmail: "adaeze@example.com",
+				ProgramName:   "30-Day Reset",
+				PlanName:      "Standard",
+				Status:        models.OrderStatusPaid,
+				PaidAt:        &paidAt,
+			}
+			return paymentNotificationData{
+				BusinessName: config.AppConfig.BusinessName,
+				Order:        order,
+				Amount:       models.Money{Amount: order.Amount, Currency: order.Currency}.String(),
+			}
+		},
+	},
+	models.EmailKindRefundNotification: {
+		description: "Sent to the refund notification recipients when a refund is processed",
+		subject:     `Refund processed: {{.Amount}} for {{.Order.ProgramName}}`,
+		html: `<p>A refund has been processed for {{.BusinessName}}:</p>
+<table cellpadding="4">
+<tr><th align="left">Customer</th><td>{{.Order.CustomerEmail}}</td></tr>
+<tr><th align="left">Program</th><td>{{.Order.ProgramName}}</td></tr>
+<tr><th align="left">Amount refunded</th><td>{{.Amount}}</td></tr>
+<tr><th align="left">Reference</th><td>{{.Order.Reference}}</td></tr>
+{{if .Refund.Reason}}<tr><th align="left">Reason</th><td>{{.Refund.Reason}}</td></tr>
+{{end}}</table>
+<p>Best regards,<br>{{.BusinessName}} System</p>
+`,
+		text: `A refund has been processed for {{.BusinessName}}:
+
+Customer: {{.Order.CustomerEmail}}
+Program: {{.Order.ProgramName}}
+Amount refunded: {{.Amount}}
+Reference: {{.Order.Reference}}
+{{if .Refund.Reason}}Reason: {{.Refund.Reason}}
+{{end}}
+Best regards,
+{{.BusinessName}} System
+`,
+		variables: []string{
+			"BusinessName", "Amount", "Refund.Reason", "Refund.Status", "Order.Reference", "Order.CustomerEmail",
+			"Order.ProgramName", "Order.PlanName",
+		},
+		sample: func() interface{} {
+			refund := models.Refund{
+				Reference: "PB-SAMPLE-REFERENCE",
+				Amount:    1250000,
+				Currency:  models.DefaultCurrency,
+				Status:    models.RefundStatusProcessed,
+				Reason:    "Customer request",
+			}
+			return refundNotificationData{
+				BusinessName: config.AppConfig.BusinessName,
+				Order: models.Order{
+					Reference:     "PB-SAMPLE-REFERENCE",
+					Amount:        2500000,
+					Currency:      models.DefaultCurrency,
+					CustomerEmail: "adaeze@example.com",
+					ProgramName:   "30-Day Reset",
+					PlanName:      "Standard",
+				},
+				Refund: refund,
+				Amount: models.Money{Amount: refund.Amount, Currency: refund.Currency}.String(),
+			}
+		},
+	},
+	models.EmailKindTestimonialNotification: {
+		description: "Sent to the testimonial notification recipients when a testimonial is added",
+		subject:     `New testimonial from {{.Testimonial.Name}}`,
+		html: `<p>A new testimonial has been added to {{.BusinessName}}:</p>
+<table cellpadding="4">
+<tr><th align="left">Name</th><td>{{.Testimonial.Name}}</td></tr>
+<tr><th align="left">Location</th><td>{{.Testimonial.Location}}</td></tr>
+{{if .Email}}<tr><th align="left">Email</th><td>{{.Email}}</td></tr>
+{{end}}</table>
+<blockquote>{{.Testimonial.Review}}</blockquote>
+<p>Best regards,<br>{{.BusinessName}} System</p>
+`,
+		text: `A new testimonial has been added to {{.BusinessName}}:
+
+Name: {{.Testimonial.Name}}
+Location: {{.Testimonial.Location}}
+{{if .Email}}Email: {{.Email}}
+{{end}}
+{{.Testimonial.Review}}
+
+Best regards,
+{{.BusinessName}} System
+`,
+		variables: []string{"BusinessName", "Email", "Testimonial.Name", "Testimonial.Location", "Testimonial.Review"},
+		sample: func() interface{} {
+			return testimonialNotificationData{
+				BusinessName: config.AppConfig.BusinessName,
+				Testimonial: models.Testimonial{
+					Name:     "Adaeze Okafor",
+					Location: "Lagos",
+					Review:   "The meal plans were easy to follow and I have more energy than ever.",
+				},
+				Email: "adaeze@example.com",
+			}
+		},
+	},
+}
+
+// renderedEmail is a template executed for one message
+type renderedEmail struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// renderEmailTemplate executes a template's subject, HTML and plain-text
+// parts. The subject is folded onto one line since it becomes a header.
+func renderEmailTemplate(subject, html, text string, data interface{}) (*renderedEmail, error) {
+	var rendered renderedEmail
+
+	subjectTmpl, err := texttemplate.New("subject").Parse(subject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: subject: %v", ErrInvalidTemplate, err)
+	}
+	htmlTmpl, err := htmltemplate.New("html_body").Parse(html)
+	if err != nil {
+		return nil, fmt.Errorf("%w: html_body: %v", ErrInvalidTemplate, err)
+	}
+	textTmpl, err := texttemplate.New("text_body").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: text_body: %v", ErrInvalidTemplate, err)
+	}
+
+	var buf strings.Builder
+	if err := subjectTmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("%w: subject: %v", ErrInvalidTemplate, err)
+	}
+	rendered.Subject = strings.Join(strings.Fields(buf.String()), " ")
+
+	buf.Reset()
+	if err := htmlTmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("%w: html_body: %v", ErrInvalidTemplate, err)
+	}
+	rendered.HTML = buf.String()
+
+	buf.Reset()
+	if err := textTmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("%w: text_body: %v", ErrInvalidTemplate, err)
+	}
+	rendered.Text = buf.String()
+
+	return &rendered, nil
+}
+
+// renderCurrentTemplate executes a template for an email kind. An edited
+// template that fails with real data falls back to the built-in one, so the
+// email still goes out.
+func renderCurrentTemplate(tmpl *models.EmailTemplate, data interface{}) (*renderedEmail, error) {
+	rendered, err := renderEmailTemplate(tmpl.Subject, tmpl.HTMLBody, tmpl.TextBody, data)
+	if err != nil && !tmpl.IsDefault {
+		log.Printf("Email template %s failed, using the default: %v", tmpl.Key, err)
+		def := defaultEmailTemplates[tmpl.Key]
+		return renderEmailTemplate(def.subject, def.html, def.text, data)
+	}
+	return rendered, err
+}
+
+// GetTemplates lists every email template, edited or default
+func (s *EmailService) GetTemplates() ([]models.EmailTemplate, error) {
+	templates := make([]models.EmailTemplate, 0, len(emailTemplateKeys))
+	for _, key := range emailTemplateKeys {
+		tmpl, err := s.GetTemplate(key)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *tmpl)
+	}
+	return templates, nil
+}
+
+// GetTemplate retrieves the current template for an email kind
+func (s *EmailService) GetTemplate(key string) (*models.EmailTemplate, error) {
+	def, ok := defaultEmailTemplates[key]
+	if !ok {
+		return nil, ErrTemplateNotFound
+	}
+
+	tmpl := models.EmailTemplate{
+		Key:         key,
+		Description: def.description,
+		Subject:     def.subject,
+		HTMLBody:    def.html,
+		TextBody:    def.text,
+		Variables:   def.variables,
+		IsDefault:   true,
+		Enabled:     true,
+		Required:    def.required,
+	}
+
+	err := s.DB.QueryRow(`
+		SELECT subject, html_body, text_body, updated_by, updated_at FROM email_templates WHERE key = $1
+	`, key).Scan(&tmpl.Subject, &tmpl.HTMLBody, &tmpl.TextBody, &tmpl.UpdatedBy, &tmpl.UpdatedAt)
+	switch {
+	case err == nil:
+		tmpl.IsDefault = false
+	case err != sql.ErrNoRows:
+		return nil, err
+	}
+
+	err = s.DB.QueryRow("SELECT enabled FROM email_settings WHERE key = $1", key).Scan(&tmpl.Enabled)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if def.required {
+		tmpl.Enabled = true
+	}
+
+	return &tmpl, nil
+}
+
+// UpdateTemplate saves an edited template after checking that it renders
+func (s *EmailService) UpdateTemplate(key string, req models.EmailTemplateRequest, adminID int) (*models.EmailTemplate, error) {
+	def, ok := defaultEmailTemplates[key]
+	if !ok {
+		return nil, ErrTemplateNotFound
+	}
+
+	if _, err := renderEmailTemplate(req.Subject, req.HTMLBody, req.TextBody, def.sample()); err != nil {
+		return nil, err
+	}
+
+	_, err := s.DB.Exec(`
+		INSERT INTO email_templates (key, subject, html_body, text_body, updated_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (key) DO UPDATE SET
+			subject = EXCLUDED.subject,
+			html_body = EXCLUDED.html_body,
+			text_body = EXCLUDED.text_body,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = NOW()
+	`, key, req.Subject, req.HTMLBody, req.TextBody, nullableInt(adminID))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetTemplate(key)
+}
+
+// SetTemplateEnabled switches a kind of email on or off
+func (s *EmailService) SetTemplateEnabled(key string, enabled bool, adminID int) (*models.EmailTemplate, error) {
+	def, ok := defaultEmailTemplates[key]
+	if !ok {
+		return nil, ErrTemplateNotFound
+	}
+	if def.required && !enabled {
+		return nil, fmt.Errorf("%w: %s email cannot be disabled", ErrInvalidTemplate, key)
+	}
+
+	_, err := s.DB.Exec(`
+		INSERT INTO email_settings (key, enabled, updated_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = NOW()
+	`, key, enabled, nullableInt(adminID))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetTemplate(key)
+}
+
+// ResetTemplate discards an edited template, restoring the built-in one
+func (s *EmailService) ResetTemplate(key string) (*models.EmailTemplate, error) {
+	if _, ok := defaultEmailTemplates[key]; !ok {
+		return nil, ErrTemplateNotFound
+	}
+
+	if _, err := s.DB.Exec("DELETE FROM email_templates WHERE key = $1", key); err != nil {
+		return nil, err
+	}
+
+	return s.GetTemplate(key)
+}
+
+// PreviewTemplate renders a template with sample data, applying any unsaved
+// changes in the request
+func (s *EmailService) PreviewTemplate(key string, req models.EmailTemplatePreviewRequest) (*models.EmailTemplatePreview, error) {
+	tmpl, err := s.GetTemplate(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Subject != "" {
+		tmpl.Subject = req.Subject
+	}
+	if req.HTMLBody != "" {
+		tmpl.HTMLBody = req.HTMLBody
+	}
+	if req.TextBody != "" {
+		tmpl.TextBody = req.TextBody
+	}
+
+	rendered, err := renderEmailTemplate(tmpl.Subject, tmpl.HTMLBody, tmpl.TextBody, defaultEmailTemplates[key].sample())
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.EmailTemplatePreview{
+		Subject:  rendered.Subject,
+		HTMLBody: rendered.HTML,
+		TextBody: rendered.Text,
+	}, nil
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"database/sql/driver"
+	"errors"
+	"plantbased-backend/config"
+	"plantbased-backend/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultEmailTemplatesRender(t *testing.T) {
+	config.AppConfig = &config.Config{BusinessName: "PlantBased"}
+
+	for _, key := range emailTemplateKeys {
+		def, ok := defaultEmailTemplates[key]
+		if !ok {
+			t.Errorf("no default template for %s", key)
+			continue
+		}
+		rendered, err := renderEmailTemplate(def.subject, def.html, def.text, def.sample())
+		if err != nil {
+			t.Errorf("%s: render error = %v", key, err)
+			continue
+		}
+		if rendered.Subject == "" || strings.ContainsAny(rendered.Subject, "\r\n") {
+			t.Errorf("%s: subject = %q, want one non-empty line", key, rendered.Subject)
+		}
+		if !strings.Contains(rendered.Text, "PlantBased") || !strings.Contains(rendered.HTML, "PlantBased") {
+			t.Errorf("%s: bodies do not mention the business", key)
+		}
+	}
+}
+
+func TestRenderEmailTemplate(t *testing.T) {
+	data := struct{ Name string }{"Ada <b>& co</b>"}
+
+	rendered, err := renderEmailTemplate("Welcome,\n  {{.Name}}", "<p>{{.Name}}</p>", "Hi {{.Name}}", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rendered.Subject != "Welcome, Ada <b>& co</b>" {
+		t.Errorf("Subject = %q", rendered.Subject)
+	}
+	if rendered.HTML != "<p>Ada &lt;b&gt;&amp; co&lt;/b&gt;</p>" {
+		t.Errorf("HTML = %q, want the name escaped", rendered.HTML)
+	}
+	if rendered.Text != "Hi Ada <b>& co</b>" {
+		t.Errorf("Text = %q, want the name as is", rendered.Text)
+	}
+
+	broken := []struct{ subject, html, text string }{
+		{"{{.Name", "<p></p>", ""},
+		{"Hi", "<p>{{.Missing}}</p>", ""},
+		{"Hi", "<p></p>", "{{if}}"},
+	}
+	for _, tt := range broken {
+		if _, err := renderEmailTemplate(tt.subject, tt.html, tt.text, data); !errors.Is(err, ErrInvalidTemplate) {
+			t.Errorf("renderEmailTemplate(%q, %q, %q) error = %v, want ErrInvalidTemplate", tt.subject, tt.html, tt.text, err)
+		}
+	}
+}
+
+func TestUpdateTemplateChecksTemplate(t *testing.T) {
+	config.AppConfig = &config.Config{BusinessName: "PlantBased"}
+	db, fake := newFakeDB(t)
+	s := &EmailService{DB: db}
+
+	if _, err := s.UpdateTemplate("no_such_email", models.EmailTemplateRequest{Subject: "Hi"}, 1); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("UpdateTemplate(unknown) error = %v, want ErrTemplateNotFound", err)
+	}
+
+	req := models.EmailTemplateRequest{Subject: "Hi {{.Lead.Nickname}}", HTMLBody: "<p>Hi</p>", TextBody: "Hi"}
+	if _, err := s.UpdateTemplate(models.EmailKindLeadNotification, req, 1); !errors.Is(err, ErrInvalidTemplate) {
+		t.Errorf("UpdateTemplate(broken) error = %v, want ErrInvalidTemplate", err)
+	}
+	if saved := fake.ran("INSERT INTO email_templates"); len(saved) != 0 {
+		t.Errorf("%d templates saved, want none", len(saved))
+	}
+}
+
+func TestRenderFallsBackToDefault(t *testing.T) {
+	config.AppConfig = &config.Config{BusinessName: "PlantBased"}
+	db, fake := newFakeDB(t)
+	fake.on("FROM email_templates", []string{"subject", "html_body", "text_body", "updated_by", "updated_at"},
+		[]driver.Value{"Edited {{.Lead.FullName}}", "<p>{{.Lead.FullName.Missing}}</p>", "Edited", int64(1), time.Now()},
+	)
+
+	fake.on("FROM email_settings", nil)
+
+	s := &EmailService{DB: db}
+	tmpl, err := s.GetTemplate(models.EmailKindLeadNotification)
+	if err != nil {
+		t.Fatalf("GetTemplate error = %v", err)
+	}
+	if tmpl.IsDefault {
+		t.Fatal("edited template reported as the default")
+	}
+	data := defaultEmailTemplates[models.EmailKindLeadNotification].sample()
+	rendered, err := renderCurrentTemplate(tmpl, data)
+	if err != nil {
+		t.Fatalf("render error = %v", err)
+	}
+	if rendered.Subject != "New Customer Registration: Adaeze Okafor" {
+		t.Errorf("Subject = %q, want the default template's", rendered.Subject)
+	}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDB is a database/sql driver that answers statements from a script, so
+// services can be tested without PostgreSQL. Statements are matched on a
+// substring of their SQL; queries with no match fail, other statements succeed.
+type fakeDB struct {
+	mu         sync.Mutex
+	responses  []fakeResponse
+	statements []fakeStatement
+}
+
+type fakeResponse struct {
+	match   string
+	columns []string
+	rows    [][]driver.Value
+	err     error
+}
+
+// fakeStatement is a statement run against a fakeDB
+type fakeStatement struct {
+	query string
+	args  []driver.Value
+}
+
+// newFakeDB returns a *sql.DB backed by a new fakeDB
+func newFakeDB(t *testing.T) (*sql.DB, *fakeDB) {
+	f := &fakeDB{}
+	db := sql.OpenDB(f)
+	t.Cleanup(func() { db.Close() })
+	return db, f
+}
+
+// on answers statements containing match with the given rows
+func (f *fakeDB) on(match string, columns []string, rows ...[]driver.Value) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses = append(f.responses, fakeResponse{match: match, columns: columns, rows: rows})
+}
+
+// fail answers statements containing match with an error
+func (f *fakeDB) fail(match string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses = append(f.responses, fakeResponse{match: match, err: err})
+}
+
+// ran returns the statements run so far that contain match
+func (f *fakeDB) ran(match string) []fakeStatement {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var matched []fakeStatement
+	for _, s := range f.statements {
+		if strings.Contains(s.query, match) {
+			matched = append(matched, s)
+		}
+	}
+	return matched
+}
+
+func (f *fakeDB) respond(query string, args []driver.NamedValue) (*fakeResponse, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	f.statements = append(f.statements, fakeStatement{query: query, args: values})
+	for i := range f.responses {
+		if strings.Contains(query, f.responses[i].match) {
+			return &f.responses[i], true
+		}
+	}
+	return nil, false
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("fakeDB does not prepare statements")
+}
+func (c fakeConn) Close() error              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	response, ok := c.db.respond(query, args)
+	if !ok {
+		return nil, fmt.Errorf("fakeDB: unexpected query %q", query)
+	}
+	if response.err != nil {
+		return nil, response.err
+	}
+	return &fakeRows{columns: response.columns, rows: response.rows}, nil
+}
+
+func (c fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	response, ok := c.db.respond(query, args)
+	if ok && response.err != nil {
+		return nil, response.err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+	next    int
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"plantbased-backend/config"
+	"plantbased-backend/models"
+	"plantbased-backend/utils"
+	"strings"
+	"time"
+)
+
+const invoiceColumns = `id, invoice_number, order_id, reference, customer_email, program_name, plan_name,
+	subtotal, discount, amount, currency, issued_at, emailed_at`
+
+// InvoiceService issues PDF invoices for paid orders and emails them to
+// customers, along with a welcome for first-time enrollments
+type InvoiceService struct {
+	DB             *sql.DB
+	emailService   *EmailService
+	programService *ProgramService
+}
+
+func NewInvoiceService(db *sql.DB, emailService *EmailService, programService *ProgramService) *InvoiceService {
+	return &InvoiceService{
+		DB:             db,
+		emailService:   emailService,
+		programService: programService,
+	}
+}
+
+func scanInvoice(row rowScanner) (*models.Invoice, error) {
+	var inv models.Invoice
+	err := row.Scan(
+		&inv.ID, &inv.InvoiceNumber, &inv.OrderID, &inv.Reference, &inv.CustomerEmail, &inv.ProgramName, &inv.PlanName,
+		&inv.Subtotal, &inv.Discount, &inv.Amount, &inv.Currency, &inv.IssuedAt, &inv.EmailedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// IssueInvoice creates the invoice for a paid order and emails it to the
+// customer. Orders that already have an invoice, or that are not paid, are
+// left alone, so it is safe to call for redelivered webhooks.
+func (s *InvoiceService) IssueInvoice(order *models.Order) (*models.Invoice, error) {
+	if order.Status != models.OrderStatusPaid {
+		return nil, nil
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Serialize issuing so invoice numbers stay sequential without gaps
+	if _, err := tx.Exec("LOCK TABLE invoices IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return nil, err
+	}
+
+	existing, err := scanInvoice(tx.QueryRow("SELECT "+invoiceColumns+" FROM invoices WHERE order_id = $1", order.ID))
+	if err == nil {
+		return existing, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	var sequence int
+	if err := tx.QueryRow("SELECT COALESCE(MAX(sequence), 0) + 1 FROM invoices").Scan(&sequence); err != nil {
+		return nil, err
+	}
+
+	// Coupon discounts are recorded against the Paystack reference
+	var discount int64
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(discount_amount), 0) FROM coupon_redemptions WHERE reference = $1
+	`, order.Reference).Scan(&discount)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice := models.Invoice{
+		InvoiceNumber: fmt.Sprintf("%s-%06d", config.AppConfig.InvoicePrefix, sequence),
+		OrderID:       order.ID,
+		Reference:     order.Reference,
+		CustomerEmail: order.CustomerEmail,
+		ProgramName:   order.ProgramName,
+		PlanName:      order.PlanName,
+		Subtotal:      order.Amount + discount,
+		Discount:      discount,
+		Amount:        order.Amount,
+		Currency:      order.Currency,
+		IssuedAt:      time.Now(),
+	}
+	if order.PaidAt != nil {
+		invoice.IssuedAt = *order.PaidAt
+	}
+
+	pdf := renderInvoicePDF(invoice)
+
+	err = tx.QueryRow(`
+		INSERT INTO invoices (
+			invoice_number, sequence, order_id, reference, customer_email, program_name, plan_name,
+			subtotal, discount, amount, currency, pdf, issued_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id
+	`, invoice.InvoiceNumber, sequence, invoice.OrderID, invoice.Reference, invoice.CustomerEmail,
+		invoice.ProgramName, invoice.PlanName, invoice.Subtotal, invoice.Discount, invoice.Amount,
+		invoice.Currency, pdf, invoice.IssuedAt).Scan(&invoice.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Queue the confirmation, welcome and payment notification with the
+	// invoice, so redelivered webhooks do not send them again; the outbox
+	// worker sends them
+	if invoice.CustomerEmail != "" {
+		if err := s.emailService.SendOrderConfirmation(tx, invoice, pdf); err != nil {
+			return nil, err
+		}
+		if err := s.queueWelcome(tx, order); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.emailService.SendPaymentNotification(tx, *order); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &invoice, nil
+}
+
+// queueWelcome queues the welcome email for a customer's first paid order for
+// a program. Subscription renewals and repeat purchases are skipped.
+func (s *InvoiceService) queueWelcome(tx *sql.Tx, order *models.Order) error {
+	if order.ProgramID == nil {
+		return nil
+	}
+
+	email := normalizeEmail(order.CustomerEmail)
+
+	var enrolled bool
+	err := tx.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM orders
+			WHERE LOWER(customer_email) = $1 AND program_id = $2 AND id < $3
+				AND status IN (`+models.OrderStatusesGrantingAccess+`)
+		)
+	`, email, *order.ProgramID, order.ID).Scan(&enrolled)
+	if err != nil || enrolled {
+		return err
+	}
+
+	program, err := s.programService.GetProgramByID(*order.ProgramID)
+	if err != nil {
+		// The program may have been deleted since checkout
+		log.Printf("Skipping welcome email for order %d: %v", order.ID, err)
+		return nil
+	}
+
+	var plan *models.ProgramPricingPlan
+	if order.PlanID != nil {
+		plan, _ = s.programService.GetPricingPlanByID(*order.ProgramID, *order.PlanID)
+	}
+
+	// Greet the customer by the name from their latest registration, if any
+	var name string
+	err = tx.QueryRow(`
+		SELECT full_name FROM leads WHERE LOWER(email) = $1 ORDER BY created_at DESC LIMIT 1
+	`, email).Scan(&name)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	return s.emailService.SendWelcome(tx, *order, name, *program, plan)
+}
+
+// GetInvoices retrieves invoices, newest first
+func (s *InvoiceService) GetInvoices() ([]models.Invoice, error) {
+	rows, err := s.DB.Query("SELECT " + invoiceColumns + " FROM invoices ORDER BY sequence DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invoices := []models.Invoice{}
+	for rows.Next() {
+		invoice, err := scanInvoice(rows)
+		if err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, *invoice)
+	}
+
+	return invoices, rows.Err()
+}
+
+// GetInvoicePDF retrieves the invoice number and PDF of an invoice
+func (s *InvoiceService) GetInvoicePDF(id int) (string, []byte, error) {
+	var number string
+	var pdf []byte
+	err := s.DB.QueryRow("SELECT invoice_number, pdf FROM invoices WHERE id = $1", id).Scan(&number, &pdf)
+	if err == sql.ErrNoRows {
+		return "", nil, errors.New("invoice not found")
+	}
+	return number, pdf, err
+}
+
+// renderInvoicePDF lays out an invoice on a single A4 page
+func renderInvoicePDF(invoice models.Invoice) []byte {
+	cfg := config.AppConfig
+	pdf := utils.NewPDF()
+	money := func(amount int64) string {
+		return models.Money{Amount: amount, Currency: invoice.Currency}.CodeString()
+	}
+
+	// Business details
+	pdf.Text(50, 70, 20, true, cfg.BusinessName)
+	y := 90.0
+	for _, line := range strings.Split(cfg.BusinessAddress, "|") {
+		if line = strings.TrimSpace(line); line != "" {
+			pdf.Text(50, y, 10, false, line)
+			y += 14
+		}
+	}
+	for _, line := range []string{cfg.BusinessEmail, cfg.BusinessPhone} {
+		if line != "" {
+			pdf.Text(50, y, 10, false, line)
+			y += 14
+		}
+	}
+	if cfg.BusinessTaxID != "" {
+		pdf.Text(50, y, 10, false, "Tax ID: "+cfg.BusinessTaxID)
+	}
+
+	// Invoice details
+	pdf.Text(380, 70, 20, true, "INVOICE")
+	pdf.Text(380, 90, 10, false, "Invoice no: "+invoice.InvoiceNumber)
+	pdf.Text(380, 104, 10, false, "Date: "+invoice.IssuedAt.Format("2 January 2006"))
+	pdf.Text(380, 118, 10, false, "Reference: "+invoice.Reference)
+	pdf.Text(380, 132, 10, true, "Status: PAID")
+
+	// Customer
+	pdf.Text(50, 190, 10, true, "Billed to")
+	pdf.Text(50, 204, 10, false, invoice.CustomerEmail)
+
+	// Line items
+	pdf.Text(50, 250, 10, true, "Description")
+	pdf.Text(420, 250, 10, true, "Amount")
+	pdf.Line(50, 258, 545, 258, 0.8)
+
+	description := invoice.ProgramName
+	if invoice.PlanName != "" {
+		description += " - " + invoice.PlanName
+	}
+	pdf.Text(50, 276, 10, false, description)
+	pdf.Text(420, 276, 10, false, money(invoice.Subtotal))
+	pdf.Line(50, 288, 545, 288, 0.5)
+
+	y = 306
+	pdf.Text(300, y, 10, false, "Subtotal")
+	pdf.Text(420, y, 10, false, money(invoice.Subtotal))
+	if invoice.Discount > 0 {
+		y += 16
+		pdf.Text(300, y, 10, false, "Discount")
+		pdf.Text(420, y, 10, false, "-"+money(invoice.Discount))
+	}
+	y += 20
+	pdf.Text(300, y, 11, true, "Total paid")
+	pdf.Text(420, y, 11, true, money(invoice.Amount))
+
+	pdf.Text(50, 780, 9, false, "Thank you for choosing "+cfg.BusinessName+".")
+
+	return pdf.Bytes()
+}
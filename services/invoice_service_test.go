@@ -0,0 +1,45 @@
+package services
+
+import (
+	"bytes"
+	"plantbased-backend/config"
+	"plantbased-backend/models"
+	"testing"
+	"time"
+)
+
+func TestRenderInvoicePDF(t *testing.T) {
+	config.AppConfig = &config.Config{BusinessName: "PlantBased", BusinessAddress: "1 Garden Road| Lagos"}
+
+	pdf := renderInvoicePDF(models.Invoice{
+		InvoiceNumber: "INV-2026-00042",
+		Reference:     "PB-123",
+		CustomerEmail: "ada@example.com",
+		ProgramName:   "Gut Reset",
+		PlanName:      "Full",
+		Subtotal:      2500000,
+		Discount:      250000,
+		Amount:        2250000,
+		Currency:      "NGN",
+		IssuedAt:      time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC),
+	})
+
+	for _, want := range []string{
+		"Invoice no: INV-2026-00042", "Date: 1 March 2026", "Gut Reset - Full", "(Lagos)",
+		"(NGN 25,000.00)", "(-NGN 2,500.00)", "(NGN 22,500.00)",
+	} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("invoice PDF does not contain %q", want)
+		}
+	}
+}
+
+func TestIssueInvoiceSkipsUnpaidOrders(t *testing.T) {
+	s := &InvoiceService{}
+	for _, status := range []string{models.OrderStatusPending, models.OrderStatusAmountMismatch} {
+		invoice, err := s.IssueInvoice(&models.Order{ID: 1, Status: status})
+		if invoice != nil || err != nil {
+			t.Errorf("IssueInvoice for a %s order = %v, %v; want nothing issued", status, invoice, err)
+		}
+	}
+}
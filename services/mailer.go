@@ -0,0 +1,197 @@
+package services
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"plantbased-backend/config"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Mailer delivers raw RFC 5322 messages. It is an interface so SMTP can be
+// swapped for a file or in-memory backend locally and in tests.
+type Mailer interface {
+	Send(from string, to []string, message []byte) error
+}
+
+// NewMailer returns the mailer selected by MAILER. An unknown value is an
+// error rather than a silent fallback to SMTP.
+func NewMailer(cfg *config.Config) (Mailer, error) {
+	switch cfg.Mailer {
+	case "smtp", "":
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPTLSMode), nil
+	case "file":
+		return NewFileMailer(cfg.MailDir), nil
+	case "memory":
+		return NewMemoryMailer(), nil
+	}
+	return nil, fmt.Errorf("unknown MAILER %q: must be smtp, file or memory", cfg.Mailer)
+}
+
+// SMTP TLS modes
+const (
+	SMTPTLSModeStartTLS = "starttls"
+	SMTPTLSModeImplicit = "tls"
+	SMTPTLSModeNone     = "none"
+)
+
+// SMTPMailer sends mail through an SMTP server
+type SMTPMailer struct {
+	host     string
+	port     int
+	username string
+	password string
+	tlsMode  string
+	timeout  time.Duration
+}
+
+func NewSMTPMailer(host string, port int, username, password, tlsMode string) *SMTPMailer {
+	return &SMTPMailer{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		tlsMode:  tlsMode,
+		timeout:  30 * time.Second,
+	}
+}
+
+func (m *SMTPMailer) Send(from string, to []string, message []byte) error {
+	if len(to) == 0 {
+		return errors.New("no recipients")
+	}
+
+	addr := net.JoinHostPort(m.host, strconv.Itoa(m.port))
+	tlsConfig := &tls.Config{ServerName: m.host}
+
+	var conn net.Conn
+	var err error
+	if m.tlsMode == SMTPTLSModeImplicit {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: m.timeout}, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, m.timeout)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(m.timeout))
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if m.tlsMode == SMTPTLSModeStartTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+
+	if m.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message); err != nil {
+		writer.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// FileMailer writes each message to a .eml file, for local development. The
+// files open in any mail client.
+type FileMailer struct {
+	dir string
+
+	mu    sync.Mutex
+	count int
+}
+
+func NewFileMailer(dir string) *FileMailer {
+	return &FileMailer{dir: dir}
+}
+
+func (m *FileMailer) Send(from string, to []string, message []byte) error {
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.count++
+	name := fmt.Sprintf("%s-%04d.eml", time.Now().Format("20060102-150405"), m.count)
+	m.mu.Unlock()
+
+	return os.WriteFile(filepath.Join(m.dir, name), message, 0o644)
+}
+
+// SentMessage is a message captured by the MemoryMailer
+type SentMessage struct {
+	From    string
+	To      []string
+	Message []byte
+}
+
+// MemoryMailer keeps sent messages in memory, for tests
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []SentMessage
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(from string, to []string, message []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, SentMessage{
+		From:    from,
+		To:      append([]string(nil), to...),
+		Message: append([]byte(nil), message...),
+	})
+	return nil
+}
+
+// Messages returns the messages sent so far
+func (m *MemoryMailer) Messages() []SentMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]SentMessage(nil), m.messages...)
+}
+
+// Reset forgets the messages sent so far
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = nil
+}
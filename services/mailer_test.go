@@ -0,0 +1,90 @@
+package services
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"plantbased-backend/config"
+	"reflect"
+	"testing"
+)
+
+func TestNewMailer(t *testing.T) {
+	mailers := []struct {
+		name string
+		want Mailer
+	}{
+		{"", &SMTPMailer{}},
+		{"smtp", &SMTPMailer{}},
+		{"file", &FileMailer{}},
+		{"memory", &MemoryMailer{}},
+	}
+	for _, tt := range mailers {
+		mailer, err := NewMailer(&config.Config{Mailer: tt.name, MailDir: t.TempDir()})
+		if err != nil {
+			t.Errorf("NewMailer(%q) error = %v", tt.name, err)
+			continue
+		}
+		if reflect.TypeOf(mailer) != reflect.TypeOf(tt.want) {
+			t.Errorf("NewMailer(%q) = %T, want %T", tt.name, mailer, tt.want)
+		}
+	}
+
+	if mailer, err := NewMailer(&config.Config{Mailer: "fiel"}); err == nil {
+		t.Errorf("NewMailer(%q) = %T, want an error", "fiel", mailer)
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	mailer := NewFileMailer(dir)
+
+	for _, message := range []string{"Subject: one\r\n\r\nFirst", "Subject: two\r\n\r\nSecond"} {
+		if err := mailer.Send("hello@example.com", []string{"ada@example.com"}, []byte(message)); err != nil {
+			t.Fatalf("Send error = %v", err)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 2 {
+		t.Fatalf("files = %v (%v), want 2 .eml files", files, err)
+	}
+	content, err := os.ReadFile(files[0])
+	if err != nil || !bytes.HasPrefix(content, []byte("Subject: one")) {
+		t.Errorf("first file = %q (%v)", content, err)
+	}
+}
+
+func TestMemoryMailer(t *testing.T) {
+	mailer := NewMemoryMailer()
+	to := []string{"ada@example.com"}
+	message := []byte("Subject: hi\r\n\r\nHello")
+
+	if err := mailer.Send("hello@example.com", to, message); err != nil {
+		t.Fatalf("Send error = %v", err)
+	}
+
+	// Later changes by the caller must not alter what was sent
+	to[0] = "changed@example.com"
+	message[0] = 'X'
+
+	sent := mailer.Messages()
+	if len(sent) != 1 {
+		t.Fatalf("%d messages, want 1", len(sent))
+	}
+	if sent[0].From != "hello@example.com" || sent[0].To[0] != "ada@example.com" || string(sent[0].Message) != "Subject: hi\r\n\r\nHello" {
+		t.Errorf("sent = %+v", sent[0])
+	}
+
+	mailer.Reset()
+	if sent := mailer.Messages(); len(sent) != 0 {
+		t.Errorf("%d messages after Reset, want 0", len(sent))
+	}
+}
+
+func TestSMTPMailerRequiresRecipients(t *testing.T) {
+	mailer := NewSMTPMailer("localhost", 25, "", "", SMTPTLSModeNone)
+	if err := mailer.Send("hello@example.com", nil, []byte("Subject: hi\r\n\r\nHello")); err == nil {
+		t.Error("Send without recipients succeeded")
+	}
+}
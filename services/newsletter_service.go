@@ -0,0 +1,349 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"net/url"
+	"plantbased-backend/config"
+	"plantbased-backend/models"
+	"strings"
+	"time"
+)
+
+// Newsletter errors
+var (
+	ErrInvalidSubscription   = errors.New("invalid newsletter subscription")
+	ErrInvalidNewsletterLink = errors.New("invalid or expired newsletter link")
+	ErrInvalidNewsletter     = errors.New("invalid newsletter")
+)
+
+const newsletterSubscriberColumns = `id, email, status, program_ids, confirmed_at, unsubscribed_at, created_at, updated_at`
+
+// newsletterResendInterval is the least time between confirmation emails to
+// one address, so the sign-up form cannot be used to flood an inbox
+const newsletterResendInterval = 5 * time.Minute
+
+// newsletterData is available to newsletter issue templates
+type newsletterData struct {
+	BusinessName string
+	Email        string
+}
+
+// NewsletterService manages newsletter sign-ups with double opt-in, signed
+// unsubscribe links and sending issues to segments of subscribers
+type NewsletterService struct {
+	DB             *sql.DB
+	emailService   *EmailService
+	programService *ProgramService
+}
+
+func NewNewsletterService(db *sql.DB, emailService *EmailService, programService *ProgramService) *NewsletterService {
+	return &NewsletterService{
+		DB:             db,
+		emailService:   emailService,
+		programService: programService,
+	}
+}
+
+func scanNewsletterSubscriber(row rowScanner) (*models.NewsletterSubscriber, error) {
+	var sub models.NewsletterSubscriber
+	var programIDsJSON []byte
+	err := row.Scan(
+		&sub.ID, &sub.Email, &sub.Status, &programIDsJSON, &sub.ConfirmedAt, &sub.UnsubscribedAt,
+		&sub.CreatedAt, &sub.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sub.ProgramIDs = []int{}
+	json.Unmarshal(programIDsJSON, &sub.ProgramIDs)
+	return &sub, nil
+}
+
+// newsletterUnsubscribeToken signs an email address for unsubscribe links.
+// Links never expire, so they keep working in old issues.
+func newsletterUnsubscribeToken(email string) string {
+	mac := hmac.New(sha256.New, []byte(config.AppConfig.NewsletterSecret))
+	mac.Write([]byte("newsletter-unsubscribe:" + email))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// newsletterUnsubscribeLinks returns the one-click link for the
+// List-Unsubscribe header, which posts straight to the API, and the link shown
+// in the email, which opens the unsubscribe page on the site
+func newsletterUnsubscribeLinks(email string) (oneClick, page string) {
+	query := url.Values{
+		"email": {email},
+		"token": {newsletterUnsubscribeToken(email)},
+	}.Encode()
+	oneClick = strings.TrimRight(config.AppConfig.APIBaseURL, "/") + "/api/v1/newsletter/unsubscribe?" + query
+	page = config.AppConfig.NewsletterUnsubscribeURL + "?" + query
+	return oneClick, page
+}
+
+// validatePrograms checks that the programs of interest exist and drops duplicates
+func (s *NewsletterService) validatePrograms(programIDs []int) ([]int, error) {
+	valid := []int{}
+	seen := map[int]bool{}
+	for _, programID := range programIDs {
+		if seen[programID] {
+			continue
+		}
+		if _, err := s.programService.GetProgramByID(programID); err != nil {
+			return nil, fmt.Errorf("%w: program %d does not exist", ErrInvalidSubscription, programID)
+		}
+		seen[programID] = true
+		valid = append(valid, programID)
+	}
+	return valid, nil
+}
+
+// Subscribe records a newsletter sign-up and emails a confirmation link.
+// Addresses that are already subscribed keep their current interests until
+// the new ones are confirmed from the email, so nobody can change someone
+// else's subscription. Addresses sent a confirmation moments ago are skipped
+// without an error, and the response is the same either way, so the form
+// cannot be used to check who subscribes.
+func (s *NewsletterService) Subscribe(req models.NewsletterSubscribeRequest) error {
+	email := normalizeEmail(req.Email)
+	if !isValidEmail(email) {
+		return fmt.Errorf("%w: email must be a valid email address", ErrInvalidSubscription)
+	}
+
+	programIDs, err := s.validatePrograms(req.ProgramIDs)
+	if err != nil {
+		return err
+	}
+	programIDsJSON, _ := json.Marshal(programIDs)
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	ttl := time.Duration(config.AppConfig.NewsletterConfirmTTLHours) * time.Hour
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO newsletter_subscribers (email, program_ids, confirm_token_hash, confirm_expires_at, confirmation_sent_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (email) DO UPDATE SET
+			status = CASE WHEN newsletter_subscribers.status = $6 THEN $6 ELSE $5 END,
+			program_ids = CASE WHEN newsletter_subscribers.status = $6
+				THEN newsletter_subscribers.program_ids ELSE EXCLUDED.program_ids END,
+			pending_program_ids = CASE WHEN newsletter_subscribers.status = $6 THEN EXCLUDED.program_ids END,
+			confirm_token_hash = EXCLUDED.confirm_token_hash,
+			confirm_expires_at = EXCLUDED.confirm_expires_at,
+			confirmation_sent_at = NOW(),
+			updated_at = NOW()
+		WHERE newsletter_subscribers.confirmation_sent_at IS NULL OR newsletter_subscribers.confirmation_sent_at < $7
+		RETURNING id
+	`, email, string(programIDsJSON), hashLoginToken(token), time.Now().Add(ttl),
+		models.NewsletterStatusPending, models.NewsletterStatusSubscribed,
+		time.Now().Add(-newsletterResendInterval)).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	link := config.AppConfig.NewsletterConfirmURL + "?token=" + url.QueryEscape(token)
+	if err := s.emailService.SendNewsletterConfirmation(tx, id, email, link, ttl); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Confirm completes a sign-up, or a subscriber's change of interests, with
+// the token from its confirmation email. Each link works once.
+func (s *NewsletterService) Confirm(token string) (*models.NewsletterSubscriber, error) {
+	sub, err := scanNewsletterSubscriber(s.DB.QueryRow(`
+		UPDATE newsletter_subscribers
+		SET confirmed_at = CASE WHEN status = $1 THEN confirmed_at ELSE NOW() END,
+			status = $1, unsubscribed_at = NULL,
+			program_ids = COALESCE(pending_program_ids, program_ids), pending_program_ids = NULL,
+			confirm_token_hash = NULL, confirm_expires_at = NULL, updated_at = NOW()
+		WHERE confirm_token_hash = $2 AND confirm_expires_at > NOW() AND status IN ($1, $3)
+		RETURNING `+newsletterSubscriberColumns,
+		models.NewsletterStatusSubscribed, hashLoginToken(token), models.NewsletterStatusPending,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidNewsletterLink
+	}
+	return sub, err
+}
+
+// Unsubscribe removes an address from the newsletter using a signed link.
+// Unsubscribing twice is not an error.
+func (s *NewsletterService) Unsubscribe(email, token string) error {
+	email = normalizeEmail(email)
+	if !hmac.Equal([]byte(token), []byte(newsletterUnsubscribeToken(email))) {
+		return ErrInvalidNewsletterLink
+	}
+
+	_, err := s.DB.Exec(`
+		UPDATE newsletter_subscribers
+		SET status = $1, unsubscribed_at = NOW(), pending_program_ids = NULL,
+			confirm_token_hash = NULL, confirm_expires_at = NULL, updated_at = NOW()
+		WHERE email = $2 AND status <> $1
+	`, models.NewsletterStatusUnsubscribed, email)
+	return err
+}
+
+// segmentConditions returns the WHERE clause and arguments selecting a
+// segment of subscribers
+func segmentConditions(filter models.NewsletterFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+	if filter.ProgramID != 0 {
+		addCondition("program_ids @> $%d::jsonb", fmt.Sprintf("[%d]", filter.ProgramID))
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// GetSubscribers retrieves the subscribers in a segment, newest first
+func (s *NewsletterService) GetSubscribers(filter models.NewsletterFilter) ([]models.NewsletterSubscriber, error) {
+	where, args := segmentConditions(filter)
+	rows, err := s.DB.Query("SELECT "+newsletterSubscriberColumns+" FROM newsletter_subscribers"+where+" ORDER BY created_at DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscribers := []models.NewsletterSubscriber{}
+	for rows.Next() {
+		sub, err := scanNewsletterSubscriber(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscribers = append(subscribers, *sub)
+	}
+
+	return subscribers, rows.Err()
+}
+
+// ExportSubscribers streams the subscribers in a segment, oldest first, one
+// row at a time, to write. The first row is the header.
+func (s *NewsletterService) ExportSubscribers(filter models.NewsletterFilter, write func(row []string) error) error {
+	where, args := segmentConditions(filter)
+	rows, err := s.DB.Query(`
+		SELECT email, status,
+			COALESCE((
+				SELECT string_agg(p.name, '; ' ORDER BY p.name) FROM programs p
+				WHERE newsletter_subscribers.program_ids @> to_jsonb(p.id)
+			), ''),
+			COALESCE(TO_CHAR(created_at, 'YYYY-MM-DD HH24:MI:SS'), ''),
+			COALESCE(TO_CHAR(confirmed_at, 'YYYY-MM-DD HH24:MI:SS'), ''),
+			COALESCE(TO_CHAR(unsubscribed_at, 'YYYY-MM-DD HH24:MI:SS'), '')
+		FROM newsletter_subscribers`+where+`
+		ORDER BY created_at, id
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if err := write([]string{"Email", "Status", "Programs", "Signed Up At", "Confirmed At", "Unsubscribed At"}); err != nil {
+		return err
+	}
+
+	values := make([]string, 6)
+	targets := make([]interface{}, len(values))
+	for i := range values {
+		targets[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(targets...); err != nil {
+			return err
+		}
+		if err := write(values); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// SendNewsletter queues an issue for every confirmed subscriber in a segment.
+// Each copy is rendered for its subscriber and ends with their unsubscribe link.
+func (s *NewsletterService) SendNewsletter(req models.NewsletterSendRequest) (*models.NewsletterSendResult, error) {
+	filter := models.NewsletterFilter{Status: models.NewsletterStatusSubscribed}
+	if req.ProgramID != nil {
+		if _, err := s.programService.GetProgramByID(*req.ProgramID); err != nil {
+			return nil, fmt.Errorf("%w: program %d does not exist", ErrInvalidNewsletter, *req.ProgramID)
+		}
+		filter.ProgramID = *req.ProgramID
+	}
+
+	// Check the issue renders before queuing any copies
+	sample := newsletterData{BusinessName: config.AppConfig.BusinessName, Email: "adaeze@example.com"}
+	if _, err := renderEmailTemplate(req.Subject, req.HTMLBody, req.TextBody, sample); err != nil {
+		return nil, err
+	}
+
+	subscribers, err := s.GetSubscribers(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, sub := range subscribers {
+		issue, err := renderEmailTemplate(req.Subject, req.HTMLBody, req.TextBody, newsletterData{
+			BusinessName: config.AppConfig.BusinessName,
+			Email:        sub.Email,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		oneClick, page := newsletterUnsubscribeLinks(sub.Email)
+		issue.Text += fmt.Sprintf("\n--\nYou are receiving this because you subscribed to the %s newsletter.\nUnsubscribe: %s\n",
+			config.AppConfig.BusinessName, page)
+		issue.HTML += fmt.Sprintf(`<hr><p style="font-size:12px;color:#666">You are receiving this because you subscribed to the %s newsletter. <a href="%s">Unsubscribe</a></p>
+`, htmltemplate.HTMLEscapeString(config.AppConfig.BusinessName), htmltemplate.HTMLEscapeString(page))
+
+		if err := s.emailService.SendNewsletter(tx, sub, issue, oneClick); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &models.NewsletterSendResult{Queued: len(subscribers)}, nil
+}
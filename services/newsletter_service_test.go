@@ -0,0 +1,101 @@
+package services
+
+import (
+	"errors"
+	"net/url"
+	"plantbased-backend/config"
+	"plantbased-backend/models"
+	"reflect"
+	"testing"
+)
+
+func TestNewsletterUnsubscribeToken(t *testing.T) {
+	config.AppConfig = &config.Config{NewsletterSecret: "newsletter-secret"}
+
+	token := newsletterUnsubscribeToken("ada@example.com")
+	if token == "" {
+		t.Fatal("empty token")
+	}
+	if again := newsletterUnsubscribeToken("ada@example.com"); again != token {
+		t.Errorf("token is not stable: %q then %q", token, again)
+	}
+	if other := newsletterUnsubscribeToken("bola@example.com"); other == token {
+		t.Error("different addresses get the same token")
+	}
+
+	config.AppConfig.NewsletterSecret = "rotated-secret"
+	if rotated := newsletterUnsubscribeToken("ada@example.com"); rotated == token {
+		t.Error("token does not depend on the secret")
+	}
+}
+
+func TestNewsletterUnsubscribeRejectsBadToken(t *testing.T) {
+	config.AppConfig = &config.Config{NewsletterSecret: "newsletter-secret"}
+	s := &NewsletterService{}
+
+	// A bad token is rejected before the database is touched
+	if err := s.Unsubscribe("ada@example.com", "not-the-token"); err != ErrInvalidNewsletterLink {
+		t.Errorf("Unsubscribe with a bad token = %v, want ErrInvalidNewsletterLink", err)
+	}
+	if err := s.Unsubscribe("ada@example.com", newsletterUnsubscribeToken("bola@example.com")); err != ErrInvalidNewsletterLink {
+		t.Errorf("Unsubscribe with another address's token = %v, want ErrInvalidNewsletterLink", err)
+	}
+}
+
+func TestNewsletterUnsubscribe(t *testing.T) {
+	config.AppConfig = &config.Config{NewsletterSecret: "newsletter-secret"}
+	db, fake := newFakeDB(t)
+	s := &NewsletterService{DB: db}
+
+	if err := s.Unsubscribe(" Ada@Example.com", newsletterUnsubscribeToken("ada@example.com")); err != nil {
+		t.Fatalf("Unsubscribe error = %v", err)
+	}
+	updates := fake.ran("UPDATE newsletter_subscribers")
+	if len(updates) != 1 || updates[0].args[0] != models.NewsletterStatusUnsubscribed || updates[0].args[1] != "ada@example.com" {
+		t.Errorf("updates = %v", updates)
+	}
+}
+
+func TestNewsletterUnsubscribeLinks(t *testing.T) {
+	config.AppConfig = &config.Config{
+		NewsletterSecret:         "newsletter-secret",
+		APIBaseURL:               "https://api.plantbased.example/",
+		NewsletterUnsubscribeURL: "https://plantbased.example/newsletter/unsubscribe",
+	}
+
+	oneClick, page := newsletterUnsubscribeLinks("ada@example.com")
+	query := "?email=ada%40example.com&token=" + url.QueryEscape(newsletterUnsubscribeToken("ada@example.com"))
+	if oneClick != "https://api.plantbased.example/api/v1/newsletter/unsubscribe"+query {
+		t.Errorf("one-click link = %q", oneClick)
+	}
+	if page != "https://plantbased.example/newsletter/unsubscribe"+query {
+		t.Errorf("page link = %q", page)
+	}
+}
+
+func TestSegmentConditions(t *testing.T) {
+	where, args := segmentConditions(models.NewsletterFilter{})
+	if where != "" || len(args) != 0 {
+		t.Errorf("segmentConditions(all) = %q, %v; want no conditions", where, args)
+	}
+
+	where, args = segmentConditions(models.NewsletterFilter{Status: models.NewsletterStatusSubscribed, ProgramID: 3})
+	if where != " WHERE status = $1 AND program_ids @> $2::jsonb" {
+		t.Errorf("where = %q", where)
+	}
+	if !reflect.DeepEqual(args, []interface{}{models.NewsletterStatusSubscribed, "[3]"}) {
+		t.Errorf("args = %v", args)
+	}
+}
+
+func TestNewsletterSubscribeRejectsInvalidEmail(t *testing.T) {
+	db, fake := newFakeDB(t)
+	s := &NewsletterService{DB: db}
+
+	if err := s.Subscribe(models.NewsletterSubscribeRequest{Email: "ada@"}); !errors.Is(err, ErrInvalidSubscription) {
+		t.Errorf("Subscribe error = %v, want ErrInvalidSubscription", err)
+	}
+	if inserts := fake.ran("INSERT INTO newsletter_subscribers"); len(inserts) != 0 {
+		t.Errorf("%d subscribers stored, want none", len(inserts))
+	}
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"plantbased-backend/models"
+	"strings"
+)
+
+// Notification rule errors
+var (
+	ErrInvalidNotificationRule  = errors.New("invalid notification rule")
+	ErrNotificationRuleNotFound = errors.New("notification rule not found")
+)
+
+const notificationRuleColumns = `id, event, program_id, recipients, cc, bcc, is_active, created_at, updated_at`
+
+// NotificationRuleService manages who receives the internal notification
+// emails for each event
+type NotificationRuleService struct {
+	DB             *sql.DB
+	programService *ProgramService
+}
+
+func NewNotificationRuleService(db *sql.DB, programService *ProgramService) *NotificationRuleService {
+	return &NotificationRuleService{
+		DB:             db,
+		programService: programService,
+	}
+}
+
+func scanNotificationRule(row rowScanner) (*models.NotificationRule, error) {
+	var r models.NotificationRule
+	var recipientsJSON, ccJSON, bccJSON []byte
+	err := row.Scan(
+		&r.ID, &r.Event, &r.ProgramID, &recipientsJSON, &ccJSON, &bccJSON, &r.IsActive, &r.CreatedAt, &r.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Recipients, r.CC, r.BCC = []string{}, []string{}, []string{}
+	json.Unmarshal(recipientsJSON, &r.Recipients)
+	json.Unmarshal(ccJSON, &r.CC)
+	json.Unmarshal(bccJSON, &r.BCC)
+	return &r, nil
+}
+
+// validateRule checks a notification rule request and normalizes its addresses
+func (s *NotificationRuleService) validateRule(req *models.NotificationRuleRequest) error {
+	if !models.IsNotificationEvent(req.Event) {
+		return fmt.Errorf("%w: event must be one of %s", ErrInvalidNotificationRule, strings.Join(models.NotificationEvents, ", "))
+	}
+
+	var err error
+	if req.Recipients, err = normalizeAddresses("recipients", req.Recipients); err != nil {
+		return err
+	}
+	if len(req.Recipients) == 0 {
+		return fmt.Errorf("%w: at least one recipient is required", ErrInvalidNotificationRule)
+	}
+	if req.CC, err = normalizeAddresses("cc", req.CC); err != nil {
+		return err
+	}
+	if req.BCC, err = normalizeAddresses("bcc", req.BCC); err != nil {
+		return err
+	}
+
+	if req.ProgramID != nil {
+		if _, err := s.programService.GetProgramByID(*req.ProgramID); err != nil {
+			return fmt.Errorf("%w: program %d does not exist", ErrInvalidNotificationRule, *req.ProgramID)
+		}
+	}
+
+	return nil
+}
+
+// normalizeAddresses lowercases and dedupes a list of email addresses,
+// rejecting any that are invalid
+func normalizeAddresses(field string, addresses []string) ([]string, error) {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, address := range addresses {
+		address = normalizeEmail(address)
+		if !isValidEmail(address) {
+			return nil, fmt.Errorf("%w: %s contains an invalid email address %q", ErrInvalidNotificationRule, field, address)
+		}
+		if !seen[address] {
+			seen[address] = true
+			normalized = append(normalized, address)
+		}
+	}
+	return normalized, nil
+}
+
+// notificationRuleArgs returns the column values stored for a rule request
+func notificationRuleArgs(req models.NotificationRuleRequest) (recipients, cc, bcc string, active bool) {
+	recipientsJSON, _ := json.Marshal(req.Recipients)
+	ccJSON, _ := json.Marshal(req.CC)
+	bccJSON, _ := json.Marshal(req.BCC)
+	active = req.IsActive == nil || *req.IsActive
+	return string(recipientsJSON), string(ccJSON), string(bccJSON), active
+}
+
+// CreateRule creates a new notification rule
+func (s *NotificationRuleService) CreateRule(req models.NotificationRuleRequest) (*models.NotificationRule, error) {
+	if err := s.validateRule(&req); err != nil {
+		return nil, err
+	}
+
+	recipients, cc, bcc, active := notificationRuleArgs(req)
+	return scanNotificationRule(s.DB.QueryRow(`
+		INSERT INTO notification_rules (event, program_id, recipients, cc, bcc, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+notificationRuleColumns,
+		req.Event, req.ProgramID, recipients, cc, bcc, active,
+	))
+}
+
+// UpdateRule replaces the settings of a notification rule
+func (s *NotificationRuleService) UpdateRule(id int, req models.NotificationRuleRequest) (*models.NotificationRule, error) {
+	if err := s.validateRule(&req); err != nil {
+		return nil, err
+	}
+
+	recipients, cc, bcc, active := notificationRuleArgs(req)
+	rule, err := scanNotificationRule(s.DB.QueryRow(`
+		UPDATE notification_rules
+		SET event = $1, program_id = $2, recipients = $3, cc = $4, bcc = $5, is_active = $6, updated_at = NOW()
+		WHERE id = $7
+		RETURNING `+notificationRuleColumns,
+		req.Event, req.ProgramID, recipients, cc, bcc, active, id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotificationRuleNotFound
+	}
+	return rule, err
+}
+
+// DeleteRule deletes a notification rule
+func (s *NotificationRuleService) DeleteRule(id int) error {
+	result, err := s.DB.Exec("DELETE FROM notification_rules WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrNotificationRuleNotFound
+	}
+
+	return nil
+}
+
+// GetRules retrieves the notification rules, optionally for one event,
+// general rules before program rules
+func (s *NotificationRuleService) GetRules(event string) ([]models.NotificationRule, error) {
+	rows, err := s.DB.Query(`
+		SELECT `+notificationRuleColumns+` FROM notification_rules
+		WHERE $1 = '' OR event = $1
+		ORDER BY event, program_id NULLS FIRST, id
+	`, event)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []models.NotificationRule{}
+	for rows.Next() {
+		rule, err := scanNotificationRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// GetRuleByID retrieves a single notification rule
+func (s *NotificationRuleService) GetRuleByID(id int) (*models.NotificationRule, error) {
+	rule, err := scanNotificationRule(s.DB.QueryRow("SELECT "+notificationRuleColumns+" FROM notification_rules WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotificationRuleNotFound
+	}
+	return rule, err
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// notificationRouting holds the addresses an event's notification goes to
+type notificationRouting struct {
+	to  []string
+	cc  []string
+	bcc []string
+}
+
+// notificationRecipients resolves the active rules for an event. When any
+// rule is specific to the program, only those rules apply. Addresses from
+// every matching rule are merged, each appearing once, in To before Cc before Bcc.
+func notificationRecipients(db querier, event string, programID *int) (*notificationRouting, error) {
+	rows, err := db.Query(`
+		SELECT `+notificationRuleColumns+` FROM notification_rules
+		WHERE event = $1 AND is_active AND (program_id IS NULL OR program_id = $2)
+		ORDER BY id
+	`, event, programID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var general, specific []models.NotificationRule
+	for rows.Next() {
+		rule, err := scanNotificationRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		if rule.ProgramID != nil {
+			specific = append(specific, *rule)
+		} else {
+			general = append(general, *rule)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rules := general
+	if len(specific) > 0 {
+		rules = specific
+	}
+
+	routing := &notificationRouting{}
+	seen := map[string]bool{}
+	add := func(list *[]string, addresses []string) {
+		for _, address := range addresses {
+			if !seen[address] {
+				seen[address] = true
+				*list = append(*list, address)
+			}
+		}
+	}
+	for _, rule := range rules {
+		add(&routing.to, rule.Recipients)
+	}
+	for _, rule := range rules {
+		add(&routing.cc, rule.CC)
+	}
+	for _, rule := range rules {
+		add(&routing.bcc, rule.BCC)
+	}
+
+	return routing, nil
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"database/sql/driver"
+	"errors"
+	"plantbased-backend/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNormalizeAddresses(t *testing.T) {
+	got, err := normalizeAddresses("cc", []string{" Ops@PlantBased.example", "ops@plantbased.example", "ceo@plantbased.example"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"ops@plantbased.example", "ceo@plantbased.example"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("normalizeAddresses = %v, want %v", got, want)
+	}
+
+	if got, err := normalizeAddresses("cc", nil); err != nil || got == nil || len(got) != 0 {
+		t.Errorf("normalizeAddresses(nil) = %v, %v; want an empty list", got, err)
+	}
+
+	for _, invalid := range []string{"ops", "Ops <ops@plantbased.example>", "ops@localhost"} {
+		if _, err := normalizeAddresses("cc", []string{invalid}); !errors.Is(err, ErrInvalidNotificationRule) {
+			t.Errorf("normalizeAddresses(%q) error = %v, want ErrInvalidNotificationRule", invalid, err)
+		}
+	}
+}
+
+// notificationRuleRow returns a notification_rules row for the fake database
+func notificationRuleRow(id int, programID interface{}, recipients, cc, bcc string) []driver.Value {
+	now := time.Now()
+	return []driver.Value{int64(id), models.NotificationEventNewLead, programID, recipients, cc, bcc, true, now, now}
+}
+
+var notificationRuleTestColumns = []string{"id", "event", "program_id", "recipients", "cc", "bcc", "is_active", "created_at", "updated_at"}
+
+func TestNotificationRecipients(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.on("FROM notification_rules", notificationRuleTestColumns,
+		notificationRuleRow(1, nil, `["sales@plantbased.example"]`, `[]`, `[]`),
+		notificationRuleRow(2, int64(3), `["coach@plantbased.example"]`, `["ops@plantbased.example"]`, `["audit@plantbased.example"]`),
+		notificationRuleRow(3, int64(3), `["ops@plantbased.example", "coach@plantbased.example"]`, `[]`, `["audit@plantbased.example"]`),
+	)
+
+	programID := 3
+	routing, err := notificationRecipients(db, models.NotificationEventNewLead, &programID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Program rules replace the general one, and each address is used once
+	if want := []string{"coach@plantbased.example", "ops@plantbased.example"}; !reflect.DeepEqual(routing.to, want) {
+		t.Errorf("to = %v, want %v", routing.to, want)
+	}
+	if len(routing.cc) != 0 {
+		t.Errorf("cc = %v, want none", routing.cc)
+	}
+	if want := []string{"audit@plantbased.example"}; !reflect.DeepEqual(routing.bcc, want) {
+		t.Errorf("bcc = %v, want %v", routing.bcc, want)
+	}
+}
+
+func TestNotificationRecipientsGeneralRules(t *testing.T) {
+	db, fake := newFakeDB(t)
+	fake.on("FROM notification_rules", notificationRuleTestColumns,
+		notificationRuleRow(1, nil, `["sales@plantbased.example"]`, `["ceo@plantbased.example"]`, `[]`),
+	)
+
+	routing, err := notificationRecipients(db, models.NotificationEventNewLead, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(routing.to, []string{"sales@plantbased.example"}) || !reflect.DeepEqual(routing.cc, []string{"ceo@plantbased.example"}) {
+		t.Errorf("routing = %+v", routing)
+	}
+}
@@ -0,0 +1,367 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"plantbased-backend/models"
+	"strings"
+	"time"
+)
+
+const orderColumns = `id, reference, amount, expected_amount, refunded_amount, currency, customer_email, program_id, plan_id,
+	program_name, plan_name, lead_id, status, paid_at, created_at, updated_at`
+
+// ErrOrderNotFound is returned when an order does not exist
+var ErrOrderNotFound = errors.New("order not found")
+
+type OrderService struct {
+	DB             *sql.DB
+	programService *ProgramService
+}
+
+func NewOrderService(db *sql.DB, programService *ProgramService) *OrderService {
+	return &OrderService{
+		DB:             db,
+		programService: programService,
+	}
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanOrder(row rowScanner) (*models.Order, error) {
+	var o models.Order
+	err := row.Scan(
+		&o.ID, &o.Reference, &o.Amount, &o.ExpectedAmount, &o.RefundedAmount, &o.Currency, &o.CustomerEmail, &o.ProgramID, &o.PlanID,
+		&o.ProgramName, &o.PlanName, &o.LeadID, &o.Status, &o.PaidAt, &o.CreatedAt, &o.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// nullableInt converts a zero ID into a NULL database value
+func nullableInt(value int) interface{} {
+	if value == 0 {
+		return nil
+	}
+	return value
+}
+
+// RecordSuccessfulCharge stores a successful Paystack charge as an order.
+// Program and plan are taken from the transaction metadata, and the charged
+// amount is reconciled against the plan price: charges that do not match, or
+// that cannot be tied to a plan, are flagged as amount_mismatch instead of paid.
+func (s *OrderService) RecordSuccessfulCharge(data models.PaystackWebhookData) (*models.Order, error) {
+	if data.Reference == "" {
+		return nil, errors.New("missing transaction reference")
+	}
+
+	programID, programName, err := s.resolveProgram(data.MetadataInt("program_id"), data.MetadataString("program"))
+	if err != nil {
+		return nil, err
+	}
+
+	planName := data.MetadataString("plan")
+	if planName == "" {
+		planName = data.MetadataString("package")
+	}
+	plan, err := s.resolvePlan(programID, data.MetadataInt("plan_id"), planName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Subscription renewals carry no metadata, only the Paystack plan
+	if plan == nil && data.Plan.PlanCode != "" {
+		if plan, err = s.resolvePlanCode(data.Plan.PlanCode); err != nil {
+			return nil, err
+		}
+		if plan != nil {
+			if programID, programName, err = s.resolveProgram(plan.ProgramID, programName); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	planID := 0
+	if plan != nil {
+		planID = plan.ID
+		if planName == "" {
+			planName = plan.Name
+		}
+	}
+
+	currency := strings.ToUpper(data.Currency)
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	charged := models.Money{Amount: int64(data.Amount), Currency: currency}
+
+	expected, current, err := s.expectedPrice(data.Reference, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	status := models.OrderStatusPaid
+	var expectedAmount interface{}
+	switch {
+	case expected == nil:
+		status = models.OrderStatusAmountMismatch
+		log.Printf("Charge %s could not be matched to a pricing plan; flagging for review", data.Reference)
+	case *expected != charged:
+		status = models.OrderStatusAmountMismatch
+		expectedAmount = expected.Amount
+		log.Printf("Charge %s amount mismatch: charged %s, expected %s", data.Reference, charged, *expected)
+	default:
+		expectedAmount = expected.Amount
+	}
+
+	// A charge seen again after a refund or dispute, from a redelivered
+	// webhook or from reconciliation, leaves the order as it is
+	if current != "" && current != status && !models.CanTransitionPayment(current, status) {
+		log.Printf("Charge %s recorded again; order stays %s", data.Reference, current)
+	}
+
+	paidAt := time.Now()
+	if parsed, err := time.Parse(time.RFC3339, data.PaidAt); err == nil {
+		paidAt = parsed
+	}
+
+	row := s.DB.QueryRow(`
+		INSERT INTO orders (
+			reference, amount, expected_amount, currency, customer_email, program_id, plan_id,
+			program_name, plan_name, status, paid_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (reference) DO UPDATE SET
+			amount = EXCLUDED.amount,
+			expected_amount = COALESCE(orders.expected_amount, EXCLUDED.expected_amount),
+			currency = EXCLUDED.currency,
+			customer_email = EXCLUDED.customer_email,
+			program_id = COALESCE(EXCLUDED.program_id, orders.program_id),
+			plan_id = COALESCE(EXCLUDED.plan_id, orders.plan_id),
+			program_name = COALESCE(NULLIF(EXCLUDED.program_name, ''), orders.program_name),
+			plan_name = COALESCE(NULLIF(EXCLUDED.plan_name, ''), orders.plan_name),
+			status = CASE WHEN orders.status IN (`+models.OrderStatusesAwaitingPayment+`)
+				THEN EXCLUDED.status ELSE orders.status END,
+			paid_at = CASE WHEN orders.status IN (`+models.OrderStatusesAwaitingPayment+`)
+				THEN EXCLUDED.paid_at ELSE orders.paid_at END,
+			updated_at = NOW()
+		RETURNING `+orderColumns,
+		data.Reference, charged.Amount, expectedAmount, currency, strings.ToLower(data.Customer.Email),
+		nullableInt(programID), nullableInt(planID), programName, planName,
+		status, paidAt,
+	)
+
+	return scanOrder(row)
+}
+
+// expectedPrice returns the amount a charge should have been for, and the
+// status of the order already stored for the reference, if any. A pending
+// order created at checkout takes precedence over the current plan price, so
+// later price changes do not affect checkouts already in flight.
+func (s *OrderService) expectedPrice(reference string, plan *models.ProgramPricingPlan) (*models.Money, string, error) {
+	var amount sql.NullInt64
+	var currency, status string
+	err := s.DB.QueryRow(`
+		SELECT expected_amount, currency, status FROM orders WHERE reference = $1
+	`, reference).Scan(&amount, &currency, &status)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, "", err
+	}
+	if err == nil && amount.Valid {
+		return &models.Money{Amount: amount.Int64, Currency: currency}, status, nil
+	}
+
+	if plan == nil {
+		return nil, status, nil
+	}
+	return &plan.Price, status, nil
+}
+
+// CreatePendingOrder stores an order for a checkout that has not been paid yet
+func (s *OrderService) CreatePendingOrder(order models.Order) (*models.Order, error) {
+	programID, planID := 0, 0
+	if order.ProgramID != nil {
+		programID = *order.ProgramID
+	}
+	if order.PlanID != nil {
+		planID = *order.PlanID
+	}
+
+	row := s.DB.QueryRow(`
+		INSERT INTO orders (
+			reference, amount, expected_amount, currency, customer_email, program_id, plan_id,
+			program_name, plan_name, status
+		) VALUES ($1, $2, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING `+orderColumns,
+		order.Reference, order.Amount, order.Currency, order.CustomerEmail,
+		nullableInt(programID), nullableInt(planID), order.ProgramName, order.PlanName,
+		models.OrderStatusPending,
+	)
+
+	return scanOrder(row)
+}
+
+// UpdateOrderStatus sets the status of an order
+func (s *OrderService) UpdateOrderStatus(id int, status string) error {
+	result, err := s.DB.Exec(`
+		UPDATE orders SET status = $1, updated_at = NOW() WHERE id = $2
+	`, status, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrOrderNotFound
+	}
+
+	return nil
+}
+
+// resolveProgram checks a metadata program ID against the database and fills in its name
+func (s *OrderService) resolveProgram(programID int, programName string) (int, string, error) {
+	if programID == 0 {
+		return 0, programName, nil
+	}
+
+	var name string
+	err := s.DB.QueryRow("SELECT name FROM programs WHERE id = $1", programID).Scan(&name)
+	if err == sql.ErrNoRows {
+		return 0, programName, nil
+	}
+	if err != nil {
+		return 0, "", err
+	}
+
+	if programName == "" {
+		programName = name
+	}
+	return programID, programName, nil
+}
+
+// resolvePlan finds the purchased plan among the program's pricing plans,
+// by ID when the metadata carries one and by name otherwise
+func (s *OrderService) resolvePlan(programID, planID int, planName string) (*models.ProgramPricingPlan, error) {
+	if programID == 0 || (planID == 0 && planName == "") {
+		return nil, nil
+	}
+
+	plans, err := s.programService.GetPricingPlansByProgramID(programID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range plans {
+		if planID != 0 && plans[i].ID == planID {
+			return &plans[i], nil
+		}
+		if planID == 0 && strings.EqualFold(plans[i].Name, planName) {
+			return &plans[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// resolvePlanCode finds the recurring pricing plan behind a Paystack plan code
+func (s *OrderService) resolvePlanCode(planCode string) (*models.ProgramPricingPlan, error) {
+	plan, err := scanPricingPlan(s.DB.QueryRow(`
+		SELECT `+pricingPlanColumns+` FROM program_pricing_plans WHERE paystack_plan_code = $1
+	`, planCode))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return plan, err
+}
+
+// GetOrders retrieves orders matching the given filter, newest first
+func (s *OrderService) GetOrders(filter models.OrderFilter) ([]models.Order, error) {
+	var conditions []string
+	var args []interface{}
+
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+	if filter.Email != "" {
+		addCondition("customer_email ILIKE $%d", "%"+filter.Email+"%")
+	}
+	if filter.Reference != "" {
+		addCondition("reference = $%d", filter.Reference)
+	}
+	if filter.ProgramID != 0 {
+		addCondition("program_id = $%d", filter.ProgramID)
+	}
+	if filter.PlanID != 0 {
+		addCondition("plan_id = $%d", filter.PlanID)
+	}
+	if filter.From != nil {
+		addCondition("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("created_at < $%d", *filter.To)
+	}
+	if filter.Unmatched {
+		addCondition("status = $%d AND lead_id IS NULL", models.OrderStatusPaid)
+	}
+
+	query := "SELECT " + orderColumns + " FROM orders"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at DESC"
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []models.Order{}
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *order)
+	}
+
+	return orders, rows.Err()
+}
+
+// GetOrderByID retrieves a single order by ID
+func (s *OrderService) GetOrderByID(id int) (*models.Order, error) {
+	order, err := scanOrder(s.DB.QueryRow("SELECT "+orderColumns+" FROM orders WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrOrderNotFound
+	}
+	return order, err
+}
+
+// GetOrderByReference retrieves a single order by its Paystack reference
+func (s *OrderService) GetOrderByReference(reference string) (*models.Order, error) {
+	order, err := scanOrder(s.DB.QueryRow("SELECT "+orderColumns+" FROM orders WHERE reference = $1", reference))
+	if err == sql.ErrNoRows {
+		return nil, ErrOrderNotFound
+	}
+	return order, err
+}
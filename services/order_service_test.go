@@ -0,0 +1,64 @@
+package services
+
+import (
+	"database/sql/driver"
+	"errors"
+	"plantbased-backend/models"
+	"testing"
+)
+
+func TestRecordSuccessfulChargeReconcilesAmount(t *testing.T) {
+	errStop := errors.New("stop after the insert")
+
+	tests := []struct {
+		name       string
+		pending    [][]driver.Value // expected_amount, currency, status of the order created at checkout
+		amount     int
+		currency   string
+		wantStatus string
+	}{
+		{"matching charge", [][]driver.Value{{int64(2500000), "NGN", models.OrderStatusPending}}, 2500000, "NGN", models.OrderStatusPaid},
+		{"currency defaults to NGN", [][]driver.Value{{int64(2500000), "NGN", models.OrderStatusPending}}, 2500000, "", models.OrderStatusPaid},
+		{"underpaid", [][]driver.Value{{int64(2500000), "NGN", models.OrderStatusPending}}, 250000, "NGN", models.OrderStatusAmountMismatch},
+		{"wrong currency", [][]driver.Value{{int64(2500000), "NGN", models.OrderStatusPending}}, 2500000, "USD", models.OrderStatusAmountMismatch},
+		{"no known price", nil, 2500000, "NGN", models.OrderStatusAmountMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newFakeDB(t)
+			fake.on("SELECT expected_amount, currency, status FROM orders", []string{"expected_amount", "currency", "status"}, tt.pending...)
+			fake.fail("INSERT INTO orders", errStop)
+
+			s := &OrderService{DB: db}
+			_, err := s.RecordSuccessfulCharge(models.PaystackWebhookData{
+				Reference: "PB-123",
+				Amount:    tt.amount,
+				Currency:  tt.currency,
+				Status:    "success",
+				Customer:  models.PaystackCustomer{Email: "Ada@Example.com"},
+			})
+			if !errors.Is(err, errStop) {
+				t.Fatalf("RecordSuccessfulCharge error = %v", err)
+			}
+
+			inserts := fake.ran("INSERT INTO orders")
+			if len(inserts) != 1 {
+				t.Fatalf("%d inserts, want 1", len(inserts))
+			}
+			if status := inserts[0].args[9]; status != tt.wantStatus {
+				t.Errorf("status = %v, want %v", status, tt.wantStatus)
+			}
+			if email := inserts[0].args[4]; email != "ada@example.com" {
+				t.Errorf("customer email = %v, want it lowercased", email)
+			}
+		})
+	}
+}
+
+func TestRecordSuccessfulChargeRequiresReference(t *testing.T) {
+	s := &OrderService{}
+	if _, err := s.RecordSuccessfulCharge(models.PaystackWebhookData{Amount: 100}); err == nil {
+		t.Error("charge without a reference was recorded")
+	}
+}
@@ -2,24 +2,160 @@ package services
 
 import (
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha512"
 	"encoding/hex"
-	"os"
+	"errors"
+	"fmt"
+	"log"
+	"plantbased-backend/config"
+	"plantbased-backend/models"
+	"strings"
 )
 
-type PaymentService struct{}
+// ErrInvalidCheckout is returned when a checkout request refers to an unknown program or plan
+var ErrInvalidCheckout = errors.New("invalid checkout request")
 
-func NewPaymentService() *PaymentService {
-	return &PaymentService{}
+type PaymentService struct {
+	client         PaystackClient
+	programService *ProgramService
+	orderService   *OrderService
+	couponService  *CouponService
+}
+
+func NewPaymentService(client PaystackClient, programService *ProgramService, orderService *OrderService, couponService *CouponService) *PaymentService {
+	return &PaymentService{
+		client:         client,
+		programService: programService,
+		orderService:   orderService,
+		couponService:  couponService,
+	}
 }
 
 // VerifyWebhookSignature verifies that the webhook came from Paystack
 func (s *PaymentService) VerifyWebhookSignature(signature string, payload []byte) bool {
-	secret := os.Getenv("PAYSTACK_SECRET_KEY")
-	
+	secret := config.AppConfig.PaystackSecretKey
+
 	hash := hmac.New(sha512.New, []byte(secret))
 	hash.Write(payload)
 	expectedSignature := hex.EncodeToString(hash.Sum(nil))
-	
+
 	return hmac.Equal([]byte(signature), []byte(expectedSignature))
-}
\ No newline at end of file
+}
+
+// InitializeCheckout prices the requested plan on the server, creates a
+// pending order and starts a Paystack transaction for it
+func (s *PaymentService) InitializeCheckout(req models.CheckoutRequest) (*models.CheckoutResponse, error) {
+	// Only unknown programs and plans are the caller's fault; anything else
+	// is a server error
+	program, err := s.programService.GetProgramByID(req.ProgramID)
+	if errors.Is(err, ErrProgramNotFound) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCheckout, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := s.programService.GetPricingPlanByID(req.ProgramID, req.PlanID)
+	if errors.Is(err, ErrPricingPlanNotFound) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCheckout, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := plan.Price.Validate(); err != nil {
+		return nil, fmt.Errorf("pricing plan %d has an invalid price: %w", plan.ID, err)
+	}
+	amount, currency := plan.Price.Amount, plan.Price.Currency
+
+	// Price the plan with the coupon, if one was given
+	var quote *models.CouponQuote
+	if req.Coupon != "" {
+		quote, err = s.couponService.Quote(req.Coupon, program.ID, plan.ID, req.Email)
+		if err != nil {
+			return nil, err
+		}
+		amount = quote.DiscountedPrice.Amount
+	}
+
+	reference, err := generateReference()
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.orderService.CreatePendingOrder(models.Order{
+		Reference:     reference,
+		Amount:        amount,
+		Currency:      currency,
+		CustomerEmail: strings.ToLower(req.Email),
+		ProgramID:     &program.ID,
+		PlanID:        &plan.ID,
+		ProgramName:   program.Name,
+		PlanName:      plan.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]interface{}{
+		"order_id":   order.ID,
+		"program_id": program.ID,
+		"plan_id":    plan.ID,
+		"program":    program.Name,
+		"plan":       plan.Name,
+	}
+
+	if quote != nil {
+		if err := s.couponService.Redeem(quote.Code, order); err != nil {
+			s.failOrder(order.ID)
+			return nil, err
+		}
+		metadata["coupon"] = quote.Code
+	}
+
+	resp, err := s.client.InitializeTransaction(models.PaystackInitializeRequest{
+		Email:       order.CustomerEmail,
+		Amount:      amount,
+		Currency:    currency,
+		Reference:   reference,
+		CallbackURL: config.AppConfig.PaystackCallbackURL,
+		Plan:        plan.PlanCode, // Subscribes the customer on recurring plans
+		Metadata:    metadata,
+	})
+	if err != nil {
+		s.failOrder(order.ID)
+		return nil, err
+	}
+
+	response := &models.CheckoutResponse{
+		AuthorizationURL: resp.AuthorizationURL,
+		AccessCode:       resp.AccessCode,
+		Reference:        reference,
+		OrderID:          order.ID,
+		Amount:           amount,
+		Currency:         currency,
+	}
+	if quote != nil {
+		response.Discount = quote.Discount.Amount
+		response.Coupon = quote.Code
+	}
+
+	return response, nil
+}
+
+// failOrder marks a pending order as failed when its checkout could not be started
+func (s *PaymentService) failOrder(orderID int) {
+	if err := s.orderService.UpdateOrderStatus(orderID, models.OrderStatusFailed); err != nil {
+		log.Printf("Failed to mark order %d as failed: %v", orderID, err)
+	}
+}
+
+// generateReference creates a unique transaction reference
+func generateReference() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "PB-" + strings.ToUpper(hex.EncodeToString(b)), nil
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"plantbased-backend/config"
+	"testing"
+)
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	config.AppConfig = &config.Config{PaystackSecretKey: "sk_test_secret"}
+	s := &PaymentService{}
+
+	payload := []byte(`{"event":"charge.success","data":{"reference":"PB-123"}}`)
+	mac := hmac.New(sha512.New, []byte("sk_test_secret"))
+	mac.Write(payload)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	if !s.VerifyWebhookSignature(signature, payload) {
+		t.Error("valid signature rejected")
+	}
+
+	tampered := []byte(`{"event":"charge.success","data":{"reference":"PB-124"}}`)
+	if s.VerifyWebhookSignature(signature, tampered) {
+		t.Error("signature accepted for a different payload")
+	}
+
+	other := hmac.New(sha512.New, []byte("sk_test_other"))
+	other.Write(payload)
+	if s.VerifyWebhookSignature(hex.EncodeToString(other.Sum(nil)), payload) {
+		t.Error("signature made with another key accepted")
+	}
+
+	if s.VerifyWebhookSignature("", payload) {
+		t.Error("missing signature accepted")
+	}
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"plantbased-backend/config"
+	"plantbased-backend/models"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PaystackClient is the subset of the Paystack API used by the backend.
+// It is an interface so the HTTP client can be swapped for a local fake.
+type PaystackClient interface {
+	InitializeTransaction(req models.PaystackInitializeRequest) (*models.PaystackInitializeResponse, error)
+	VerifyTransaction(reference string) (*models.PaystackWebhookData, error)
+	CreateRefund(req models.PaystackRefundRequest) (*models.PaystackRefundData, error)
+	CreatePlan(req models.PaystackPlanRequest) (*models.PaystackPlanRef, error)
+	UpdatePlan(planCode string, req models.PaystackPlanRequest) error
+}
+
+// NewPaystackClient returns the client selected by PAYSTACK_CLIENT
+func NewPaystackClient(cfg *config.Config) PaystackClient {
+	if cfg.PaystackClient == "fake" {
+		return NewFakePaystackClient()
+	}
+	return NewHTTPPaystackClient(cfg.PaystackSecretKey, cfg.PaystackBaseURL)
+}
+
+// HTTPPaystackClient talks to the real Paystack API
+type HTTPPaystackClient struct {
+	SecretKey  string
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+func NewHTTPPaystackClient(secretKey, baseURL string) *HTTPPaystackClient {
+	return &HTTPPaystackClient{
+		SecretKey:  secretKey,
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// paystackEnvelope is the response wrapper used by every Paystack endpoint
+type paystackEnvelope struct {
+	Status  bool            `json:"status"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// do sends a request to Paystack and decodes the "data" field into out
+func (c *HTTPPaystackClient) do(method, path string, payload interface{}, out interface{}) error {
+	if c.SecretKey == "" {
+		return errors.New("PAYSTACK_SECRET_KEY is not set")
+	}
+
+	var body bytes.Buffer
+	if payload != nil {
+		if err := json.NewEncoder(&body).Encode(payload); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, c.BaseURL+path, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.SecretKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("paystack request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var envelope paystackEnvelope
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("invalid paystack response (HTTP %d): %w", resp.StatusCode, err)
+	}
+
+	if resp.StatusCode >= 300 || !envelope.Status {
+		return fmt.Errorf("paystack error (HTTP %d): %s", resp.StatusCode, envelope.Message)
+	}
+
+	if out != nil {
+		return json.Unmarshal(envelope.Data, out)
+	}
+	return nil
+}
+
+// InitializeTransaction starts a Paystack checkout
+func (c *HTTPPaystackClient) InitializeTransaction(req models.PaystackInitializeRequest) (*models.PaystackInitializeResponse, error) {
+	var resp models.PaystackInitializeResponse
+	if err := c.do(http.MethodPost, "/transaction/initialize", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// VerifyTransaction fetches the current state of a transaction from Paystack
+func (c *HTTPPaystackClient) VerifyTransaction(reference string) (*models.PaystackWebhookData, error) {
+	var data models.PaystackWebhookData
+	if err := c.do(http.MethodGet, "/transaction/verify/"+url.PathEscape(reference), nil, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// CreateRefund asks Paystack to refund all or part of a transaction
+func (c *HTTPPaystackClient) CreateRefund(req models.PaystackRefundRequest) (*models.PaystackRefundData, error) {
+	var data models.PaystackRefundData
+	if err := c.do(http.MethodPost, "/refund", req, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// CreatePlan creates a Paystack plan for a recurring pricing plan
+func (c *HTTPPaystackClient) CreatePlan(req models.PaystackPlanRequest) (*models.PaystackPlanRef, error) {
+	var plan models.PaystackPlanRef
+	if err := c.do(http.MethodPost, "/plan", req, &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// UpdatePlan updates an existing Paystack plan. Paystack applies the new
+// amount to existing subscriptions from their next charge.
+func (c *HTTPPaystackClient) UpdatePlan(planCode string, req models.PaystackPlanRequest) error {
+	return c.do(http.MethodPut, "/plan/"+url.PathEscape(planCode), req, nil)
+}
+
+// FakePaystackClient is an in-memory stand-in for Paystack, for local
+// development and tests. It records every request it receives.
+type FakePaystackClient struct {
+	mu           sync.Mutex
+	Initialized  []models.PaystackInitializeRequest
+	CheckoutBase string
+
+	// Transactions holds the verify result for each reference; references
+	// that are not present verify as "abandoned"
+	Transactions map[string]models.PaystackWebhookData
+
+	Refunds []models.PaystackRefundRequest
+
+	// Plans holds every plan created or updated, keyed by plan code
+	Plans map[string]models.PaystackPlanRequest
+}
+
+func NewFakePaystackClient() *FakePaystackClient {
+	return &FakePaystackClient{
+		CheckoutBase: "https://checkout.paystack.local/",
+		Transactions: make(map[string]models.PaystackWebhookData),
+		Plans:        make(map[string]models.PaystackPlanRequest),
+	}
+}
+
+// InitializeTransaction records the request and returns a fake checkout URL
+func (c *FakePaystackClient) InitializeTransaction(req models.PaystackInitializeRequest) (*models.PaystackInitializeResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Initialized = append(c.Initialized, req)
+	return &models.PaystackInitializeResponse{
+		AuthorizationURL: c.CheckoutBase + req.Reference,
+		AccessCode:       "fake_" + req.Reference,
+		Reference:        req.Reference,
+	}, nil
+}
+
+// VerifyTransaction returns the stored transaction for the reference
+func (c *FakePaystackClient) VerifyTransaction(reference string) (*models.PaystackWebhookData, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, ok := c.Transactions[reference]
+	if !ok {
+		data = models.PaystackWebhookData{Reference: reference, Status: "abandoned"}
+	}
+	return &data, nil
+}
+
+// CreateRefund records the request and returns a pending refund
+func (c *FakePaystackClient) CreateRefund(req models.PaystackRefundRequest) (*models.PaystackRefundData, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Refunds = append(c.Refunds, req)
+	return &models.PaystackRefundData{
+		ID:                   json.Number(strconv.Itoa(len(c.Refunds))),
+		TransactionReference: req.Transaction,
+		Amount:               req.Amount,
+		Currency:             req.Currency,
+		Status:               models.RefundStatusPending,
+	}, nil
+}
+
+// CreatePlan records the plan under a generated plan code
+func (c *FakePaystackClient) CreatePlan(req models.PaystackPlanRequest) (*models.PaystackPlanRef, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	code := fmt.Sprintf("PLN_fake%d", len(c.Plans)+1)
+	c.Plans[code] = req
+	return &models.PaystackPlanRef{PlanCode: code, Name: req.Name}, nil
+}
+
+// UpdatePlan replaces a previously created plan
+func (c *FakePaystackClient) UpdatePlan(planCode string, req models.PaystackPlanRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.Plans[planCode]; !ok {
+		return fmt.Errorf("paystack error (HTTP 404): plan %s not found", planCode)
+	}
+	c.Plans[planCode] = req
+	return nil
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"plantbased-backend/models"
+	"testing"
+)
+
+func TestHTTPPaystackClientInitializeTransaction(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/transaction/initialize" {
+			t.Errorf("request = %s %s, want POST /transaction/initialize", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sk_test_secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+
+		var req models.PaystackInitializeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("invalid request body: %v", err)
+		}
+		if req.Amount != 2500000 || req.Currency != "NGN" || req.Reference != "PB-123" {
+			t.Errorf("request = %+v", req)
+		}
+
+		w.Write([]byte(`{"status":true,"message":"Authorization URL created","data":{
+			"authorization_url":"https://checkout.paystack.com/abc","access_code":"abc","reference":"PB-123"}}`))
+	}))
+	defer server.Close()
+
+	client := NewHTTPPaystackClient("sk_test_secret", server.URL+"/")
+	resp, err := client.InitializeTransaction(models.PaystackInitializeRequest{
+		Email:     "ada@example.com",
+		Amount:    2500000,
+		Currency:  "NGN",
+		Reference: "PB-123",
+	})
+	if err != nil {
+		t.Fatalf("InitializeTransaction error = %v", err)
+	}
+	if resp.AuthorizationURL != "https://checkout.paystack.com/abc" || resp.AccessCode != "abc" {
+		t.Errorf("InitializeTransaction = %+v", resp)
+	}
+}
+
+func TestHTTPPaystackClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status":false,"message":"Invalid amount"}`))
+	}))
+	defer server.Close()
+
+	if _, err := NewHTTPPaystackClient("sk_test_secret", server.URL).InitializeTransaction(models.PaystackInitializeRequest{}); err == nil {
+		t.Error("InitializeTransaction succeeded on a Paystack error")
+	}
+
+	// Nothing is sent without a secret key
+	if _, err := NewHTTPPaystackClient("", server.URL).InitializeTransaction(models.PaystackInitializeRequest{}); err == nil {
+		t.Error("InitializeTransaction succeeded without a secret key")
+	}
+}
+
+func TestHTTPPaystackClientVerifyTransaction(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.EscapedPath() != "/transaction/verify/PB%2F123" {
+			t.Errorf("request = %s %s, want GET /transaction/verify/PB%%2F123", r.Method, r.URL.EscapedPath())
+		}
+		w.Write([]byte(`{"status":true,"message":"Verification successful","data":{
+			"reference":"PB/123","amount":2500000,"currency":"NGN","status":"success",
+			"customer":{"email":"ada@example.com"},"metadata":"{\"program_id\":\"4\"}"}}`))
+	}))
+	defer server.Close()
+
+	data, err := NewHTTPPaystackClient("sk_test_secret", server.URL).VerifyTransaction("PB/123")
+	if err != nil {
+		t.Fatalf("VerifyTransaction error = %v", err)
+	}
+	if data.Status != "success" || data.Amount != 2500000 || data.MetadataInt("program_id") != 4 {
+		t.Errorf("VerifyTransaction = %+v", data)
+	}
+}
+
+func TestFakePaystackClientVerifyTransaction(t *testing.T) {
+	client := NewFakePaystackClient()
+	client.Transactions["PB-1"] = models.PaystackWebhookData{Reference: "PB-1", Status: "success"}
+
+	if data, _ := client.VerifyTransaction("PB-1"); data.Status != "success" {
+		t.Errorf("stored transaction verified as %q", data.Status)
+	}
+	if data, _ := client.VerifyTransaction("PB-2"); data.Status != "abandoned" || data.Reference != "PB-2" {
+		t.Errorf("unknown transaction verified as %+v, want abandoned", data)
+	}
+}
+
+func TestHTTPPaystackClientCreateRefund(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/refund" {
+			t.Errorf("request = %s %s, want POST /refund", r.Method, r.URL.Path)
+		}
+		var req models.PaystackRefundRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("invalid request body: %v", err)
+		}
+		if req.Transaction != "PB-123" || req.Amount != 500000 {
+			t.Errorf("request = %+v", req)
+		}
+		w.Write([]byte(`{"status":true,"message":"Refund has been queued for processing","data":{
+			"id":3018284,"transaction_reference":"PB-123","amount":500000,"currency":"NGN","status":"pending"}}`))
+	}))
+	defer server.Close()
+
+	refund, err := NewHTTPPaystackClient("sk_test_secret", server.URL).CreateRefund(models.PaystackRefundRequest{
+		Transaction: "PB-123",
+		Amount:      500000,
+	})
+	if err != nil {
+		t.Fatalf("CreateRefund error = %v", err)
+	}
+	if refund.ID.String() != "3018284" || refund.Status != models.RefundStatusPending {
+		t.Errorf("CreateRefund = %+v", refund)
+	}
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"plantbased-backend/models"
+)
+
+// ErrNotEntitled is returned when a customer asks for a program they have not paid for
+var ErrNotEntitled = errors.New("you are not enrolled in this program")
+
+// PortalService serves a logged-in customer's programs, orders, receipts and
+// subscriptions. Records are matched to the account by email.
+type PortalService struct {
+	DB                  *sql.DB
+	customerAuthService *CustomerAuthService
+	programService      *ProgramService
+}
+
+func NewPortalService(db *sql.DB, customerAuthService *CustomerAuthService, programService *ProgramService) *PortalService {
+	return &PortalService{
+		DB:                  db,
+		customerAuthService: customerAuthService,
+		programService:      programService,
+	}
+}
+
+// ErrAccountInactive is returned for deactivated customer accounts
+var ErrAccountInactive = errors.New("account is inactive")
+
+// ErrInvoiceNotFound is returned for receipts that do not exist or belong to
+// another customer
+var ErrInvoiceNotFound = errors.New("invoice not found")
+
+// activeAccount retrieves the customer's account, rejecting deactivated ones
+// whose tokens have not expired yet
+func (s *PortalService) activeAccount(customerID int) (*models.CustomerAccount, error) {
+	account, err := s.customerAuthService.GetAccountByID(customerID)
+	if err != nil {
+		return nil, err
+	}
+	if !account.IsActive {
+		return nil, ErrAccountInactive
+	}
+	return account, nil
+}
+
+// GetAccount retrieves the customer's account
+func (s *PortalService) GetAccount(customerID int) (*models.CustomerAccount, error) {
+	return s.activeAccount(customerID)
+}
+
+// entitlementsQuery lists the programs an email is entitled to, one row per
+// purchase or subscription. One-off plans grant access once paid; recurring
+// plans grant access while the subscription is active or past due, and until
+// the end of the paid period after it is cancelled.
+const entitlementsQuery = `
+	SELECT o.program_id, o.plan_name, '` + models.ProgramAccessPurchase + `', '', NULL::timestamp,
+		COALESCE(o.paid_at, o.created_at)
+	FROM orders o
+	LEFT JOIN program_pricing_plans p ON p.id = o.plan_id
+	WHERE LOWER(o.customer_email) = $1
+		AND o.program_id IS NOT NULL
+		AND o.status IN (` + models.OrderStatusesGrantingAccess + `)
+		AND COALESCE(p.billing_interval, '` + models.BillingIntervalOneOff + `') = '` + models.BillingIntervalOneOff + `'
+	UNION ALL
+	SELECT sub.program_id, sub.plan_name, '` + models.ProgramAccessSubscription + `', sub.status,
+		CASE WHEN sub.status = $4 THEN sub.next_payment_date END, sub.created_at
+	FROM subscriptions sub
+	WHERE LOWER(sub.customer_email) = $1
+		AND sub.program_id IS NOT NULL
+		AND (sub.status IN ($2, $3) OR (sub.status = $4 AND sub.next_payment_date > NOW()))
+	ORDER BY 6`
+
+// GetPrograms lists the programs the customer is entitled to, with full content
+func (s *PortalService) GetPrograms(customerID int) ([]models.EnrolledProgram, error) {
+	account, err := s.activeAccount(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.DB.Query(entitlementsQuery, normalizeEmail(account.Email),
+		models.SubscriptionStatusActive, models.SubscriptionStatusPastDue, models.SubscriptionStatusCancelled,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// A program can be bought more than once; keep the first entitlement,
+	// preferring a purchase over a subscription
+	var order []int
+	entitlements := map[int]*models.EnrolledProgram{}
+	for rows.Next() {
+		var programID int
+		var enrolled models.EnrolledProgram
+		err := rows.Scan(
+			&programID, &enrolled.PlanName, &enrolled.Access, &enrolled.SubscriptionStatus,
+			&enrolled.AccessUntil, &enrolled.EnrolledAt,
+		)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		existing, seen := entitlements[programID]
+		if !seen {
+			order = append(order, programID)
+			entitlements[programID] = &enrolled
+		} else if existing.Access != models.ProgramAccessPurchase && enrolled.Access == models.ProgramAccessPurchase {
+			entitlements[programID] = &enrolled
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	programs := []models.EnrolledProgram{}
+	for _, programID := range order {
+		program, err := s.programService.GetProgramByID(programID)
+		if err != nil {
+			// The program may have been deleted since it was bought
+			continue
+		}
+
+		enrolled := entitlements[programID]
+		enrolled.Program = *program
+		programs = append(programs, *enrolled)
+	}
+
+	return programs, nil
+}
+
+// GetProgram retrieves one program's full content if the customer is entitled to it
+func (s *PortalService) GetProgram(customerID, programID int) (*models.EnrolledProgram, error) {
+	programs, err := s.GetPrograms(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, program := range programs {
+		if program.Program.ID == programID {
+			return &program, nil
+		}
+	}
+	return nil, ErrNotEntitled
+}
+
+// CheckEntitlement returns ErrNotEntitled unless the customer is entitled to
+// the program. It answers with a single query for when the content itself is
+// not needed.
+func (s *PortalService) CheckEntitlement(customerID, programID int) error {
+	account, err := s.activeAccount(customerID)
+	if err != nil {
+		return err
+	}
+
+	var entitled bool
+	err = s.DB.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM (`+entitlementsQuery+`) entitlements WHERE program_id = $5)
+	`, normalizeEmail(account.Email),
+		models.SubscriptionStatusActive, models.SubscriptionStatusPastDue, models.SubscriptionStatusCancelled,
+		programID,
+	).Scan(&entitled)
+	if err != nil {
+		return err
+	}
+	if !entitled {
+		return ErrNotEntitled
+	}
+	return nil
+}
+
+// GetOrders lists the customer's orders, newest first
+func (s *PortalService) GetOrders(customerID int) ([]models.Order, error) {
+	account, err := s.activeAccount(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.DB.Query(`
+		SELECT `+orderColumns+` FROM orders WHERE LOWER(customer_email) = $1 ORDER BY created_at DESC
+	`, normalizeEmail(account.Email))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []models.Order{}
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *order)
+	}
+
+	return orders, rows.Err()
+}
+
+// GetInvoices lists the customer's receipts, newest first
+func (s *PortalService) GetInvoices(customerID int) ([]models.Invoice, error) {
+	account, err := s.activeAccount(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.DB.Query(`
+		SELECT `+invoiceColumns+` FROM invoices WHERE LOWER(customer_email) = $1 ORDER BY sequence DESC
+	`, normalizeEmail(account.Email))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invoices := []models.Invoice{}
+	for rows.Next() {
+		invoice, err := scanInvoice(rows)
+		if err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, *invoice)
+	}
+
+	return invoices, rows.Err()
+}
+
+// GetInvoicePDF retrieves the PDF of one of the customer's receipts
+func (s *PortalService) GetInvoicePDF(customerID, invoiceID int) (string, []byte, error) {
+	account, err := s.activeAccount(customerID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var number string
+	var pdf []byte
+	err = s.DB.QueryRow(`
+		SELECT invoice_number, pdf FROM invoices WHERE id = $1 AND LOWER(customer_email) = $2
+	`, invoiceID, normalizeEmail(account.Email)).Scan(&number, &pdf)
+	if err == sql.ErrNoRows {
+		return "", nil, ErrInvoiceNotFound
+	}
+	return number, pdf, err
+}
+
+// GetSubscriptions lists the customer's subscriptions, newest first
+func (s *PortalService) GetSubscriptions(customerID int) ([]models.Subscription, error) {
+	account, err := s.activeAccount(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.DB.Query(`
+		SELECT `+subscriptionColumns+` FROM subscriptions WHERE LOWER(customer_email) = $1 ORDER BY created_at DESC
+	`, normalizeEmail(account.Email))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []models.Subscription{}
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, *subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
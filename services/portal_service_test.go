@@ -0,0 +1,50 @@
+package services
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPortalRejectsInactiveAccounts(t *testing.T) {
+	db, fake := newFakeDB(t)
+	now := time.Now()
+	fake.on("FROM customer_accounts", []string{"id", "email", "full_name", "is_active", "last_login_at", "created_at", "updated_at"},
+		[]driver.Value{int64(7), "ada@example.com", "Adaeze Okafor", false, nil, now, now},
+	)
+
+	s := &PortalService{DB: db, customerAuthService: &CustomerAuthService{DB: db}}
+	if _, err := s.GetOrders(7); !errors.Is(err, ErrAccountInactive) {
+		t.Errorf("GetOrders error = %v, want ErrAccountInactive", err)
+	}
+	if _, err := s.GetPrograms(7); !errors.Is(err, ErrAccountInactive) {
+		t.Errorf("GetPrograms error = %v, want ErrAccountInactive", err)
+	}
+	if queries := fake.ran("FROM orders"); len(queries) != 0 {
+		t.Errorf("%d order queries for an inactive account, want none", len(queries))
+	}
+}
+
+func TestPortalMatchesRecordsByEmail(t *testing.T) {
+	db, fake := newFakeDB(t)
+	now := time.Now()
+	fake.on("FROM customer_accounts", []string{"id", "email", "full_name", "is_active", "last_login_at", "created_at", "updated_at"},
+		[]driver.Value{int64(7), "Ada@Example.com", "Adaeze Okafor", true, nil, now, now},
+	)
+	fake.on("FROM orders", nil)
+
+	s := &PortalService{DB: db, customerAuthService: &CustomerAuthService{DB: db}}
+	orders, err := s.GetOrders(7)
+	if err != nil {
+		t.Fatalf("GetOrders error = %v", err)
+	}
+	if orders == nil || len(orders) != 0 {
+		t.Errorf("GetOrders = %v, want an empty list", orders)
+	}
+
+	queries := fake.ran("FROM orders")
+	if len(queries) != 1 || queries[0].args[0] != "ada@example.com" {
+		t.Errorf("order queries = %v, want one by lowercased email", queries)
+	}
+}
@@ -4,17 +4,95 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"mime/multipart"
 	"plantbased-backend/models"
 	"plantbased-backend/utils"
+	"strings"
 )
 
 type ProgramService struct {
-	DB *sql.DB
+	DB     *sql.DB
+	client PaystackClient
 }
 
-func NewProgramService(db *sql.DB) *ProgramService {
-	return &ProgramService{DB: db}
+func NewProgramService(db *sql.DB, client PaystackClient) *ProgramService {
+	return &ProgramService{
+		DB:     db,
+		client: client,
+	}
+}
+
+const pricingPlanColumns = `id, program_id, name, subtitle, COALESCE(price_amount, 0), currency,
+	billing_interval, COALESCE(paystack_plan_code, ''), features, created_at, updated_at`
+
+func scanPricingPlan(row rowScanner) (*models.ProgramPricingPlan, error) {
+	var plan models.ProgramPricingPlan
+	var featuresJSON []byte
+	err := row.Scan(
+		&plan.ID, &plan.ProgramID, &plan.Name, &plan.Subtitle, &plan.Price.Amount, &plan.Price.Currency,
+		&plan.Interval, &plan.PlanCode, &featuresJSON, &plan.CreatedAt, &plan.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	json.Unmarshal(featuresJSON, &plan.Features)
+	return &plan, nil
+}
+
+// ErrInvalidPricingPlan is returned when a pricing plan fails validation
+var ErrInvalidPricingPlan = errors.New("invalid pricing plan")
+
+// Program lookup errors
+var (
+	ErrProgramNotFound     = errors.New("program not found")
+	ErrPricingPlanNotFound = errors.New("pricing plan not found")
+)
+
+// validatePricingPlan checks the name, price and billing interval of a
+// pricing plan, defaulting the interval to one-off
+func validatePricingPlan(req *models.PricingPlanRequest) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidPricingPlan)
+	}
+	if err := req.Price.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPricingPlan, err)
+	}
+	if req.Interval == "" {
+		req.Interval = models.BillingIntervalOneOff
+	}
+	if !models.IsValidBillingInterval(req.Interval) {
+		return fmt.Errorf("%w: interval must be one of one_off, monthly or quarterly", ErrInvalidPricingPlan)
+	}
+	return nil
+}
+
+// syncPaystackPlan creates or updates the Paystack plan behind a recurring
+// pricing plan and returns its plan code. One-off plans have no Paystack plan.
+func (s *ProgramService) syncPaystackPlan(programName string, req models.PricingPlanRequest, planCode string) (string, error) {
+	if req.Interval == models.BillingIntervalOneOff {
+		return "", nil
+	}
+
+	plan := models.PaystackPlanRequest{
+		Name:     programName + " - " + req.Name,
+		Amount:   req.Price.Amount,
+		Interval: req.Interval,
+		Currency: req.Price.Currency,
+	}
+
+	if planCode != "" {
+		if err := s.client.UpdatePlan(planCode, plan); err != nil {
+			return "", fmt.Errorf("failed to update Paystack plan: %w", err)
+		}
+		return planCode, nil
+	}
+
+	created, err := s.client.CreatePlan(plan)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Paystack plan: %w", err)
+	}
+	return created.PlanCode, nil
 }
 
 // CreateProgram creates a new program with images and pricing plans
@@ -22,6 +100,11 @@ func (s *ProgramService) CreateProgram(
 	req models.CreateProgramRequest,
 	images map[string]multipart.File,
 ) (*models.ProgramResponse, error) {
+	for i := range req.PricingPlans {
+		if err := validatePricingPlan(&req.PricingPlans[i]); err != nil {
+			return nil, err
+		}
+	}
 
 	// Upload all images to Cloudinary
 	mainImage, err := utils.UploadImage(images["mainImage"], "programs")
@@ -105,12 +188,18 @@ func (s *ProgramService) CreateProgram(
 	for _, plan := range req.PricingPlans {
 		featuresJSON, _ := json.Marshal(plan.Features)
 
+		planCode, err := s.syncPaystackPlan(req.Name, plan, "")
+		if err != nil {
+			return nil, err
+		}
+
 		var planID int
 		err = s.DB.QueryRow(`
-			INSERT INTO program_pricing_plans (program_id, name, subtitle, price, features)
-			VALUES ($1, $2, $3, $4, $5)
+			INSERT INTO program_pricing_plans (program_id, name, subtitle, price_amount, currency, billing_interval, paystack_plan_code, features)
+			VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)
 			RETURNING id
-		`, programID, plan.Name, plan.Subtitle, plan.Price, featuresJSON).Scan(&planID)
+		`, programID, plan.Name, plan.Subtitle, plan.Price.Amount, plan.Price.Currency,
+			plan.Interval, planCode, featuresJSON).Scan(&planID)
 
 		if err != nil {
 			return nil, err
@@ -122,6 +211,8 @@ func (s *ProgramService) CreateProgram(
 			Name:      plan.Name,
 			Subtitle:  plan.Subtitle,
 			Price:     plan.Price,
+			Interval:  plan.Interval,
+			PlanCode:  planCode,
 			Features:  plan.Features,
 		})
 	}
@@ -144,12 +235,31 @@ func (s *ProgramService) UpdateProgram(
 	req models.CreateProgramRequest,
 	images map[string]multipart.File,
 ) (*models.ProgramResponse, error) {
+	for i := range req.PricingPlans {
+		if err := validatePricingPlan(&req.PricingPlans[i]); err != nil {
+			return nil, err
+		}
+	}
+
 	// Check if program exists
 	existingProgram, err := s.GetProgramByID(id)
 	if err != nil {
 		return nil, err
 	}
 
+	var existingPlans []models.ProgramPricingPlan
+	var matchedPlans []*models.ProgramPricingPlan
+	if len(req.PricingPlans) > 0 {
+		existingPlans, err = s.GetPricingPlansByProgramID(id)
+		if err != nil {
+			return nil, err
+		}
+		matchedPlans, err = matchPricingPlans(existingPlans, req.PricingPlans)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Handle image updates (if new images provided)
 	mainImagePublicID := existingProgram.MainImagePublicID
 	mainImageURL := existingProgram.MainImageURL
@@ -259,25 +369,62 @@ func (s *ProgramService) UpdateProgram(
 		return nil, err
 	}
 
-	// Update pricing plans (delete old ones and insert new ones)
+	// Update pricing plans in place, so orders, leads and subscriptions keep
+	// pointing at them; add the new ones and delete those left out
 	if len(req.PricingPlans) > 0 {
-		// Delete existing pricing plans
-		_, err = s.DB.Exec("DELETE FROM program_pricing_plans WHERE program_id = $1", id)
-		if err != nil {
-			return nil, err
-		}
-
-		// Insert new pricing plans
-		for _, plan := range req.PricingPlans {
+		kept := make(map[int]bool)
+		for i, plan := range req.PricingPlans {
 			featuresJSON, _ := json.Marshal(plan.Features)
+
+			existing := matchedPlans[i]
+			if existing == nil {
+				planCode, err := s.syncPaystackPlan(req.Name, plan, "")
+				if err != nil {
+					return nil, err
+				}
+
+				_, err = s.DB.Exec(`
+					INSERT INTO program_pricing_plans (program_id, name, subtitle, price_amount, currency, billing_interval, paystack_plan_code, features)
+					VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)
+				`, id, plan.Name, plan.Subtitle, plan.Price.Amount, plan.Price.Currency, plan.Interval, planCode, featuresJSON)
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			kept[existing.ID] = true
+
+			// A Paystack plan's interval cannot change, so a new interval gets a new plan
+			planCode := existing.PlanCode
+			if existing.Interval != plan.Interval {
+				planCode = ""
+			}
+			planCode, err := s.syncPaystackPlan(req.Name, plan, planCode)
+			if err != nil {
+				return nil, err
+			}
+
 			_, err = s.DB.Exec(`
-				INSERT INTO program_pricing_plans (program_id, name, subtitle, price, features)
-				VALUES ($1, $2, $3, $4, $5)
-			`, id, plan.Name, plan.Subtitle, plan.Price, featuresJSON)
+				UPDATE program_pricing_plans
+				SET name = $1, subtitle = $2, price_amount = $3, currency = $4, billing_interval = $5,
+					paystack_plan_code = NULLIF($6, ''), features = $7, updated_at = NOW()
+				WHERE id = $8 AND program_id = $9
+			`, plan.Name, plan.Subtitle, plan.Price.Amount, plan.Price.Currency, plan.Interval,
+				planCode, featuresJSON, existing.ID, id)
 			if err != nil {
 				return nil, err
 			}
 		}
+
+		for _, plan := range existingPlans {
+			if kept[plan.ID] {
+				continue
+			}
+			if _, err := s.DB.Exec("DELETE FROM program_pricing_plans WHERE id = $1 AND program_id = $2", plan.ID, id); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	// Get updated program
@@ -297,6 +444,50 @@ func (s *ProgramService) UpdateProgram(
 	}, nil
 }
 
+// matchPricingPlans pairs each requested plan with the existing plan it
+// updates, or nil for a new plan. Plans are matched by ID; requests without
+// one fall back to the name and interval, so clients that do not send IDs
+// still update plans in place.
+func matchPricingPlans(existing []models.ProgramPricingPlan, requested []models.PricingPlanRequest) ([]*models.ProgramPricingPlan, error) {
+	byID := make(map[int]*models.ProgramPricingPlan)
+	for i := range existing {
+		byID[existing[i].ID] = &existing[i]
+	}
+
+	matched := make([]*models.ProgramPricingPlan, len(requested))
+	taken := make(map[int]bool)
+	for i, req := range requested {
+		if req.ID == 0 {
+			continue
+		}
+		plan, ok := byID[req.ID]
+		if !ok {
+			return nil, fmt.Errorf("%w: plan %d does not belong to this program", ErrInvalidPricingPlan, req.ID)
+		}
+		if taken[req.ID] {
+			return nil, fmt.Errorf("%w: plan %d is listed more than once", ErrInvalidPricingPlan, req.ID)
+		}
+		taken[req.ID] = true
+		matched[i] = plan
+	}
+
+	for i, req := range requested {
+		if req.ID != 0 {
+			continue
+		}
+		for j := range existing {
+			plan := &existing[j]
+			if !taken[plan.ID] && strings.EqualFold(plan.Name, req.Name) && plan.Interval == req.Interval {
+				taken[plan.ID] = true
+				matched[i] = plan
+				break
+			}
+		}
+	}
+
+	return matched, nil
+}
+
 // GetAllPrograms retrieves all programs with their pricing plans
 func (s *ProgramService) GetAllPrograms() ([]models.ProgramResponse, error) {
 	rows, err := s.DB.Query(`
@@ -370,7 +561,7 @@ func (s *ProgramService) GetProgramByID(id int) (*models.Program, error) {
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, errors.New("program not found")
+		return nil, ErrProgramNotFound
 	}
 
 	if err != nil {
@@ -383,7 +574,7 @@ func (s *ProgramService) GetProgramByID(id int) (*models.Program, error) {
 // GetPricingPlansByProgramID retrieves all pricing plans for a program
 func (s *ProgramService) GetPricingPlansByProgramID(programID int) ([]models.ProgramPricingPlan, error) {
 	rows, err := s.DB.Query(`
-		SELECT id, program_id, name, subtitle, price, features, created_at, updated_at
+		SELECT `+pricingPlanColumns+`
 		FROM program_pricing_plans
 		WHERE program_id = $1
 		ORDER BY id ASC
@@ -396,78 +587,134 @@ func (s *ProgramService) GetPricingPlansByProgramID(programID int) ([]models.Pro
 
 	var plans []models.ProgramPricingPlan
 	for rows.Next() {
-		var plan models.ProgramPricingPlan
-		var featuresJSON []byte
-
-		err := rows.Scan(
-			&plan.ID, &plan.ProgramID, &plan.Name, &plan.Subtitle,
-			&plan.Price, &featuresJSON, &plan.CreatedAt, &plan.UpdatedAt,
-		)
+		plan, err := scanPricingPlan(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		// Unmarshal features JSON
-		json.Unmarshal(featuresJSON, &plan.Features)
-		plans = append(plans, plan)
+		plans = append(plans, *plan)
 	}
 
 	return plans, nil
 }
 
+// GetPricingPlanByID retrieves a single pricing plan belonging to a program
+func (s *ProgramService) GetPricingPlanByID(programID, planID int) (*models.ProgramPricingPlan, error) {
+	plan, err := scanPricingPlan(s.DB.QueryRow(`
+		SELECT `+pricingPlanColumns+`
+		FROM program_pricing_plans
+		WHERE id = $1 AND program_id = $2
+	`, planID, programID))
+
+	if err == sql.ErrNoRows {
+		return nil, ErrPricingPlanNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// GetPricingPlanByPlanCode retrieves the recurring pricing plan behind a Paystack plan
+func (s *ProgramService) GetPricingPlanByPlanCode(planCode string) (*models.ProgramPricingPlan, error) {
+	plan, err := scanPricingPlan(s.DB.QueryRow(`
+		SELECT `+pricingPlanColumns+`
+		FROM program_pricing_plans
+		WHERE paystack_plan_code = $1
+	`, planCode))
+
+	if err == sql.ErrNoRows {
+		return nil, ErrPricingPlanNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
 // AddPricingPlan adds a pricing plan to an existing program
 func (s *ProgramService) AddPricingPlan(programID int, req models.PricingPlanRequest) (*models.ProgramPricingPlan, error) {
+	if err := validatePricingPlan(&req); err != nil {
+		return nil, err
+	}
+
 	// Verify program exists
-	_, err := s.GetProgramByID(programID)
+	program, err := s.GetProgramByID(programID)
+	if err != nil {
+		return nil, err
+	}
+
+	planCode, err := s.syncPaystackPlan(program.Name, req, "")
 	if err != nil {
 		return nil, err
 	}
 
 	featuresJSON, _ := json.Marshal(req.Features)
 
-	var plan models.ProgramPricingPlan
-	err = s.DB.QueryRow(`
-		INSERT INTO program_pricing_plans (program_id, name, subtitle, price, features)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, program_id, name, subtitle, price, features, created_at, updated_at
-	`, programID, req.Name, req.Subtitle, req.Price, featuresJSON).Scan(
-		&plan.ID, &plan.ProgramID, &plan.Name, &plan.Subtitle,
-		&plan.Price, &featuresJSON, &plan.CreatedAt, &plan.UpdatedAt,
-	)
+	plan, err := scanPricingPlan(s.DB.QueryRow(`
+		INSERT INTO program_pricing_plans (program_id, name, subtitle, price_amount, currency, billing_interval, paystack_plan_code, features)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)
+		RETURNING `+pricingPlanColumns,
+		programID, req.Name, req.Subtitle, req.Price.Amount, req.Price.Currency, req.Interval, planCode, featuresJSON,
+	))
 
 	if err != nil {
 		return nil, err
 	}
 
-	json.Unmarshal(featuresJSON, &plan.Features)
-	return &plan, nil
+	return plan, nil
 }
 
 // UpdatePricingPlan updates a specific pricing plan
 func (s *ProgramService) UpdatePricingPlan(programID, planID int, req models.PricingPlanRequest) (*models.ProgramPricingPlan, error) {
+	if err := validatePricingPlan(&req); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.GetPricingPlanByID(programID, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := s.GetProgramByID(programID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A Paystack plan's interval cannot change, so a new interval gets a new plan
+	planCode := existing.PlanCode
+	if existing.Interval != req.Interval {
+		planCode = ""
+	}
+	planCode, err = s.syncPaystackPlan(program.Name, req, planCode)
+	if err != nil {
+		return nil, err
+	}
+
 	featuresJSON, _ := json.Marshal(req.Features)
 
-	var plan models.ProgramPricingPlan
-	err := s.DB.QueryRow(`
+	plan, err := scanPricingPlan(s.DB.QueryRow(`
 		UPDATE program_pricing_plans
-		SET name = $1, subtitle = $2, price = $3, features = $4, updated_at = NOW()
-		WHERE id = $5 AND program_id = $6
-		RETURNING id, program_id, name, subtitle, price, features, created_at, updated_at
-	`, req.Name, req.Subtitle, req.Price, featuresJSON, planID, programID).Scan(
-		&plan.ID, &plan.ProgramID, &plan.Name, &plan.Subtitle,
-		&plan.Price, &featuresJSON, &plan.CreatedAt, &plan.UpdatedAt,
-	)
+		SET name = $1, subtitle = $2, price_amount = $3, currency = $4, billing_interval = $5,
+			paystack_plan_code = NULLIF($6, ''), features = $7, updated_at = NOW()
+		WHERE id = $8 AND program_id = $9
+		RETURNING `+pricingPlanColumns,
+		req.Name, req.Subtitle, req.Price.Amount, req.Price.Currency, req.Interval,
+		planCode, featuresJSON, planID, programID,
+	))
 
 	if err == sql.ErrNoRows {
-		return nil, errors.New("pricing plan not found")
+		return nil, ErrPricingPlanNotFound
 	}
 
 	if err != nil {
 		return nil, err
 	}
 
-	json.Unmarshal(featuresJSON, &plan.Features)
-	return &plan, nil
+	return plan, nil
 }
 
 // DeletePricingPlan deletes a specific pricing plan
@@ -483,7 +730,7 @@ func (s *ProgramService) DeletePricingPlan(programID, planID int) error {
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		return errors.New("pricing plan not found")
+		return ErrPricingPlanNotFound
 	}
 
 	return nil
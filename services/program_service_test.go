@@ -0,0 +1,112 @@
+package services
+
+import (
+	"errors"
+	"plantbased-backend/models"
+	"testing"
+)
+
+func TestValidatePricingPlan(t *testing.T) {
+	req := models.PricingPlanRequest{Name: "Standard", Price: models.Money{Amount: 2500000, Currency: "NGN"}}
+	if err := validatePricingPlan(&req); err != nil {
+		t.Fatalf("validatePricingPlan error = %v", err)
+	}
+	if req.Interval != models.BillingIntervalOneOff {
+		t.Errorf("interval = %q, want plans to default to one_off", req.Interval)
+	}
+
+	invalid := []models.PricingPlanRequest{
+		{Name: " ", Price: models.Money{Amount: 2500000, Currency: "NGN"}},
+		{Name: "Standard", Price: models.Money{Amount: 0, Currency: "NGN"}},
+		{Name: "Standard", Price: models.Money{Amount: 2500000, Currency: "XYZ"}},
+		{Name: "Standard", Price: models.Money{Amount: 2500000, Currency: "NGN"}, Interval: "weekly"},
+	}
+	for _, req := range invalid {
+		if err := validatePricingPlan(&req); !errors.Is(err, ErrInvalidPricingPlan) {
+			t.Errorf("validatePricingPlan(%+v) error = %v, want ErrInvalidPricingPlan", req, err)
+		}
+	}
+}
+
+func TestSyncPaystackPlan(t *testing.T) {
+	client := NewFakePaystackClient()
+	s := &ProgramService{client: client}
+	price := models.Money{Amount: 1500000, Currency: "NGN"}
+
+	// One-off plans have no Paystack plan
+	code, err := s.syncPaystackPlan("Gut Reset", models.PricingPlanRequest{
+		Name: "Full", Price: price, Interval: models.BillingIntervalOneOff,
+	}, "")
+	if err != nil || code != "" || len(client.Plans) != 0 {
+		t.Fatalf("one-off plan: code %q, error %v, %d Paystack plans", code, err, len(client.Plans))
+	}
+
+	monthly := models.PricingPlanRequest{Name: "Monthly", Price: price, Interval: models.BillingIntervalMonthly}
+	code, err = s.syncPaystackPlan("Gut Reset", monthly, "")
+	if err != nil || code == "" {
+		t.Fatalf("recurring plan: code %q, error %v", code, err)
+	}
+	created := client.Plans[code]
+	if created.Name != "Gut Reset - Monthly" || created.Amount != 1500000 || created.Interval != "monthly" {
+		t.Errorf("created plan = %+v", created)
+	}
+
+	// An existing plan is updated under the same code
+	monthly.Price.Amount = 1800000
+	updated, err := s.syncPaystackPlan("Gut Reset", monthly, code)
+	if err != nil || updated != code {
+		t.Fatalf("update: code %q, error %v, want %q", updated, err, code)
+	}
+	if client.Plans[code].Amount != 1800000 || len(client.Plans) != 1 {
+		t.Errorf("plans after update = %+v", client.Plans)
+	}
+}
+
+func TestMatchPricingPlans(t *testing.T) {
+	existing := []models.ProgramPricingPlan{
+		{ID: 1, Name: "Basic", Interval: models.BillingIntervalOneOff},
+		{ID: 2, Name: "Premium", Interval: models.BillingIntervalOneOff},
+		{ID: 3, Name: "Premium", Interval: models.BillingIntervalMonthly},
+	}
+
+	matched, err := matchPricingPlans(existing, []models.PricingPlanRequest{
+		{ID: 2, Name: "Premium Plus", Interval: models.BillingIntervalOneOff},
+		{Name: "premium", Interval: models.BillingIntervalMonthly},
+		{Name: "Family", Interval: models.BillingIntervalOneOff},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []int{2, 3, 0}
+	for i, plan := range matched {
+		got := 0
+		if plan != nil {
+			got = plan.ID
+		}
+		if got != want[i] {
+			t.Errorf("plan %d matched existing plan %d, want %d", i, got, want[i])
+		}
+	}
+
+	// A plan renamed by ID is not matched again by name
+	matched, err = matchPricingPlans(existing, []models.PricingPlanRequest{
+		{ID: 1, Name: "Starter", Interval: models.BillingIntervalOneOff},
+		{Name: "Basic", Interval: models.BillingIntervalOneOff},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if matched[1] != nil {
+		t.Errorf("second plan matched existing plan %d, want a new plan", matched[1].ID)
+	}
+
+	for _, requested := range [][]models.PricingPlanRequest{
+		{{ID: 9, Name: "Basic"}},
+		{{ID: 1, Name: "Basic"}, {ID: 1, Name: "Basic"}},
+	} {
+		if _, err := matchPricingPlans(existing, requested); !errors.Is(err, ErrInvalidPricingPlan) {
+			t.Errorf("matchPricingPlans(%+v) error = %v, want ErrInvalidPricingPlan", requested, err)
+		}
+	}
+}
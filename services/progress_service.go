@@ -0,0 +1,302 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"plantbased-backend/models"
+	"strings"
+	"time"
+)
+
+// ErrInvalidProgress is returned for invalid section or check-in updates
+var ErrInvalidProgress = errors.New("invalid progress update")
+
+const checkInColumns = `id, program_id, TO_CHAR(check_in_date, 'YYYY-MM-DD'), weight_kg::float8, energy_level,
+	symptoms, notes, created_at, updated_at`
+
+// ProgressService tracks enrolled customers' progress through program
+// sections and their daily check-ins
+type ProgressService struct {
+	DB            *sql.DB
+	portalService *PortalService
+}
+
+func NewProgressService(db *sql.DB, portalService *PortalService) *ProgressService {
+	return &ProgressService{
+		DB:            db,
+		portalService: portalService,
+	}
+}
+
+func scanCheckIn(row rowScanner) (*models.CheckIn, error) {
+	var checkIn models.CheckIn
+	var symptomsJSON []byte
+	err := row.Scan(
+		&checkIn.ID, &checkIn.ProgramID, &checkIn.Date, &checkIn.WeightKg, &checkIn.EnergyLevel,
+		&symptomsJSON, &checkIn.Notes, &checkIn.CreatedAt, &checkIn.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	checkIn.Symptoms = []string{}
+	if err := json.Unmarshal(symptomsJSON, &checkIn.Symptoms); err != nil {
+		return nil, fmt.Errorf("check-in %d has invalid symptoms: %w", checkIn.ID, err)
+	}
+	return &checkIn, nil
+}
+
+// GetProgress retrieves the customer's completed sections of a program
+func (s *ProgressService) GetProgress(customerID, programID int) (*models.ProgramProgress, error) {
+	if err := s.portalService.CheckEntitlement(customerID, programID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.DB.Query(`
+		SELECT section, completed_at FROM program_section_progress
+		WHERE customer_id = $1 AND program_id = $2
+	`, customerID, programID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	completed := map[string]time.Time{}
+	for rows.Next() {
+		var section string
+		var completedAt time.Time
+		if err := rows.Scan(&section, &completedAt); err != nil {
+			return nil, err
+		}
+		completed[section] = completedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Report sections in program order, skipping any no longer in use
+	progress := models.ProgramProgress{
+		ProgramID:         programID,
+		CompletedSections: []models.SectionCompletion{},
+		TotalSections:     len(models.ProgramSections),
+	}
+	for _, section := range models.ProgramSections {
+		if completedAt, ok := completed[section]; ok {
+			progress.CompletedSections = append(progress.CompletedSections, models.SectionCompletion{
+				Section:     section,
+				CompletedAt: completedAt,
+			})
+		}
+	}
+	progress.PercentComplete = len(progress.CompletedSections) * 100 / progress.TotalSections
+
+	return &progress, nil
+}
+
+// SetSectionComplete marks a section complete, or not complete, for the customer
+func (s *ProgressService) SetSectionComplete(customerID, programID int, section string, complete bool) (*models.ProgramProgress, error) {
+	if !models.IsValidProgramSection(section) {
+		return nil, fmt.Errorf("%w: section must be one of %s", ErrInvalidProgress, strings.Join(models.ProgramSections, ", "))
+	}
+
+	if err := s.portalService.CheckEntitlement(customerID, programID); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if complete {
+		_, err = s.DB.Exec(`
+			INSERT INTO program_section_progress (customer_id, program_id, section)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (customer_id, program_id, section) DO NOTHING
+		`, customerID, programID, section)
+	} else {
+		_, err = s.DB.Exec(`
+			DELETE FROM program_section_progress WHERE customer_id = $1 AND program_id = $2 AND section = $3
+		`, customerID, programID, section)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetProgress(customerID, programID)
+}
+
+// RecordCheckIn stores the customer's check-in for a day, replacing any
+// earlier check-in for the same day
+func (s *ProgressService) RecordCheckIn(customerID, programID int, req models.CheckInRequest) (*models.CheckIn, error) {
+	date := time.Now()
+	if req.Date != "" {
+		parsed, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			return nil, fmt.Errorf("%w: date must be formatted YYYY-MM-DD", ErrInvalidProgress)
+		}
+		// Allow a day of slack for customers ahead of the server's time zone
+		if parsed.After(time.Now().AddDate(0, 0, 1)) {
+			return nil, fmt.Errorf("%w: date cannot be in the future", ErrInvalidProgress)
+		}
+		date = parsed
+	}
+
+	if req.WeightKg == nil && req.EnergyLevel == nil && len(req.Symptoms) == 0 && strings.TrimSpace(req.Notes) == "" {
+		return nil, fmt.Errorf("%w: a check-in needs a weight, energy level, symptoms or notes", ErrInvalidProgress)
+	}
+
+	if err := s.portalService.CheckEntitlement(customerID, programID); err != nil {
+		return nil, err
+	}
+
+	symptoms := []string{}
+	for _, symptom := range req.Symptoms {
+		if symptom = strings.TrimSpace(symptom); symptom != "" {
+			symptoms = append(symptoms, symptom)
+		}
+	}
+	symptomsJSON, _ := json.Marshal(symptoms)
+
+	return scanCheckIn(s.DB.QueryRow(`
+		INSERT INTO program_check_ins (customer_id, program_id, check_in_date, weight_kg, energy_level, symptoms, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (customer_id, program_id, check_in_date) DO UPDATE SET
+			weight_kg = EXCLUDED.weight_kg,
+			energy_level = EXCLUDED.energy_level,
+			symptoms = EXCLUDED.symptoms,
+			notes = EXCLUDED.notes,
+			updated_at = NOW()
+		RETURNING `+checkInColumns,
+		customerID, programID, date.Format("2006-01-02"), req.WeightKg, req.EnergyLevel,
+		string(symptomsJSON), strings.TrimSpace(req.Notes),
+	))
+}
+
+// GetCheckIns retrieves the customer's check-ins for a program, newest first
+func (s *ProgressService) GetCheckIns(customerID, programID int) ([]models.CheckIn, error) {
+	if err := s.portalService.CheckEntitlement(customerID, programID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.DB.Query(`
+		SELECT `+checkInColumns+` FROM program_check_ins
+		WHERE customer_id = $1 AND program_id = $2
+		ORDER BY check_in_date DESC
+	`, customerID, programID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checkIns := []models.CheckIn{}
+	for rows.Next() {
+		checkIn, err := scanCheckIn(rows)
+		if err != nil {
+			return nil, err
+		}
+		checkIns = append(checkIns, *checkIn)
+	}
+
+	return checkIns, rows.Err()
+}
+
+// GetCompletionStats summarizes progress for every program, or for one
+// program when programID is not zero
+func (s *ProgressService) GetCompletionStats(programID int) ([]models.ProgramCompletionStats, error) {
+	args := []interface{}{models.SubscriptionStatusActive, models.SubscriptionStatusPastDue}
+	placeholders := make([]string, len(models.ProgramSections))
+	for i, section := range models.ProgramSections {
+		args = append(args, section)
+		placeholders[i] = fmt.Sprintf("$%d", len(args))
+	}
+
+	// %[1]s is the list of section placeholders, %[2]d the number of sections
+	query := fmt.Sprintf(`
+		SELECT p.id, p.name,
+			(SELECT COUNT(DISTINCT LOWER(email)) FROM (
+				SELECT customer_email AS email FROM orders
+				WHERE program_id = p.id AND status IN (`+models.OrderStatusesGrantingAccess+`)
+				UNION
+				SELECT customer_email FROM subscriptions
+				WHERE program_id = p.id AND status IN ($1, $2)
+			) enrolled),
+			(SELECT COUNT(DISTINCT customer_id) FROM program_section_progress WHERE program_id = p.id),
+			(SELECT COUNT(*) FROM (
+				SELECT customer_id FROM program_section_progress
+				WHERE program_id = p.id AND section IN (%[1]s)
+				GROUP BY customer_id HAVING COUNT(*) = %[2]d
+			) completed),
+			(SELECT COALESCE(AVG(done) * 100.0 / %[2]d, 0) FROM (
+				SELECT COUNT(*) AS done FROM program_section_progress
+				WHERE program_id = p.id AND section IN (%[1]s)
+				GROUP BY customer_id
+			) started)::float8,
+			(SELECT COUNT(*) FROM program_check_ins WHERE program_id = p.id),
+			(SELECT COUNT(DISTINCT customer_id) FROM (
+				SELECT customer_id FROM program_section_progress
+				WHERE program_id = p.id AND completed_at > NOW() - INTERVAL '7 days'
+				UNION
+				SELECT customer_id FROM program_check_ins
+				WHERE program_id = p.id AND updated_at > NOW() - INTERVAL '7 days'
+			) active)
+		FROM programs p`, strings.Join(placeholders, ", "), len(models.ProgramSections))
+	if programID != 0 {
+		args = append(args, programID)
+		query += fmt.Sprintf(" WHERE p.id = $%d", len(args))
+	}
+	query += " ORDER BY p.name"
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []models.ProgramCompletionStats{}
+	index := map[int]int{}
+	for rows.Next() {
+		var st models.ProgramCompletionStats
+		err := rows.Scan(
+			&st.ProgramID, &st.ProgramName, &st.EnrolledCustomers, &st.StartedCustomers,
+			&st.CompletedCustomers, &st.AverageCompletion, &st.CheckIns, &st.ActiveLast7Days,
+		)
+		if err != nil {
+			return nil, err
+		}
+		st.SectionCompletions = map[string]int{}
+		for _, section := range models.ProgramSections {
+			st.SectionCompletions[section] = 0
+		}
+		index[st.ProgramID] = len(stats)
+		stats = append(stats, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sectionQuery := `
+		SELECT program_id, section, COUNT(*) FROM program_section_progress`
+	var sectionArgs []interface{}
+	if programID != 0 {
+		sectionQuery += " WHERE program_id = $1"
+		sectionArgs = append(sectionArgs, programID)
+	}
+	sectionQuery += " GROUP BY program_id, section"
+
+	sectionRows, err := s.DB.Query(sectionQuery, sectionArgs...)
+	if err != nil {
+		return nil, err
+	}
+	defer sectionRows.Close()
+
+	for sectionRows.Next() {
+		var id, count int
+		var section string
+		if err := sectionRows.Scan(&id, &section, &count); err != nil {
+			return nil, err
+		}
+		if i, ok := index[id]; ok && models.IsValidProgramSection(section) {
+			stats[i].SectionCompletions[section] = count
+		}
+	}
+
+	return stats, sectionRows.Err()
+}
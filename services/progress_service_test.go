@@ -0,0 +1,37 @@
+package services
+
+import (
+	"errors"
+	"plantbased-backend/models"
+	"testing"
+	"time"
+)
+
+func TestRecordCheckInValidation(t *testing.T) {
+	energy := 7
+	tomorrow := time.Now().AddDate(0, 0, 3).Format("2006-01-02")
+
+	tests := []struct {
+		name string
+		req  models.CheckInRequest
+	}{
+		{"bad date", models.CheckInRequest{Date: "18/10/2026", EnergyLevel: &energy}},
+		{"future date", models.CheckInRequest{Date: tomorrow, EnergyLevel: &energy}},
+		{"empty", models.CheckInRequest{Notes: "   "}},
+	}
+
+	// Invalid check-ins are rejected before the database is used
+	s := &ProgressService{}
+	for _, tt := range tests {
+		if _, err := s.RecordCheckIn(7, 3, tt.req); !errors.Is(err, ErrInvalidProgress) {
+			t.Errorf("%s: RecordCheckIn error = %v, want ErrInvalidProgress", tt.name, err)
+		}
+	}
+}
+
+func TestSetSectionCompleteRejectsUnknownSection(t *testing.T) {
+	s := &ProgressService{}
+	if _, err := s.SetSectionComplete(7, 3, "pricing", true); !errors.Is(err, ErrInvalidProgress) {
+		t.Errorf("SetSectionComplete error = %v, want ErrInvalidProgress", err)
+	}
+}
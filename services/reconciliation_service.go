@@ -0,0 +1,215 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"plantbased-backend/models"
+	"sync"
+	"time"
+)
+
+// Reconciliation errors
+var (
+	ErrReconciliationRunning = errors.New("a reconciliation run is already in progress")
+	ErrNoReconciliationRuns  = errors.New("no reconciliation runs yet")
+)
+
+// ReconciliationService verifies pending orders with Paystack for the cases
+// where the charge webhook never arrives. Every reference handed out at
+// checkout is stored as a pending order, so those are what gets checked.
+type ReconciliationService struct {
+	DB             *sql.DB
+	client         PaystackClient
+	webhookService *WebhookService
+	after          time.Duration
+	interval       time.Duration
+	running        sync.Mutex
+}
+
+func NewReconciliationService(
+	db *sql.DB,
+	client PaystackClient,
+	webhookService *WebhookService,
+	after, interval time.Duration,
+) *ReconciliationService {
+	return &ReconciliationService{
+		DB:             db,
+		client:         client,
+		webhookService: webhookService,
+		after:          after,
+		interval:       interval,
+	}
+}
+
+// Start runs reconciliation on a fixed interval until the process exits
+func (s *ReconciliationService) Start() {
+	if s.interval <= 0 {
+		log.Println("Payment reconciliation disabled (interval is not positive)")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if _, err := s.Run("scheduled"); err != nil && err != ErrReconciliationRunning {
+				log.Printf("Payment reconciliation failed: %v", err)
+			}
+		}
+	}()
+}
+
+// Run verifies every order still pending after the configured delay and
+// records the outcome as a reconciliation run
+func (s *ReconciliationService) Run(trigger string) (*models.ReconciliationRun, error) {
+	if !s.running.TryLock() {
+		return nil, ErrReconciliationRunning
+	}
+	defer s.running.Unlock()
+
+	run := models.ReconciliationRun{Trigger: trigger, Errors: []string{}}
+	err := s.DB.QueryRow(`
+		INSERT INTO reconciliation_runs (trigger) VALUES ($1) RETURNING id, started_at
+	`, trigger).Scan(&run.ID, &run.StartedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// A failure to list the orders is recorded on the run, which is still
+	// finished, so it does not look like it is in progress forever
+	references, listErr := s.pendingReferences()
+	if listErr != nil {
+		listErr = fmt.Errorf("failed to list pending orders: %w", listErr)
+		run.Errors = append(run.Errors, listErr.Error())
+	}
+
+	for _, reference := range references {
+		run.Checked++
+		if err := s.reconcile(reference, &run); err != nil {
+			run.Errors = append(run.Errors, fmt.Sprintf("%s: %v", reference, err))
+		}
+	}
+
+	errorsJSON, _ := json.Marshal(run.Errors)
+	err = s.DB.QueryRow(`
+		UPDATE reconciliation_runs
+		SET checked = $1, paid = $2, failed = $3, abandoned = $4, unchanged = $5,
+			errors = $6, finished_at = NOW()
+		WHERE id = $7
+		RETURNING finished_at
+	`, run.Checked, run.Paid, run.Failed, run.Abandoned, run.Unchanged, string(errorsJSON), run.ID).Scan(&run.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+	if listErr != nil {
+		return nil, listErr
+	}
+
+	log.Printf("Payment reconciliation (%s): checked %d, paid %d, failed %d, abandoned %d, errors %d",
+		trigger, run.Checked, run.Paid, run.Failed, run.Abandoned, len(run.Errors))
+	return &run, nil
+}
+
+// pendingReferences returns references of orders pending longer than the delay
+func (s *ReconciliationService) pendingReferences() ([]string, error) {
+	rows, err := s.DB.Query(`
+		SELECT reference FROM orders
+		WHERE status = $1 AND created_at < $2
+		ORDER BY created_at ASC
+	`, models.OrderStatusPending, time.Now().Add(-s.after))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var references []string
+	for rows.Next() {
+		var reference string
+		if err := rows.Scan(&reference); err != nil {
+			return nil, err
+		}
+		references = append(references, reference)
+	}
+
+	return references, rows.Err()
+}
+
+// reconcile verifies a single reference and updates its order
+func (s *ReconciliationService) reconcile(reference string, run *models.ReconciliationRun) error {
+	data, err := s.client.VerifyTransaction(reference)
+	if err != nil {
+		return err
+	}
+
+	switch data.Status {
+	case "success":
+		if data.Reference == "" {
+			data.Reference = reference
+		}
+		duplicate, err := s.webhookService.RecordVerifiedCharge(*data)
+		if err != nil {
+			return err
+		}
+		if duplicate {
+			// The charge webhook got there first
+			run.Unchanged++
+		} else {
+			run.Paid++
+		}
+	case "failed", "reversed":
+		if err := s.setPendingOrderStatus(reference, models.OrderStatusFailed); err != nil {
+			return err
+		}
+		run.Failed++
+	case "abandoned":
+		if err := s.setPendingOrderStatus(reference, models.OrderStatusAbandoned); err != nil {
+			return err
+		}
+		run.Abandoned++
+	default:
+		// Still ongoing on Paystack's side; check again next run
+		run.Unchanged++
+	}
+
+	return nil
+}
+
+// setPendingOrderStatus updates an order only if it is still pending, so a
+// webhook that lands mid-run is never overwritten
+func (s *ReconciliationService) setPendingOrderStatus(reference, status string) error {
+	_, err := s.DB.Exec(`
+		UPDATE orders SET status = $1, updated_at = NOW()
+		WHERE reference = $2 AND status = $3
+	`, status, reference, models.OrderStatusPending)
+	return err
+}
+
+// GetLastRun retrieves the most recent reconciliation run
+func (s *ReconciliationService) GetLastRun() (*models.ReconciliationRun, error) {
+	var run models.ReconciliationRun
+	var errorsJSON []byte
+
+	err := s.DB.QueryRow(`
+		SELECT id, trigger, checked, paid, failed, abandoned, unchanged, errors, started_at, finished_at
+		FROM reconciliation_runs
+		ORDER BY started_at DESC
+		LIMIT 1
+	`).Scan(
+		&run.ID, &run.Trigger, &run.Checked, &run.Paid, &run.Failed, &run.Abandoned,
+		&run.Unchanged, &errorsJSON, &run.StartedAt, &run.FinishedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrNoReconciliationRuns
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	json.Unmarshal(errorsJSON, &run.Errors)
+	return &run, nil
+}
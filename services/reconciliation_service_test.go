@@ -0,0 +1,63 @@
+package services
+
+import (
+	"database/sql/driver"
+	"plantbased-backend/models"
+	"testing"
+	"time"
+)
+
+func TestReconciliationRun(t *testing.T) {
+	db, fake := newFakeDB(t)
+	now := time.Now()
+	fake.on("INSERT INTO reconciliation_runs", []string{"id", "started_at"}, []driver.Value{int64(7), now})
+	fake.on("SELECT reference FROM orders", []string{"reference"},
+		[]driver.Value{"PB-1"}, []driver.Value{"PB-2"}, []driver.Value{"PB-3"})
+	fake.on("UPDATE reconciliation_runs", []string{"finished_at"}, []driver.Value{now})
+
+	client := NewFakePaystackClient()
+	client.Transactions["PB-1"] = models.PaystackWebhookData{Reference: "PB-1", Status: "failed"}
+	client.Transactions["PB-3"] = models.PaystackWebhookData{Reference: "PB-3", Status: "ongoing"}
+	// PB-2 is unknown to Paystack, so it verifies as abandoned
+
+	s := &ReconciliationService{DB: db, client: client, after: time.Hour}
+	run, err := s.Run("manual")
+	if err != nil {
+		t.Fatalf("Run error = %v", err)
+	}
+
+	if run.ID != 7 || run.Checked != 3 || run.Failed != 1 || run.Abandoned != 1 || run.Unchanged != 1 || run.Paid != 0 {
+		t.Errorf("run = %+v", run)
+	}
+	if len(run.Errors) != 0 || run.FinishedAt == nil {
+		t.Errorf("run errors = %v, finished at %v", run.Errors, run.FinishedAt)
+	}
+
+	// Only orders that are still pending are updated
+	updates := fake.ran("UPDATE orders SET status")
+	if len(updates) != 2 {
+		t.Fatalf("%d order updates, want 2", len(updates))
+	}
+	want := [][]driver.Value{
+		{models.OrderStatusFailed, "PB-1", models.OrderStatusPending},
+		{models.OrderStatusAbandoned, "PB-2", models.OrderStatusPending},
+	}
+	for i, update := range updates {
+		for j, arg := range want[i] {
+			if update.args[j] != arg {
+				t.Errorf("update %d args = %v, want %v", i, update.args, want[i])
+				break
+			}
+		}
+	}
+}
+
+func TestReconciliationRunIsExclusive(t *testing.T) {
+	s := &ReconciliationService{}
+	s.running.Lock()
+	defer s.running.Unlock()
+
+	if _, err := s.Run("manual"); err != ErrReconciliationRunning {
+		t.Errorf("Run while running error = %v, want ErrReconciliationRunning", err)
+	}
+}
@@ -0,0 +1,390 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"plantbased-backend/models"
+	"strings"
+)
+
+// ErrInvalidRefund is returned when a refund request cannot be honoured
+var ErrInvalidRefund = errors.New("invalid refund")
+
+const refundColumns = `id, order_id, reference, COALESCE(paystack_refund_id, ''), amount, currency,
+	status, reason, initiated_by, created_at, updated_at`
+
+const disputeColumns = `id, order_id, reference, paystack_dispute_id, status, resolution, category,
+	created_at, updated_at`
+
+// RefundService issues refunds through Paystack and applies refund and
+// dispute webhooks to orders
+type RefundService struct {
+	DB           *sql.DB
+	client       PaystackClient
+	orderService *OrderService
+	emailService *EmailService
+}
+
+func NewRefundService(db *sql.DB, client PaystackClient, orderService *OrderService, emailService *EmailService) *RefundService {
+	return &RefundService{
+		DB:           db,
+		client:       client,
+		orderService: orderService,
+		emailService: emailService,
+	}
+}
+
+func scanRefund(row rowScanner) (*models.Refund, error) {
+	var r models.Refund
+	err := row.Scan(
+		&r.ID, &r.OrderID, &r.Reference, &r.PaystackRefundID, &r.Amount, &r.Currency,
+		&r.Status, &r.Reason, &r.InitiatedBy, &r.CreatedAt, &r.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func scanDispute(row rowScanner) (*models.Dispute, error) {
+	var d models.Dispute
+	err := row.Scan(
+		&d.ID, &d.OrderID, &d.Reference, &d.PaystackDisputeID, &d.Status, &d.Resolution, &d.Category,
+		&d.CreatedAt, &d.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// InitiateRefund starts a full or partial refund of an order. An amount of
+// zero refunds whatever has not been refunded yet. The order itself only
+// changes state once Paystack confirms the refund through a webhook.
+func (s *RefundService) InitiateRefund(orderID int, req models.RefundRequest, adminID int) (*models.Refund, error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// The order stays locked until the pending refund is recorded, so refunds
+	// started at the same time cannot together exceed what was paid
+	order, err := scanOrder(tx.QueryRow("SELECT "+orderColumns+" FROM orders WHERE id = $1 FOR UPDATE", orderID))
+	if err == sql.ErrNoRows {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !models.IsRefundable(order.Status) {
+		return nil, fmt.Errorf("%w: orders with status %q cannot be refunded", ErrInvalidRefund, order.Status)
+	}
+
+	var pending int64
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE order_id = $1 AND status = $2
+	`, orderID, models.RefundStatusPending).Scan(&pending)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := order.Amount - order.RefundedAmount - pending
+	amount := req.Amount
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount <= 0 || amount > remaining {
+		return nil, fmt.Errorf("%w: amount must be between 1 and %d", ErrInvalidRefund, remaining)
+	}
+
+	// Record the refund before asking Paystack for it, so a webhook that
+	// arrives before Paystack's response finds it instead of adding another
+	refund, err := scanRefund(tx.QueryRow(`
+		INSERT INTO refunds (order_id, reference, amount, currency, status, reason, initiated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+refundColumns,
+		order.ID, order.Reference, amount, order.Currency,
+		models.RefundStatusPending, req.Reason, nullableInt(adminID),
+	))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	data, err := s.client.CreateRefund(models.PaystackRefundRequest{
+		Transaction:  order.Reference,
+		Amount:       amount,
+		Currency:     order.Currency,
+		MerchantNote: req.Reason,
+	})
+	if err != nil {
+		if _, dbErr := s.DB.Exec(`
+			UPDATE refunds SET status = $1, updated_at = NOW() WHERE id = $2
+		`, models.RefundStatusFailed, refund.ID); dbErr != nil {
+			log.Printf("Failed to mark refund %d as failed: %v", refund.ID, dbErr)
+		}
+		return nil, err
+	}
+
+	refund, err = scanRefund(s.DB.QueryRow(`
+		UPDATE refunds SET paystack_refund_id = NULLIF($1, ''), updated_at = NOW()
+		WHERE id = $2 AND paystack_refund_id IS NULL
+			AND NOT EXISTS (SELECT 1 FROM refunds WHERE paystack_refund_id = $1)
+		RETURNING `+refundColumns,
+		data.ID.String(), refund.ID,
+	))
+	if err == sql.ErrNoRows {
+		// A webhook got here first and already recorded the refund; drop our
+		// row unless the webhook claimed it
+		if _, err := s.DB.Exec(`
+			DELETE FROM refunds WHERE id = $1 AND paystack_refund_id IS NULL
+		`, refund.ID); err != nil {
+			return nil, err
+		}
+		refund, err = scanRefund(s.DB.QueryRow(`
+			SELECT `+refundColumns+` FROM refunds WHERE paystack_refund_id = $1
+		`, data.ID.String()))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The row stays pending until the processed status is applied below or by
+	// the webhook, which is what moves the order along
+	status := models.RefundStatusPending
+	if data.Status == models.RefundStatusProcessed {
+		status = models.RefundStatusProcessed
+	}
+
+	// Some refunds are processed immediately; apply them like a webhook would
+	if status == models.RefundStatusProcessed {
+		data.TransactionReference = order.Reference
+		data.Amount = amount
+		if err := s.HandleRefundEvent("refund.processed", *data); err != nil {
+			return nil, err
+		}
+		return s.getRefundByID(refund.ID)
+	}
+
+	return refund, nil
+}
+
+// HandleRefundEvent applies a refund.* webhook to the refund history and the order
+func (s *RefundService) HandleRefundEvent(event string, data models.PaystackRefundData) error {
+	var status string
+	switch event {
+	case "refund.processed":
+		status = models.RefundStatusProcessed
+	case "refund.failed":
+		status = models.RefundStatusFailed
+	case "refund.pending":
+		status = models.RefundStatusPending
+	default:
+		return nil
+	}
+
+	order, err := s.orderService.GetOrderByReference(data.TransactionReference)
+	if err != nil {
+		return fmt.Errorf("refund for unknown transaction %q: %w", data.TransactionReference, err)
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Find the refund we started, or record one started from the Paystack dashboard
+	var refundID int
+	var previousStatus string
+	err = tx.QueryRow(`
+		SELECT id, status FROM refunds WHERE paystack_refund_id = $1 FOR UPDATE
+	`, data.ID.String()).Scan(&refundID, &previousStatus)
+	if err == sql.ErrNoRows {
+		// An admin refund still waiting for Paystack's response has no
+		// Paystack ID yet; claim it rather than recording the refund twice
+		err = tx.QueryRow(`
+			UPDATE refunds SET paystack_refund_id = NULLIF($1, ''), updated_at = NOW()
+			WHERE id = (
+				SELECT id FROM refunds
+				WHERE order_id = $2 AND paystack_refund_id IS NULL AND status = $3 AND amount = $4
+				ORDER BY id LIMIT 1 FOR UPDATE
+			)
+			RETURNING id, status
+		`, data.ID.String(), order.ID, models.RefundStatusPending, data.Amount).Scan(&refundID, &previousStatus)
+	}
+	if err == sql.ErrNoRows {
+		err = tx.QueryRow(`
+			INSERT INTO refunds (order_id, reference, paystack_refund_id, amount, currency, status)
+			VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6)
+			RETURNING id
+		`, order.ID, order.Reference, data.ID.String(), data.Amount,
+			strings.ToUpper(data.Currency), models.RefundStatusPending).Scan(&refundID)
+		previousStatus = models.RefundStatusPending
+	}
+	if err != nil {
+		return err
+	}
+
+	if previousStatus == status {
+		return tx.Commit()
+	}
+
+	_, err = tx.Exec(`
+		UPDATE refunds SET status = $1, updated_at = NOW() WHERE id = $2
+	`, status, refundID)
+	if err != nil {
+		return err
+	}
+
+	if status == models.RefundStatusProcessed {
+		if err := s.applyProcessedRefund(tx, order.ID, data.Amount); err != nil {
+			return err
+		}
+
+		// Queued with the status change so each refund is notified once
+		refund, err := scanRefund(tx.QueryRow("SELECT "+refundColumns+" FROM refunds WHERE id = $1", refundID))
+		if err != nil {
+			return err
+		}
+		if err := s.emailService.SendRefundNotification(tx, *order, *refund); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// applyProcessedRefund adds a processed refund to the order and moves it
+// along the payment state machine
+func (s *RefundService) applyProcessedRefund(tx *sql.Tx, orderID int, amount int64) error {
+	var status string
+	var total, refunded int64
+	err := tx.QueryRow(`
+		SELECT status, amount, refunded_amount FROM orders WHERE id = $1 FOR UPDATE
+	`, orderID).Scan(&status, &total, &refunded)
+	if err != nil {
+		return err
+	}
+
+	refunded += amount
+	next := models.RefundStatusFor(total, refunded)
+	if status == models.OrderStatusDisputed && next != models.OrderStatusRefunded {
+		// Stay disputed until the dispute is resolved
+		next = status
+	}
+
+	if next != status && !models.CanTransitionPayment(status, next) {
+		log.Printf("Order %d: refund recorded but status %q cannot move to %q", orderID, status, next)
+		next = status
+	}
+
+	_, err = tx.Exec(`
+		UPDATE orders SET refunded_amount = $1, status = $2, updated_at = NOW() WHERE id = $3
+	`, refunded, next, orderID)
+	return err
+}
+
+// HandleDisputeEvent applies a charge.dispute.* webhook to the order
+func (s *RefundService) HandleDisputeEvent(event string, data models.PaystackDisputeData) error {
+	order, err := s.orderService.GetOrderByReference(data.Transaction.Reference)
+	if err != nil {
+		return fmt.Errorf("dispute for unknown transaction %q: %w", data.Transaction.Reference, err)
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO disputes (order_id, reference, paystack_dispute_id, status, resolution, category)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (paystack_dispute_id) DO UPDATE SET
+			status = EXCLUDED.status,
+			resolution = EXCLUDED.resolution,
+			category = COALESCE(NULLIF(EXCLUDED.category, ''), disputes.category),
+			updated_at = NOW()
+	`, order.ID, order.Reference, data.ID.String(), data.Status, data.Resolution, data.Category)
+	if err != nil {
+		return err
+	}
+
+	next := models.OrderStatusDisputed
+	if event == "charge.dispute.resolve" {
+		// Back to the status implied by refunds; a dispute lost to the
+		// customer arrives as a separate refund event
+		next = models.RefundStatusFor(order.Amount, order.RefundedAmount)
+	}
+
+	if next != order.Status {
+		if !models.CanTransitionPayment(order.Status, next) {
+			log.Printf("Order %d: dispute recorded but status %q cannot move to %q", order.ID, order.Status, next)
+		} else if _, err := tx.Exec(`
+			UPDATE orders SET status = $1, updated_at = NOW() WHERE id = $2
+		`, next, order.ID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetRefundsByOrderID retrieves the refund history of an order
+func (s *RefundService) GetRefundsByOrderID(orderID int) ([]models.Refund, error) {
+	rows, err := s.DB.Query(`
+		SELECT `+refundColumns+` FROM refunds WHERE order_id = $1 ORDER BY created_at ASC
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunds := []models.Refund{}
+	for rows.Next() {
+		refund, err := scanRefund(rows)
+		if err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, *refund)
+	}
+
+	return refunds, rows.Err()
+}
+
+// GetDisputesByOrderID retrieves the disputes raised against an order
+func (s *RefundService) GetDisputesByOrderID(orderID int) ([]models.Dispute, error) {
+	rows, err := s.DB.Query(`
+		SELECT `+disputeColumns+` FROM disputes WHERE order_id = $1 ORDER BY created_at ASC
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	disputes := []models.Dispute{}
+	for rows.Next() {
+		dispute, err := scanDispute(rows)
+		if err != nil {
+			return nil, err
+		}
+		disputes = append(disputes, *dispute)
+	}
+
+	return disputes, rows.Err()
+}
+
+func (s *RefundService) getRefundByID(id int) (*models.Refund, error) {
+	refund, err := scanRefund(s.DB.QueryRow("SELECT "+refundColumns+" FROM refunds WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, errors.New("refund not found")
+	}
+	return refund, err
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"plantbased-backend/models"
+	"strings"
+	"time"
+)
+
+const subscriptionColumns = `id, subscription_code, email_token, customer_email, customer_code, plan_code,
+	program_id, plan_id, program_name, plan_name, status, next_payment_date, cancelled_at, created_at, updated_at`
+
+// SubscriptionService keeps the subscriptions table in step with Paystack's
+// subscription and invoice webhooks
+type SubscriptionService struct {
+	DB             *sql.DB
+	programService *ProgramService
+}
+
+func NewSubscriptionService(db *sql.DB, programService *ProgramService) *SubscriptionService {
+	return &SubscriptionService{
+		DB:             db,
+		programService: programService,
+	}
+}
+
+func scanSubscription(row rowScanner) (*models.Subscription, error) {
+	var sub models.Subscription
+	err := row.Scan(
+		&sub.ID, &sub.SubscriptionCode, &sub.EmailToken, &sub.CustomerEmail, &sub.CustomerCode, &sub.PlanCode,
+		&sub.ProgramID, &sub.PlanID, &sub.ProgramName, &sub.PlanName, &sub.Status, &sub.NextPaymentDate,
+		&sub.CancelledAt, &sub.CreatedAt, &sub.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// parsePaystackTime parses a Paystack timestamp, returning nil when it is empty or invalid
+func parsePaystackTime(value string) *time.Time {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return &parsed
+}
+
+// HandleSubscriptionEvent applies a subscription.* webhook
+func (s *SubscriptionService) HandleSubscriptionEvent(event string, data models.PaystackSubscriptionData) error {
+	if data.SubscriptionCode == "" {
+		return errors.New("missing subscription code")
+	}
+
+	switch event {
+	case "subscription.create":
+		return s.upsertSubscription(data)
+	case "subscription.disable":
+		return s.setStatus(data.SubscriptionCode, models.SubscriptionStatusCancelled)
+	}
+
+	return nil
+}
+
+// HandleInvoiceEvent applies an invoice.* webhook. A failed renewal marks the
+// subscription past due; a later paid invoice makes it active again.
+func (s *SubscriptionService) HandleInvoiceEvent(event string, data models.PaystackInvoiceData) error {
+	code := data.Subscription.SubscriptionCode
+	if code == "" {
+		return errors.New("missing subscription code")
+	}
+
+	switch {
+	case event == "invoice.payment_failed":
+		return s.setStatus(code, models.SubscriptionStatusPastDue)
+	case event == "invoice.update" && data.Paid:
+		return s.setStatus(code, models.SubscriptionStatusActive)
+	}
+
+	return nil
+}
+
+// upsertSubscription records a new subscription, mapping its Paystack plan
+// back to the program and pricing plan it was created for
+func (s *SubscriptionService) upsertSubscription(data models.PaystackSubscriptionData) error {
+	var programID, planID int
+	var programName, planName string
+
+	if data.Plan.PlanCode != "" {
+		plan, err := s.programService.GetPricingPlanByPlanCode(data.Plan.PlanCode)
+		if err == nil {
+			planID, planName = plan.ID, plan.Name
+			if program, err := s.programService.GetProgramByID(plan.ProgramID); err == nil {
+				programID, programName = program.ID, program.Name
+			}
+		} else {
+			log.Printf("Subscription %s uses unknown plan %s", data.SubscriptionCode, data.Plan.PlanCode)
+		}
+	}
+
+	_, err := s.DB.Exec(`
+		INSERT INTO subscriptions (
+			subscription_code, email_token, customer_email, customer_code, plan_code,
+			program_id, plan_id, program_name, plan_name, status, next_payment_date
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (subscription_code) DO UPDATE SET
+			email_token = EXCLUDED.email_token,
+			customer_email = EXCLUDED.customer_email,
+			customer_code = EXCLUDED.customer_code,
+			plan_code = EXCLUDED.plan_code,
+			program_id = COALESCE(EXCLUDED.program_id, subscriptions.program_id),
+			plan_id = COALESCE(EXCLUDED.plan_id, subscriptions.plan_id),
+			program_name = COALESCE(NULLIF(EXCLUDED.program_name, ''), subscriptions.program_name),
+			plan_name = COALESCE(NULLIF(EXCLUDED.plan_name, ''), subscriptions.plan_name),
+			status = EXCLUDED.status,
+			next_payment_date = EXCLUDED.next_payment_date,
+			cancelled_at = NULL,
+			updated_at = NOW()
+	`, data.SubscriptionCode, data.EmailToken, strings.ToLower(data.Customer.Email), data.Customer.CustomerCode,
+		data.Plan.PlanCode, nullableInt(programID), nullableInt(planID), programName, planName,
+		models.SubscriptionStatusActive, parsePaystackTime(data.NextPaymentDate),
+	)
+	return err
+}
+
+// setStatus moves a subscription to a new status. Cancelled subscriptions stay cancelled.
+func (s *SubscriptionService) setStatus(code, status string) error {
+	result, err := s.DB.Exec(`
+		UPDATE subscriptions
+		SET status = $1,
+			cancelled_at = CASE WHEN $1 = 'cancelled' THEN NOW() ELSE cancelled_at END,
+			updated_at = NOW()
+		WHERE subscription_code = $2 AND status <> 'cancelled'
+	`, status, code)
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		log.Printf("Subscription %s not updated to %s (unknown or already cancelled)", code, status)
+	}
+	return nil
+}
+
+// GetSubscriptions retrieves subscriptions, optionally filtered by status
+func (s *SubscriptionService) GetSubscriptions(status string) ([]models.Subscription, error) {
+	query := "SELECT " + subscriptionColumns + " FROM subscriptions"
+	var args []interface{}
+	if status != "" {
+		query += " WHERE status = $1"
+		args = append(args, status)
+	}
+	query += " ORDER BY created_at DESC"
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []models.Subscription{}
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, *sub)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+// GetSubscriptionByID retrieves a single subscription
+func (s *SubscriptionService) GetSubscriptionByID(id int) (*models.Subscription, error) {
+	sub, err := scanSubscription(s.DB.QueryRow("SELECT "+subscriptionColumns+" FROM subscriptions WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, errors.New("subscription not found")
+	}
+	return sub, err
+}
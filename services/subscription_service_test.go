@@ -0,0 +1,71 @@
+package services
+
+import (
+	"plantbased-backend/models"
+	"testing"
+)
+
+func TestParsePaystackTime(t *testing.T) {
+	if got := parsePaystackTime("2026-03-01T08:00:00.000Z"); got == nil || got.Month() != 3 || got.Day() != 1 {
+		t.Errorf("parsePaystackTime = %v", got)
+	}
+	for _, value := range []string{"", "next week"} {
+		if got := parsePaystackTime(value); got != nil {
+			t.Errorf("parsePaystackTime(%q) = %v, want nil", value, got)
+		}
+	}
+}
+
+func TestSubscriptionEvents(t *testing.T) {
+	tests := []struct {
+		name       string
+		apply      func(s *SubscriptionService) error
+		wantStatus string // "" when nothing should change
+	}{
+		{"disable", func(s *SubscriptionService) error {
+			return s.HandleSubscriptionEvent("subscription.disable", models.PaystackSubscriptionData{SubscriptionCode: "SUB_1"})
+		}, models.SubscriptionStatusCancelled},
+		{"failed renewal", func(s *SubscriptionService) error {
+			return s.HandleInvoiceEvent("invoice.payment_failed", models.PaystackInvoiceData{
+				Subscription: models.PaystackSubscriptionData{SubscriptionCode: "SUB_1"},
+			})
+		}, models.SubscriptionStatusPastDue},
+		{"paid invoice", func(s *SubscriptionService) error {
+			return s.HandleInvoiceEvent("invoice.update", models.PaystackInvoiceData{
+				Paid:         true,
+				Subscription: models.PaystackSubscriptionData{SubscriptionCode: "SUB_1"},
+			})
+		}, models.SubscriptionStatusActive},
+		{"unpaid invoice update", func(s *SubscriptionService) error {
+			return s.HandleInvoiceEvent("invoice.update", models.PaystackInvoiceData{
+				Subscription: models.PaystackSubscriptionData{SubscriptionCode: "SUB_1"},
+			})
+		}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := newFakeDB(t)
+			s := &SubscriptionService{DB: db}
+			if err := tt.apply(s); err != nil {
+				t.Fatalf("error = %v", err)
+			}
+
+			updates := fake.ran("UPDATE subscriptions")
+			if tt.wantStatus == "" {
+				if len(updates) != 0 {
+					t.Errorf("%d updates, want none", len(updates))
+				}
+				return
+			}
+			if len(updates) != 1 || updates[0].args[0] != tt.wantStatus {
+				t.Errorf("updates = %+v, want status %q", updates, tt.wantStatus)
+			}
+		})
+	}
+
+	s := &SubscriptionService{}
+	if err := s.HandleSubscriptionEvent("subscription.disable", models.PaystackSubscriptionData{}); err == nil {
+		t.Error("event without a subscription code accepted")
+	}
+}
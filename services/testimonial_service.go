@@ -7,22 +7,30 @@ import (
 )
 
 type TestimonialService struct {
-	DB *sql.DB
+	DB           *sql.DB
+	emailService *EmailService
 }
 
-func NewTestimonialService(db *sql.DB) *TestimonialService {
-	return &TestimonialService{DB: db}
+func NewTestimonialService(db *sql.DB, emailService *EmailService) *TestimonialService {
+	return &TestimonialService{DB: db, emailService: emailService}
 }
 
-// CreateTestimonial creates a new testimonial
+// CreateTestimonial creates a new testimonial and queues the new testimonial notification
 func (s *TestimonialService) CreateTestimonial(req models.CreateTestimonialRequest) (*models.Testimonial, error) {
 	var testimonial models.Testimonial
 
-	err := s.DB.QueryRow(`
-		INSERT INTO testimonials (name, location, review, avatar)
-		VALUES ($1, $2, $3, $4)
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	email := normalizeEmail(req.Email)
+	err = tx.QueryRow(`
+		INSERT INTO testimonials (name, location, review, avatar, email)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, name, location, review, avatar, created_at, updated_at
-	`, req.Name, req.Location, req.Review, req.Avatar).Scan(
+	`, req.Name, req.Location, req.Review, req.Avatar, email).Scan(
 		&testimonial.ID,
 		&testimonial.Name,
 		&testimonial.Location,
@@ -36,6 +44,14 @@ func (s *TestimonialService) CreateTestimonial(req models.CreateTestimonialReque
 		return nil, err
 	}
 
+	if err := s.emailService.SendTestimonialNotification(tx, testimonial, email); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	return &testimonial, nil
 }
 
@@ -103,13 +119,14 @@ func (s *TestimonialService) GetTestimonialByID(id int) (*models.Testimonial, er
 	return &t, nil
 }
 
-// UpdateTestimonial updates an existing testimonial
+// UpdateTestimonial updates an existing testimonial. The email is never
+// returned to the admin UI, so an empty one keeps the stored address.
 func (s *TestimonialService) UpdateTestimonial(id int, req models.CreateTestimonialRequest) (*models.Testimonial, error) {
 	_, err := s.DB.Exec(`
 		UPDATE testimonials
-		SET name = $1, location = $2, review = $3, avatar = $4, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $5
-	`, req.Name, req.Location, req.Review, req.Avatar, id)
+		SET name = $1, location = $2, review = $3, avatar = $4, email = COALESCE(NULLIF($5, ''), email), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6
+	`, req.Name, req.Location, req.Review, req.Avatar, normalizeEmail(req.Email), id)
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,290 @@
+package services
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"plantbased-backend/models"
+	"strings"
+	"time"
+)
+
+// ErrInvalidWebhookPayload is returned when a webhook body cannot be decoded
+var ErrInvalidWebhookPayload = errors.New("invalid webhook data")
+
+// webhookProcessingLease is how long a delivery being processed holds its
+// event. Redeliveries within the lease are treated as duplicates; after it, the
+// first delivery is assumed to have died and the event can be claimed again.
+const webhookProcessingLease = 5 * time.Minute
+
+const webhookEventColumns = `id, event, reference, payload, status, attempts, last_error,
+	processed_at, created_at, updated_at`
+
+// WebhookService records Paystack webhooks in the webhook_events ledger and
+// makes sure each event is only processed once
+type WebhookService struct {
+	DB                  *sql.DB
+	orderService        *OrderService
+	refundService       *RefundService
+	subscriptionService *SubscriptionService
+	invoiceService      *InvoiceService
+	customerService     *CustomerService
+}
+
+func NewWebhookService(
+	db *sql.DB,
+	orderService *OrderService,
+	refundService *RefundService,
+	subscriptionService *SubscriptionService,
+	invoiceService *InvoiceService,
+	customerService *CustomerService,
+) *WebhookService {
+	return &WebhookService{
+		DB:                  db,
+		orderService:        orderService,
+		refundService:       refundService,
+		subscriptionService: subscriptionService,
+		invoiceService:      invoiceService,
+		customerService:     customerService,
+	}
+}
+
+func scanWebhookEvent(row rowScanner) (*models.WebhookEvent, error) {
+	var e models.WebhookEvent
+	var payload []byte
+	err := row.Scan(
+		&e.ID, &e.Event, &e.Reference, &payload, &e.Status, &e.Attempts, &e.LastError,
+		&e.ProcessedAt, &e.CreatedAt, &e.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	e.Payload = payload
+	return &e, nil
+}
+
+// eventReference returns the ledger key for a webhook. Events without a
+// reference are keyed on a hash of their payload instead.
+func eventReference(webhook models.PaystackWebhook, payload []byte) string {
+	if webhook.Data.Reference != "" {
+		return webhook.Data.Reference
+	}
+	sum := sha256.Sum256(payload)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// ProcessPayload records a verified webhook payload and processes it unless
+// it has already been processed. It reports whether the delivery was a duplicate.
+func (s *WebhookService) ProcessPayload(payload []byte) (bool, error) {
+	var webhook models.PaystackWebhook
+	if err := json.Unmarshal(payload, &webhook); err != nil || webhook.Event == "" {
+		return false, ErrInvalidWebhookPayload
+	}
+
+	reference := eventReference(webhook, payload)
+
+	// Record the delivery. Events already processed, or claimed by a delivery
+	// still being processed, are left alone and reported as duplicates;
+	// anything else is (re)claimed for processing.
+	var eventID int
+	err := s.DB.QueryRow(`
+		INSERT INTO webhook_events (event, reference, payload, status, attempts)
+		VALUES ($1, $2, $3, $4, 1)
+		ON CONFLICT (event, reference) DO UPDATE SET
+			attempts = webhook_events.attempts + 1,
+			payload = EXCLUDED.payload,
+			status = EXCLUDED.status,
+			updated_at = NOW()
+		WHERE webhook_events.status <> $5
+			AND NOT (webhook_events.status = $4 AND webhook_events.updated_at > $6)
+		RETURNING id
+	`, webhook.Event, reference, string(payload), models.WebhookStatusProcessing, models.WebhookStatusProcessed,
+		time.Now().Add(-webhookProcessingLease)).Scan(&eventID)
+	if err == sql.ErrNoRows {
+		log.Printf("Skipping duplicate webhook %s for reference %s", webhook.Event, reference)
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return false, s.process(eventID, webhook, payload)
+}
+
+// RecordVerifiedCharge processes a successful charge confirmed by verifying the
+// transaction with Paystack as if its charge.success webhook had arrived, so
+// it goes through the same ledger and is never processed twice. It reports
+// whether the charge had already been recorded.
+func (s *WebhookService) RecordVerifiedCharge(data models.PaystackWebhookData) (bool, error) {
+	payload, err := json.Marshal(models.PaystackWebhook{Event: "charge.success", Data: data})
+	if err != nil {
+		return false, err
+	}
+	return s.ProcessPayload(payload)
+}
+
+// RetryEvent reprocesses a stored webhook event that has not been processed yet
+func (s *WebhookService) RetryEvent(id int) (*models.WebhookEvent, error) {
+	event, err := s.GetEventByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.Status == models.WebhookStatusProcessed {
+		return nil, errors.New("webhook event has already been processed")
+	}
+
+	var webhook models.PaystackWebhook
+	if err := json.Unmarshal(event.Payload, &webhook); err != nil {
+		return nil, ErrInvalidWebhookPayload
+	}
+
+	result, err := s.DB.Exec(`
+		UPDATE webhook_events
+		SET status = $1, attempts = attempts + 1, updated_at = NOW()
+		WHERE id = $2 AND status <> $3 AND NOT (status = $1 AND updated_at > $4)
+	`, models.WebhookStatusProcessing, id, models.WebhookStatusProcessed, time.Now().Add(-webhookProcessingLease))
+	if err != nil {
+		return nil, err
+	}
+	if claimed, _ := result.RowsAffected(); claimed == 0 {
+		return nil, errors.New("webhook event is already being processed")
+	}
+
+	if err := s.process(id, webhook, event.Payload); err != nil {
+		return nil, err
+	}
+
+	return s.GetEventByID(id)
+}
+
+// process dispatches the webhook and records the outcome in the ledger
+func (s *WebhookService) process(eventID int, webhook models.PaystackWebhook, payload []byte) error {
+	if err := s.dispatch(webhook, payload); err != nil {
+		_, dbErr := s.DB.Exec(`
+			UPDATE webhook_events SET status = $1, last_error = $2, updated_at = NOW()
+			WHERE id = $3
+		`, models.WebhookStatusFailed, err.Error(), eventID)
+		if dbErr != nil {
+			log.Printf("Failed to mark webhook event %d as failed: %v", eventID, dbErr)
+		}
+		return err
+	}
+
+	_, err := s.DB.Exec(`
+		UPDATE webhook_events
+		SET status = $1, last_error = '', processed_at = NOW(), updated_at = NOW()
+		WHERE id = $2
+	`, models.WebhookStatusProcessed, eventID)
+	return err
+}
+
+// dispatch handles a single webhook event. Events whose data does not fit
+// PaystackWebhookData are decoded again from the raw payload.
+func (s *WebhookService) dispatch(webhook models.PaystackWebhook, payload []byte) error {
+	switch {
+	case strings.HasPrefix(webhook.Event, "refund."):
+		var refund struct {
+			Data models.PaystackRefundData `json:"data"`
+		}
+		if err := json.Unmarshal(payload, &refund); err != nil {
+			return ErrInvalidWebhookPayload
+		}
+		return s.refundService.HandleRefundEvent(webhook.Event, refund.Data)
+
+	case strings.HasPrefix(webhook.Event, "charge.dispute."):
+		var dispute struct {
+			Data models.PaystackDisputeData `json:"data"`
+		}
+		if err := json.Unmarshal(payload, &dispute); err != nil {
+			return ErrInvalidWebhookPayload
+		}
+		return s.refundService.HandleDisputeEvent(webhook.Event, dispute.Data)
+
+	case strings.HasPrefix(webhook.Event, "subscription."):
+		var subscription struct {
+			Data models.PaystackSubscriptionData `json:"data"`
+		}
+		if err := json.Unmarshal(payload, &subscription); err != nil {
+			return ErrInvalidWebhookPayload
+		}
+		return s.subscriptionService.HandleSubscriptionEvent(webhook.Event, subscription.Data)
+
+	case strings.HasPrefix(webhook.Event, "invoice."):
+		var invoice struct {
+			Data models.PaystackInvoiceData `json:"data"`
+		}
+		if err := json.Unmarshal(payload, &invoice); err != nil {
+			return ErrInvalidWebhookPayload
+		}
+		return s.subscriptionService.HandleInvoiceEvent(webhook.Event, invoice.Data)
+
+	case webhook.Event == "charge.success":
+		if webhook.Data.Status != "success" {
+			return nil
+		}
+		order, err := s.orderService.RecordSuccessfulCharge(webhook.Data)
+		if err != nil {
+			return err
+		}
+		log.Printf("Recorded order %d for reference %s", order.ID, order.Reference)
+
+		invoice, err := s.invoiceService.IssueInvoice(order)
+		if err != nil {
+			return fmt.Errorf("failed to issue invoice for order %d: %w", order.ID, err)
+		}
+		if invoice != nil {
+			log.Printf("Issued invoice %s for order %d", invoice.InvoiceNumber, order.ID)
+		}
+
+		// A failed match leaves the order in the unmatched payments queue
+		if lead, err := s.customerService.MatchOrder(order); err != nil {
+			log.Printf("Failed to match order %d to a lead: %v", order.ID, err)
+		} else if lead != nil {
+			log.Printf("Matched order %d to lead %d", order.ID, lead.ID)
+		}
+	}
+
+	return nil
+}
+
+// GetEvents retrieves ledger entries, optionally filtered by status
+func (s *WebhookService) GetEvents(status string) ([]models.WebhookEvent, error) {
+	query := "SELECT " + webhookEventColumns + " FROM webhook_events"
+	var args []interface{}
+	if status != "" {
+		query += " WHERE status = $1"
+		args = append(args, status)
+	}
+	query += " ORDER BY created_at DESC LIMIT 200"
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.WebhookEvent{}
+	for rows.Next() {
+		event, err := scanWebhookEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *event)
+	}
+
+	return events, rows.Err()
+}
+
+// GetEventByID retrieves a single ledger entry
+func (s *WebhookService) GetEventByID(id int) (*models.WebhookEvent, error) {
+	event, err := scanWebhookEvent(s.DB.QueryRow("SELECT "+webhookEventColumns+" FROM webhook_events WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, errors.New("webhook event not found")
+	}
+	return event, err
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"errors"
+	"plantbased-backend/models"
+	"strings"
+	"testing"
+)
+
+func TestEventReference(t *testing.T) {
+	withReference := models.PaystackWebhook{Event: "charge.success", Data: models.PaystackWebhookData{Reference: "PB-123"}}
+	if got := eventReference(withReference, []byte(`{}`)); got != "PB-123" {
+		t.Errorf("eventReference with a reference = %q, want %q", got, "PB-123")
+	}
+
+	// Events without a reference are keyed on their payload
+	noReference := models.PaystackWebhook{Event: "transfer.success"}
+	first := eventReference(noReference, []byte(`{"event":"transfer.success","data":{"id":1}}`))
+	if !strings.HasPrefix(first, "sha256:") {
+		t.Errorf("eventReference without a reference = %q, want a sha256: key", first)
+	}
+	if again := eventReference(noReference, []byte(`{"event":"transfer.success","data":{"id":1}}`)); again != first {
+		t.Errorf("the same payload is keyed %q then %q", first, again)
+	}
+	if other := eventReference(noReference, []byte(`{"event":"transfer.success","data":{"id":2}}`)); other == first {
+		t.Error("different payloads get the same key")
+	}
+}
+
+func TestProcessPayloadRejectsInvalidPayloads(t *testing.T) {
+	s := &WebhookService{}
+
+	// Rejected before the ledger is touched
+	for _, payload := range []string{``, `not json`, `{"data":{"reference":"PB-123"}}`, `{"event":""}`} {
+		if _, err := s.ProcessPayload([]byte(payload)); !errors.Is(err, ErrInvalidWebhookPayload) {
+			t.Errorf("ProcessPayload(%q) error = %v, want ErrInvalidWebhookPayload", payload, err)
+		}
+	}
+}
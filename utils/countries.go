@@ -0,0 +1,340 @@
+package utils
+
+import "strings"
+
+// Country is an ISO 3166-1 country with its international calling code
+type Country struct {
+	Alpha2      string
+	Alpha3      string
+	Name        string
+	CallingCode string // Without the leading "+"
+}
+
+// Countries lists the ISO 3166-1 countries and territories
+var Countries = []Country{
+	{"AF", "AFG", "Afghanistan", "93"},
+	{"AX", "ALA", "Aland Islands", "358"},
+	{"AL", "ALB", "Albania", "355"},
+	{"DZ", "DZA", "Algeria", "213"},
+	{"AS", "ASM", "American Samoa", "1"},
+	{"AD", "AND", "Andorra", "376"},
+	{"AO", "AGO", "Angola", "244"},
+	{"AI", "AIA", "Anguilla", "1"},
+	{"AQ", "ATA", "Antarctica", "672"},
+	{"AG", "ATG", "Antigua and Barbuda", "1"},
+	{"AR", "ARG", "Argentina", "54"},
+	{"AM", "ARM", "Armenia", "374"},
+	{"AW", "ABW", "Aruba", "297"},
+	{"AU", "AUS", "Australia", "61"},
+	{"AT", "AUT", "Austria", "43"},
+	{"AZ", "AZE", "Azerbaijan", "994"},
+	{"BS", "BHS", "Bahamas", "1"},
+	{"BH", "BHR", "Bahrain", "973"},
+	{"BD", "BGD", "Bangladesh", "880"},
+	{"BB", "BRB", "Barbados", "1"},
+	{"BY", "BLR", "Belarus", "375"},
+	{"BE", "BEL", "Belgium", "32"},
+	{"BZ", "BLZ", "Belize", "501"},
+	{"BJ", "BEN", "Benin", "229"},
+	{"BM", "BMU", "Bermuda", "1"},
+	{"BT", "BTN", "Bhutan", "975"},
+	{"BO", "BOL", "Bolivia", "591"},
+	{"BQ", "BES", "Bonaire, Sint Eustatius and Saba", "599"},
+	{"BA", "BIH", "Bosnia and Herzegovina", "387"},
+	{"BW", "BWA", "Botswana", "267"},
+	{"BV", "BVT", "Bouvet Island", "47"},
+	{"BR", "BRA", "Brazil", "55"},
+	{"IO", "IOT", "British Indian Ocean Territory", "246"},
+	{"BN", "BRN", "Brunei", "673"},
+	{"BG", "BGR", "Bulgaria", "359"},
+	{"BF", "BFA", "Burkina Faso", "226"},
+	{"BI", "BDI", "Burundi", "257"},
+	{"CV", "CPV", "Cabo Verde", "238"},
+	{"KH", "KHM", "Cambodia", "855"},
+	{"CM", "CMR", "Cameroon", "237"},
+	{"CA", "CAN", "Canada", "1"},
+	{"KY", "CYM", "Cayman Islands", "1"},
+	{"CF", "CAF", "Central African Republic", "236"},
+	{"TD", "TCD", "Chad", "235"},
+	{"CL", "CHL", "Chile", "56"},
+	{"CN", "CHN", "China", "86"},
+	{"CX", "CXR", "Christmas Island", "61"},
+	{"CC", "CCK", "Cocos (Keeling) Islands", "61"},
+	{"CO", "COL", "Colombia", "57"},
+	{"KM", "COM", "Comoros", "269"},
+	{"CG", "COG", "Congo", "242"},
+	{"CD", "COD", "Democratic Republic of the Congo", "243"},
+	{"CK", "COK", "Cook Islands", "682"},
+	{"CR", "CRI", "Costa Rica", "506"},
+	{"CI", "CIV", "Cote d'Ivoire", "225"},
+	{"HR", "HRV", "Croatia", "385"},
+	{"CU", "CUB", "Cuba", "53"},
+	{"CW", "CUW", "Curacao", "599"},
+	{"CY", "CYP", "Cyprus", "357"},
+	{"CZ", "CZE", "Czechia", "420"},
+	{"DK", "DNK", "Denmark", "45"},
+	{"DJ", "DJI", "Djibouti", "253"},
+	{"DM", "DMA", "Dominica", "1"},
+	{"DO", "DOM", "Dominican Republic", "1"},
+	{"EC", "ECU", "Ecuador", "593"},
+	{"EG", "EGY", "Egypt", "20"},
+	{"SV", "SLV", "El Salvador", "503"},
+	{"GQ", "GNQ", "Equatorial Guinea", "240"},
+	{"ER", "ERI", "Eritrea", "291"},
+	{"EE", "EST", "Estonia", "372"},
+	{"SZ", "SWZ", "Eswatini", "268"},
+	{"ET", "ETH", "Ethiopia", "251"},
+	{"FK", "FLK", "Falkland Islands", "500"},
+	{"FO", "FRO", "Faroe Islands", "298"},
+	{"FJ", "FJI", "Fiji", "679"},
+	{"FI", "FIN", "Finland", "358"},
+	{"FR", "FRA", "France", "33"},
+	{"GF", "GUF", "French Guiana", "594"},
+	{"PF", "PYF", "French Polynesia", "689"},
+	{"TF", "ATF", "French Southern Territories", "262"},
+	{"GA", "GAB", "Gabon", "241"},
+	{"GM", "GMB", "Gambia", "220"},
+	{"GE", "GEO", "Georgia", "995"},
+	{"DE", "DEU", "Germany", "49"},
+	{"GH", "GHA", "Ghana", "233"},
+	{"GI", "GIB", "Gibraltar", "350"},
+	{"GR", "GRC", "Greece", "30"},
+	{"GL", "GRL", "Greenland", "299"},
+	{"GD", "GRD", "Grenada", "1"},
+	{"GP", "GLP", "Guadeloupe", "590"},
+	{"GU", "GUM", "Guam", "1"},
+	{"GT", "GTM", "Guatemala", "502"},
+	{"GG", "GGY", "Guernsey", "44"},
+	{"GN", "GIN", "Guinea", "224"},
+	{"GW", "GNB", "Guinea-Bissau", "245"},
+	{"GY", "GUY", "Guyana", "592"},
+	{"HT", "HTI", "Haiti", "509"},
+	{"HM", "HMD", "Heard Island and McDonald Islands", "672"},
+	{"VA", "VAT", "Holy See", "39"},
+	{"HN", "HND", "Honduras", "504"},
+	{"HK", "HKG", "Hong Kong", "852"},
+	{"HU", "HUN", "Hungary", "36"},
+	{"IS", "ISL", "Iceland", "354"},
+	{"IN", "IND", "India", "91"},
+	{"ID", "IDN", "Indonesia", "62"},
+	{"IR", "IRN", "Iran", "98"},
+	{"IQ", "IRQ", "Iraq", "964"},
+	{"IE", "IRL", "Ireland", "353"},
+	{"IM", "IMN", "Isle of Man", "44"},
+	{"IL", "ISR", "Israel", "972"},
+	{"IT", "ITA", "Italy", "39"},
+	{"JM", "JAM", "Jamaica", "1"},
+	{"JP", "JPN", "Japan", "81"},
+	{"JE", "JEY", "Jersey", "44"},
+	{"JO", "JOR", "Jordan", "962"},
+	{"KZ", "KAZ", "Kazakhstan", "7"},
+	{"KE", "KEN", "Kenya", "254"},
+	{"KI", "KIR", "Kiribati", "686"},
+	{"KP", "PRK", "North Korea", "850"},
+	{"KR", "KOR", "South Korea", "82"},
+	{"KW", "KWT", "Kuwait", "965"},
+	{"KG", "KGZ", "Kyrgyzstan", "996"},
+	{"LA", "LAO", "Laos", "856"},
+	{"LV", "LVA", "Latvia", "371"},
+	{"LB", "LBN", "Lebanon", "961"},
+	{"LS", "LSO", "Lesotho", "266"},
+	{"LR", "LBR", "Liberia", "231"},
+	{"LY", "LBY", "Libya", "218"},
+	{"LI", "LIE", "Liechtenstein", "423"},
+	{"LT", "LTU", "Lithuania", "370"},
+	{"LU", "LUX", "Luxembourg", "352"},
+	{"MO", "MAC", "Macao", "853"},
+	{"MG", "MDG", "Madagascar", "261"},
+	{"MW", "MWI", "Malawi", "265"},
+	{"MY", "MYS", "Malaysia", "60"},
+	{"MV", "MDV", "Maldives", "960"},
+	{"ML", "MLI", "Mali", "223"},
+	{"MT", "MLT", "Malta", "356"},
+	{"MH", "MHL", "Marshall Islands", "692"},
+	{"MQ", "MTQ", "Martinique", "596"},
+	{"MR", "MRT", "Mauritania", "222"},
+	{"MU", "MUS", "Mauritius", "230"},
+	{"YT", "MYT", "Mayotte", "262"},
+	{"MX", "MEX", "Mexico", "52"},
+	{"FM", "FSM", "Micronesia", "691"},
+	{"MD", "MDA", "Moldova", "373"},
+	{"MC", "MCO", "Monaco", "377"},
+	{"MN", "MNG", "Mongolia", "976"},
+	{"ME", "MNE", "Montenegro", "382"},
+	{"MS", "MSR", "Montserrat", "1"},
+	{"MA", "MAR", "Morocco", "212"},
+	{"MZ", "MOZ", "Mozambique", "258"},
+	{"MM", "MMR", "Myanmar", "95"},
+	{"NA", "NAM", "Namibia", "264"},
+	{"NR", "NRU", "Nauru", "674"},
+	{"NP", "NPL", "Nepal", "977"},
+	{"NL", "NLD", "Netherlands", "31"},
+	{"NC", "NCL", "New Caledonia", "687"},
+	{"NZ", "NZL", "New Zealand", "64"},
+	{"NI", "NIC", "Nicaragua", "505"},
+	{"NE", "NER", "Niger", "227"},
+	{"NG", "NGA", "Nigeria", "234"},
+	{"NU", "NIU", "Niue", "683"},
+	{"NF", "NFK", "Norfolk Island", "672"},
+	{"MK", "MKD", "North Macedonia", "389"},
+	{"MP", "MNP", "Northern Mariana Islands", "1"},
+	{"NO", "NOR", "Norway", "47"},
+	{"OM", "OMN", "Oman", "968"},
+	{"PK", "PAK", "Pakistan", "92"},
+	{"PW", "PLW", "Palau", "680"},
+	{"PS", "PSE", "Palestine", "970"},
+	{"PA", "PAN", "Panama", "507"},
+	{"PG", "PNG", "Papua New Guinea", "675"},
+	{"PY", "PRY", "Paraguay", "595"},
+	{"PE", "PER", "Peru", "51"},
+	{"PH", "PHL", "Philippines", "63"},
+	{"PN", "PCN", "Pitcairn", "64"},
+	{"PL", "POL", "Poland", "48"},
+	{"PT", "PRT", "Portugal", "351"},
+	{"PR", "PRI", "Puerto Rico", "1"},
+	{"QA", "QAT", "Qatar", "974"},
+	{"RE", "REU", "Reunion", "262"},
+	{"RO", "ROU", "Romania", "40"},
+	{"RU", "RUS", "Russia", "7"},
+	{"RW", "RWA", "Rwanda", "250"},
+	{"BL", "BLM", "Saint Barthelemy", "590"},
+	{"SH", "SHN", "Saint Helena, Ascension and Tristan da Cunha", "290"},
+	{"KN", "KNA", "Saint Kitts and Nevis", "1"},
+	{"LC", "LCA", "Saint Lucia", "1"},
+	{"MF", "MAF", "Saint Martin", "590"},
+	{"PM", "SPM", "Saint Pierre and Miquelon", "508"},
+	{"VC", "VCT", "Saint Vincent and the Grenadines", "1"},
+	{"WS", "WSM", "Samoa", "685"},
+	{"SM", "SMR", "San Marino", "378"},
+	{"ST", "STP", "Sao Tome and Principe", "239"},
+	{"SA", "SAU", "Saudi Arabia", "966"},
+	{"SN", "SEN", "Senegal", "221"},
+	{"RS", "SRB", "Serbia", "381"},
+	{"SC", "SYC", "Seychelles", "248"},
+	{"SL", "SLE", "Sierra Leone", "232"},
+	{"SG", "SGP", "Singapore", "65"},
+	{"SX", "SXM", "Sint Maarten", "1"},
+	{"SK", "SVK", "Slovakia", "421"},
+	{"SI", "SVN", "Slovenia", "386"},
+	{"SB", "SLB", "Solomon Islands", "677"},
+	{"SO", "SOM", "Somalia", "252"},
+	{"ZA", "ZAF", "South Africa", "27"},
+	{"GS", "SGS", "South Georgia and the South Sandwich Islands", "500"},
+	{"SS", "SSD", "South Sudan", "211"},
+	{"ES", "ESP", "Spain", "34"},
+	{"LK", "LKA", "Sri Lanka", "94"},
+	{"SD", "SDN", "Sudan", "249"},
+	{"SR", "SUR", "Suriname", "597"},
+	{"SJ", "SJM", "Svalbard and Jan Mayen", "47"},
+	{"SE", "SWE", "Sweden", "46"},
+	{"CH", "CHE", "Switzerland", "41"},
+	{"SY", "SYR", "Syria", "963"},
+	{"TW", "TWN", "Taiwan", "886"},
+	{"TJ", "TJK", "Tajikistan", "992"},
+	{"TZ", "TZA", "Tanzania", "255"},
+	{"TH", "THA", "Thailand", "66"},
+	{"TL", "TLS", "Timor-Leste", "670"},
+	{"TG", "TGO", "Togo", "228"},
+	{"TK", "TKL", "Tokelau", "690"},
+	{"TO", "TON", "Tonga", "676"},
+	{"TT", "TTO", "Trinidad and Tobago", "1"},
+	{"TN", "TUN", "Tunisia", "216"},
+	{"TR", "TUR", "Turkey", "90"},
+	{"TM", "TKM", "Turkmenistan", "993"},
+	{"TC", "TCA", "Turks and Caicos Islands", "1"},
+	{"TV", "TUV", "Tuvalu", "688"},
+	{"UG", "UGA", "Uganda", "256"},
+	{"UA", "UKR", "Ukraine", "380"},
+	{"AE", "ARE", "United Arab Emirates", "971"},
+	{"GB", "GBR", "United Kingdom", "44"},
+	{"US", "USA", "United States", "1"},
+	{"UM", "UMI", "United States Minor Outlying Islands", "1"},
+	{"UY", "URY", "Uruguay", "598"},
+	{"UZ", "UZB", "Uzbekistan", "998"},
+	{"VU", "VUT", "Vanuatu", "678"},
+	{"VE", "VEN", "Venezuela", "58"},
+	{"VN", "VNM", "Vietnam", "84"},
+	{"VG", "VGB", "British Virgin Islands", "1"},
+	{"VI", "VIR", "U.S. Virgin Islands", "1"},
+	{"WF", "WLF", "Wallis and Futuna", "681"},
+	{"EH", "ESH", "Western Sahara", "212"},
+	{"YE", "YEM", "Yemen", "967"},
+	{"ZM", "ZMB", "Zambia", "260"},
+	{"ZW", "ZWE", "Zimbabwe", "263"},
+}
+
+// countryAliases maps other common names and nationality adjectives to
+// alpha-2 codes. Keys are lower case.
+var countryAliases = map[string]string{
+	// Alternative names
+	"uk": "GB", "great britain": "GB", "britain": "GB", "england": "GB", "scotland": "GB", "wales": "GB",
+	"northern ireland": "GB", "usa": "US", "us": "US", "america": "US", "united states of america": "US",
+	"uae": "AE", "ivory coast": "CI", "cote divoire": "CI", "drc": "CD", "dr congo": "CD",
+	"congo-kinshasa": "CD", "congo-brazzaville": "CG", "republic of the congo": "CG", "cape verde": "CV",
+	"czech republic": "CZ", "swaziland": "SZ", "burma": "MM", "holland": "NL", "the netherlands": "NL",
+	"turkiye": "TR", "türkiye": "TR", "macedonia": "MK", "korea": "KR", "republic of korea": "KR",
+	"russian federation": "RU", "viet nam": "VN", "the gambia": "GM", "east timor": "TL",
+	"vatican": "VA", "vatican city": "VA", "palestinian territories": "PS",
+
+	// Nationalities
+	"afghan": "AF", "albanian": "AL", "algerian": "DZ", "american": "US", "angolan": "AO",
+	"argentine": "AR", "argentinian": "AR", "armenian": "AM", "australian": "AU", "austrian": "AT",
+	"azerbaijani": "AZ", "bahamian": "BS", "bahraini": "BH", "bangladeshi": "BD", "barbadian": "BB",
+	"belarusian": "BY", "belgian": "BE", "belizean": "BZ", "beninese": "BJ", "bhutanese": "BT",
+	"bolivian": "BO", "bosnian": "BA", "botswanan": "BW", "motswana": "BW", "batswana": "BW",
+	"brazilian": "BR", "british": "GB", "english": "GB", "scottish": "GB", "welsh": "GB",
+	"bruneian": "BN", "bulgarian": "BG", "burkinabe": "BF", "burundian": "BI", "cambodian": "KH",
+	"cameroonian": "CM", "canadian": "CA", "cape verdean": "CV", "central african": "CF", "chadian": "TD",
+	"chilean": "CL", "chinese": "CN", "colombian": "CO", "comoran": "KM", "congolese": "CD",
+	"costa rican": "CR", "croatian": "HR", "cuban": "CU", "cypriot": "CY", "czech": "CZ",
+	"danish": "DK", "djiboutian": "DJ", "dominican": "DO", "dutch": "NL", "ecuadorian": "EC",
+	"egyptian": "EG", "emirati": "AE", "equatorial guinean": "GQ", "eritrean": "ER", "estonian": "EE",
+	"ethiopian": "ET", "fijian": "FJ", "filipino": "PH", "finnish": "FI", "french": "FR",
+	"gabonese": "GA", "gambian": "GM", "georgian": "GE", "german": "DE", "ghanaian": "GH",
+	"greek": "GR", "grenadian": "GD", "guatemalan": "GT", "guinean": "GN", "guyanese": "GY",
+	"haitian": "HT", "honduran": "HN", "hungarian": "HU", "icelandic": "IS", "indian": "IN",
+	"indonesian": "ID", "iranian": "IR", "iraqi": "IQ", "irish": "IE", "israeli": "IL",
+	"italian": "IT", "ivorian": "CI", "jamaican": "JM", "japanese": "JP", "jordanian": "JO",
+	"kazakh": "KZ", "kenyan": "KE", "korean": "KR", "south korean": "KR", "north korean": "KP",
+	"kuwaiti": "KW", "kyrgyz": "KG", "lao": "LA", "laotian": "LA", "latvian": "LV",
+	"lebanese": "LB", "liberian": "LR", "libyan": "LY", "lithuanian": "LT", "luxembourgish": "LU",
+	"malagasy": "MG", "malawian": "MW", "malaysian": "MY", "maldivian": "MV", "malian": "ML",
+	"maltese": "MT", "mauritanian": "MR", "mauritian": "MU", "mexican": "MX", "moldovan": "MD",
+	"mongolian": "MN", "montenegrin": "ME", "moroccan": "MA", "mozambican": "MZ", "namibian": "NA",
+	"nepalese": "NP", "nepali": "NP", "new zealander": "NZ", "kiwi": "NZ", "nicaraguan": "NI",
+	"nigerien": "NE", "nigerian": "NG", "norwegian": "NO", "omani": "OM", "pakistani": "PK",
+	"palestinian": "PS", "panamanian": "PA", "paraguayan": "PY", "peruvian": "PE", "polish": "PL",
+	"portuguese": "PT", "puerto rican": "PR", "qatari": "QA", "romanian": "RO", "russian": "RU",
+	"rwandan": "RW", "salvadoran": "SV", "saudi": "SA", "saudi arabian": "SA", "senegalese": "SN",
+	"serbian": "RS", "seychellois": "SC", "sierra leonean": "SL", "singaporean": "SG",
+	"slovak": "SK", "slovenian": "SI", "somali": "SO", "south african": "ZA", "south sudanese": "SS",
+	"spanish": "ES", "sri lankan": "LK", "sudanese": "SD", "surinamese": "SR", "swazi": "SZ",
+	"swedish": "SE", "swiss": "CH", "syrian": "SY", "taiwanese": "TW", "tajik": "TJ",
+	"tanzanian": "TZ", "thai": "TH", "togolese": "TG", "trinidadian": "TT", "tobagonian": "TT",
+	"tunisian": "TN", "turkish": "TR", "turkmen": "TM", "ugandan": "UG", "ukrainian": "UA",
+	"uruguayan": "UY", "uzbek": "UZ", "venezuelan": "VE", "vietnamese": "VN", "yemeni": "YE",
+	"zambian": "ZM", "zimbabwean": "ZW",
+}
+
+// LookupCountry resolves a country name, nationality, or ISO alpha-2/alpha-3
+// code to a country
+func LookupCountry(input string) (Country, bool) {
+	key := strings.ToLower(strings.Join(strings.Fields(input), " "))
+	key = strings.ReplaceAll(key, "'", "")
+	if key == "" {
+		return Country{}, false
+	}
+
+	if code, ok := countryAliases[key]; ok {
+		key = strings.ToLower(code)
+	}
+
+	for _, country := range Countries {
+		if key == strings.ToLower(country.Alpha2) || key == strings.ToLower(country.Alpha3) ||
+			key == strings.ToLower(strings.ReplaceAll(country.Name, "'", "")) {
+			return country, true
+		}
+	}
+
+	return Country{}, false
+}
@@ -0,0 +1,36 @@
+package utils
+
+import "testing"
+
+func TestLookupCountry(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"NG", "NG"},
+		{"nga", "NG"},
+		{"Nigeria", "NG"},
+		{"  nigerian ", "NG"},
+		{"United   Kingdom", "GB"},
+		{"British", "GB"},
+		{"Cote dIvoire", "CI"},
+		{"new zealander", "NZ"},
+	}
+
+	for _, tt := range tests {
+		got, ok := LookupCountry(tt.input)
+		if !ok {
+			t.Errorf("LookupCountry(%q) found nothing, want %s", tt.input, tt.want)
+			continue
+		}
+		if got.Alpha2 != tt.want {
+			t.Errorf("LookupCountry(%q) = %s, want %s", tt.input, got.Alpha2, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "   ", "Atlantis", "N"} {
+		if got, ok := LookupCountry(input); ok {
+			t.Errorf("LookupCountry(%q) = %s, want no match", input, got.Alpha2)
+		}
+	}
+}
@@ -8,6 +8,12 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Token audiences keep admin and customer tokens apart
+const (
+	AdminAudience    = "admin"
+	CustomerAudience = "customer"
+)
+
 // Claims represents JWT claims
 type Claims struct {
 	AdminID int    `json:"admin_id"`
@@ -21,6 +27,7 @@ func GenerateToken(adminID int, email string, expiry time.Duration) (string, err
 		AdminID: adminID,
 		Email:   email,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{AdminAudience},
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
@@ -30,7 +37,8 @@ func GenerateToken(adminID int, email string, expiry time.Duration) (string, err
 	return token.SignedString([]byte(config.AppConfig.JWTSecret))
 }
 
-// ValidateToken validates and parses a JWT token
+// ValidateToken validates and parses an admin JWT token. Customer tokens are
+// rejected.
 func ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -43,9 +51,79 @@ func ValidateToken(tokenString string) (*Claims, error) {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	// Tokens issued before audiences were added have none, so check for the
+	// customer audience rather than requiring the admin one
+	for _, audience := range claims.Audience {
+		if audience == CustomerAudience {
+			return nil, errors.New("not an admin token")
+		}
+	}
+	if claims.AdminID == 0 {
+		return nil, errors.New("not an admin token")
+	}
+
+	return claims, nil
+}
+
+// Customer token types keep access tokens and refresh tokens apart, so a
+// long-lived refresh token cannot be used to call the API directly
+const (
+	CustomerAccessToken  = "access"
+	CustomerRefreshToken = "refresh"
+)
+
+// CustomerClaims represents the JWT claims of a customer account
+type CustomerClaims struct {
+	CustomerID int    `json:"customer_id"`
+	Email      string `json:"email"`
+	Type       string `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+// GenerateCustomerToken creates a JWT token of the given type for a customer
+// account
+func GenerateCustomerToken(customerID int, email, tokenType string, expiry time.Duration) (string, error) {
+	claims := CustomerClaims{
+		CustomerID: customerID,
+		Email:      email,
+		Type:       tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{CustomerAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(config.AppConfig.JWTSecret))
+}
+
+// ValidateCustomerToken validates and parses a customer JWT token of the given
+// type. Admin tokens and customer tokens of the other type are rejected.
+func ValidateCustomerToken(tokenString, tokenType string) (*CustomerClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &CustomerClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return []byte(config.AppConfig.JWTSecret), nil
+	}, jwt.WithAudience(CustomerAudience))
+
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*CustomerClaims)
+	if !ok || !token.Valid || claims.CustomerID == 0 {
+		return nil, errors.New("invalid token")
+	}
+	if claims.Type != tokenType {
+		return nil, errors.New("not a customer " + tokenType + " token")
 	}
 
-	return nil, errors.New("invalid token")
+	return claims, nil
 }
\ No newline at end of file
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"plantbased-backend/config"
+	"testing"
+	"time"
+)
+
+func TestCustomerTokens(t *testing.T) {
+	config.AppConfig = &config.Config{JWTSecret: "test-secret"}
+
+	customer, err := GenerateCustomerToken(7, "ada@example.com", CustomerAccessToken, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims, err := ValidateCustomerToken(customer, CustomerAccessToken); err != nil || claims.CustomerID != 7 {
+		t.Errorf("customer token = %v, %v; want customer 7", claims, err)
+	}
+	if _, err := ValidateToken(customer); err == nil {
+		t.Error("customer token accepted as an admin token")
+	}
+
+	admin, err := GenerateToken(7, "ada@example.com", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims, err := ValidateToken(admin); err != nil || claims.AdminID != 7 {
+		t.Errorf("admin token = %v, %v; want admin 7", claims, err)
+	}
+	if _, err := ValidateCustomerToken(admin, CustomerAccessToken); err == nil {
+		t.Error("admin token accepted as a customer token")
+	}
+
+	expired, err := GenerateCustomerToken(7, "ada@example.com", CustomerAccessToken, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateCustomerToken(expired, CustomerAccessToken); err == nil {
+		t.Error("expired customer token accepted")
+	}
+}
+
+func TestCustomerTokenTypes(t *testing.T) {
+	config.AppConfig = &config.Config{JWTSecret: "test-secret"}
+
+	access, err := GenerateCustomerToken(7, "ada@example.com", CustomerAccessToken, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refresh, err := GenerateCustomerToken(7, "ada@example.com", CustomerRefreshToken, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claims, err := ValidateCustomerToken(refresh, CustomerRefreshToken); err != nil || claims.CustomerID != 7 {
+		t.Errorf("refresh token as refresh = %v, %v; want customer 7", claims, err)
+	}
+	if _, err := ValidateCustomerToken(refresh, CustomerAccessToken); err == nil {
+		t.Error("refresh token accepted as an access token")
+	}
+	if _, err := ValidateCustomerToken(access, CustomerRefreshToken); err == nil {
+		t.Error("access token accepted as a refresh token")
+	}
+}
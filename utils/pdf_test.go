@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPDFBytes(t *testing.T) {
+	pdf := NewPDF()
+	pdf.Text(50, 70, 20, true, "INVOICE")
+	pdf.Line(50, 258, 545, 258, 0.8)
+	out := pdf.Bytes()
+
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatalf("not a PDF document:\n%s", out)
+	}
+	if !bytes.Contains(out, []byte("(INVOICE) Tj")) || !bytes.Contains(out, []byte("/F2 20.0 Tf")) {
+		t.Error("text missing from the content stream")
+	}
+
+	// The cross-reference table must point at each object
+	match := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	if match == nil {
+		t.Fatal("no startxref")
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(out[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+	entries := strings.Split(string(out[xref:]), "\n")[3:9]
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(entry[:10])
+		if want := fmt.Sprintf("%d 0 obj", i+1); !bytes.HasPrefix(out[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q, want %q", i+1, out[offset:offset+len(want)], want)
+		}
+	}
+}
+
+func TestPDFEscape(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"Plan (monthly)", `Plan \(monthly\)`},
+		{`C:\path`, `C:\\path`},
+		{"two\nlines", "two lines"},
+		{"Café", `Caf\351`},
+		{"₦25,000", "?25,000"},
+	}
+
+	for _, tt := range tests {
+		if got := pdfEscape(tt.text); got != tt.want {
+			t.Errorf("pdfEscape(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
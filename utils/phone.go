@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+)
+
+// NormalizePhone converts a phone number to E.164 (e.g. "+2348031234567").
+// Numbers written without an international prefix are read as national
+// numbers of the given country, dropping the leading trunk "0".
+func NormalizePhone(raw string, country *Country) (string, error) {
+	var digits strings.Builder
+	international := false
+
+	for i, r := range strings.TrimSpace(raw) {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+			international = true
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+			// Formatting characters
+		default:
+			return "", errors.New("phone number contains invalid characters")
+		}
+	}
+
+	number := digits.String()
+	switch {
+	case international:
+	case strings.HasPrefix(number, "00"):
+		number = number[2:]
+	case country != nil:
+		number = country.CallingCode + strings.TrimPrefix(number, "0")
+	default:
+		return "", errors.New("phone number must include the country code, e.g. +234")
+	}
+
+	// E.164 allows at most 15 digits; anything under 8 is not a real subscriber number
+	if len(number) < 8 || len(number) > 15 || number[0] == '0' {
+		return "", errors.New("phone number is not a valid international number")
+	}
+
+	return "+" + number, nil
+}
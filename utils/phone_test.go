@@ -0,0 +1,50 @@
+package utils
+
+import "testing"
+
+func TestNormalizePhone(t *testing.T) {
+	nigeria, _ := LookupCountry("NG")
+	uk, _ := LookupCountry("GB")
+
+	tests := []struct {
+		raw     string
+		country *Country
+		want    string
+	}{
+		{"08031234567", &nigeria, "+2348031234567"},
+		{"0803 123 4567", &nigeria, "+2348031234567"},
+		{"+234 (803) 123-4567", &nigeria, "+2348031234567"},
+		{"+447911123456", &nigeria, "+447911123456"},
+		{"00447911123456", nil, "+447911123456"},
+		{"07911 123456", &uk, "+447911123456"},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizePhone(tt.raw, tt.country)
+		if err != nil {
+			t.Errorf("NormalizePhone(%q) error = %v", tt.raw, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizePhone(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+
+	invalid := []struct {
+		raw     string
+		country *Country
+	}{
+		{"08031234567", nil},              // National number without a country
+		{"0803-123-4567 ext 2", &nigeria}, // Letters
+		{"234+8031234567", &nigeria},      // Plus sign not at the start
+		{"+1234", nil},                    // Too short
+		{"+1234567890123456", nil},        // Longer than E.164 allows
+		{"+0123456789", nil},              // Calling codes never start with 0
+	}
+
+	for _, tt := range invalid {
+		if got, err := NormalizePhone(tt.raw, tt.country); err == nil {
+			t.Errorf("NormalizePhone(%q) = %q, want an error", tt.raw, got)
+		}
+	}
+}
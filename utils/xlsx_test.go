@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+	x, err := NewXLSXWriter(&buf, "Customers & Leads")
+	if err != nil {
+		t.Fatalf("NewXLSXWriter error = %v", err)
+	}
+	rows := [][]string{
+		{"Full Name", "Email"},
+		{"Adaeze <Ada> Okafor", " ada@example.com "},
+	}
+	for _, row := range rows {
+		if err := x.WriteRow(row); err != nil {
+			t.Fatalf("WriteRow error = %v", err)
+		}
+	}
+	if err := x.Close(); err != nil {
+		t.Fatalf("Close error = %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("output is not a zip archive: %v", err)
+	}
+
+	parts := map[string]string{}
+	for _, file := range archive.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", file.Name, err)
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", file.Name, err)
+		}
+		parts[file.Name] = string(content)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("workbook has no %s", name)
+		}
+	}
+
+	if !strings.Contains(parts["xl/workbook.xml"], `name="Customers &amp; Leads"`) {
+		t.Errorf("sheet name not escaped: %s", parts["xl/workbook.xml"])
+	}
+
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	if got := strings.Count(sheet, "<row>"); got != len(rows) {
+		t.Errorf("sheet has %d rows, want %d", got, len(rows))
+	}
+	if !strings.Contains(sheet, "Adaeze &lt;Ada&gt; Okafor") || !strings.Contains(sheet, `xml:space="preserve"> ada@example.com </t>`) {
+		t.Errorf("cell values not escaped or preserved: %s", sheet)
+	}
+	if !strings.HasSuffix(sheet, "</sheetData></worksheet>") {
+		t.Errorf("sheet not closed: %s", sheet)
+	}
+}